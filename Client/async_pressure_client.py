@@ -4,6 +4,8 @@ import time
 import random
 import statistics
 
+import msgid
+
 from internal_pb.internal_pb2 import Envelope
 from internal_pb.gate_pb2 import ResumeReq, SessionInit
 from internal_pb.login_pb2 import LoginReq, LoginRsp
@@ -13,20 +15,21 @@ from internal_pb.game_pb2 import LoadPlayerDataReq, LoadPlayerDataRsp, PlayerIni
 # =====================
 # Msg IDs
 # =====================
-MSG_RESUME_REQ = 1
-MSG_RESUME_RSP = 2
-MSG_SESSION_INIT = 3
+# 由 msgid.json 加载，见 msgid.py
+MSG_RESUME_REQ = msgid.MSG_RESUME_REQ
+MSG_RESUME_RSP = msgid.MSG_RESUME_RSP
+MSG_SESSION_INIT = msgid.MSG_SESSION_INIT
 
-MSG_HEARTBEAT_REQ = 10
-MSG_HEARTBEAT_RSP = 11
+MSG_HEARTBEAT_REQ = msgid.MSG_HEARTBEAT_REQ
+MSG_HEARTBEAT_RSP = msgid.MSG_HEARTBEAT_RSP
 
-MSG_LOGIN_REQ = 1001
-MSG_LOGIN_RSP = 1002
+MSG_LOGIN_REQ = msgid.MSG_LOGIN_REQ
+MSG_LOGIN_RSP = msgid.MSG_LOGIN_RSP
 
-MSG_ENTER_GAME_RSP = 3002
-MSG_LOAD_PLAYER_DATA_REQ = 3003
-MSG_LOAD_PLAYER_DATA_RSP = 3004
-MSG_PLAYER_OFFLINE_NOTIFY = 3006
+MSG_ENTER_GAME_RSP = msgid.MSG_PLAYER_ENTER_GAME_RSP
+MSG_LOAD_PLAYER_DATA_REQ = msgid.MSG_LOAD_PLAYER_DATA_REQ
+MSG_LOAD_PLAYER_DATA_RSP = msgid.MSG_LOAD_PLAYER_DATA_RSP
+MSG_PLAYER_OFFLINE_NOTIFY = msgid.MSG_PLAYER_OFFLINE_NOTIFY
 
 
 # =====================
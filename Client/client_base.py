@@ -5,24 +5,29 @@ import time
 import websocket
 
 from google.protobuf import json_format
+
+import msgid
 from internal_pb.internal_pb2 import Envelope
-from internal_pb.gate_pb2 import ResumeReq, SessionInit
+from internal_pb.gate_pb2 import ResumeReq, ResumeRsp, SessionInit
+from internal_pb.error_pb2 import ErrorRsp
 from internal_pb.login_pb2 import LoginReq, LoginRsp
 from internal_pb.game_pb2 import LoadPlayerDataReq, LoadPlayerDataRsp, PlayerInitRsp
 
-MSG_RESUME_REQ = 1
-MSG_RESUME_RSP = 2
-MSG_SESSION_INIT = 3
-
-MSG_HEARTBEAT_REQ = 10
-MSG_HEARTBEAT_RSP = 11
-MSG_LOGIN_REQ = 1001
-MSG_LOGIN_RSP = 1002
-MSG_ENTER_GAME_REQ = 3001
-MSG_ENTER_GAME_RSP = 3002
-MSG_LOAD_PLAYER_DATA_REQ = 3003
-MSG_LOAD_PLAYER_DATA_RSP = 3004
-MSG_PLAYER_OFFLINE_NOTIFY = 3006
+# msgID 来自 msgid.json（protoc-gen-msgid 生成），不再在客户端手写
+MSG_RESUME_REQ = msgid.MSG_RESUME_REQ
+MSG_RESUME_RSP = msgid.MSG_RESUME_RSP
+MSG_SESSION_INIT = msgid.MSG_SESSION_INIT
+
+MSG_HEARTBEAT_REQ = msgid.MSG_HEARTBEAT_REQ
+MSG_HEARTBEAT_RSP = msgid.MSG_HEARTBEAT_RSP
+MSG_ERROR_RSP = msgid.MSG_ERROR_RSP
+MSG_LOGIN_REQ = msgid.MSG_LOGIN_REQ
+MSG_LOGIN_RSP = msgid.MSG_LOGIN_RSP
+MSG_ENTER_GAME_REQ = msgid.MSG_PLAYER_ENTER_GAME_REQ
+MSG_ENTER_GAME_RSP = msgid.MSG_PLAYER_ENTER_GAME_RSP
+MSG_LOAD_PLAYER_DATA_REQ = msgid.MSG_LOAD_PLAYER_DATA_REQ
+MSG_LOAD_PLAYER_DATA_RSP = msgid.MSG_LOAD_PLAYER_DATA_RSP
+MSG_PLAYER_OFFLINE_NOTIFY = msgid.MSG_PLAYER_OFFLINE_NOTIFY
 
 # =====================
 # Client
@@ -41,6 +46,7 @@ class GameClient:
         self.session_id = 0
         self.player_id = 0
         self.token = ""
+        self.last_seq = 0
 
         self.lock = threading.Lock()
 
@@ -140,6 +146,7 @@ class GameClient:
         req = ResumeReq(
             session_id=self.session_id,
             token=self.token,
+            last_seq=self.last_seq,
         )
         print("[Client] send ResumeReq")
         self.send_envelope(MSG_RESUME_REQ, req.SerializeToString())
@@ -248,6 +255,11 @@ class GameClient:
     # Message
     # -----------------
     def on_message(self, env: Envelope):
+        if env.seq:
+            if self.last_seq and env.seq != self.last_seq + 1:
+                print(f"[Client] seq gap {self.last_seq} -> {env.seq}")
+            self.last_seq = env.seq
+
         if env.msg_id == MSG_SESSION_INIT:
             init = SessionInit()
             init.ParseFromString(env.payload)
@@ -269,12 +281,21 @@ class GameClient:
             print(f"[Client] EnterGameRsp role={rsp.data.role_id}")
 
         elif env.msg_id == MSG_RESUME_RSP:
-            print("[Client] ResumeRsp OK")
+            rsp = ResumeRsp()
+            rsp.ParseFromString(env.payload)
+            print(f"[Client] ResumeRsp ok={rsp.ok} full_reload={rsp.full_reload}")
+            if rsp.ok and rsp.full_reload:
+                self.load_player_data()
             self.start_heartbeat()
 
         elif env.msg_id == MSG_HEARTBEAT_RSP:
             pass
 
+        elif env.msg_id == MSG_ERROR_RSP:
+            rsp = ErrorRsp()
+            rsp.ParseFromString(env.payload)
+            print(f"[Client] ErrorRsp code={rsp.code} msg={rsp.message}")
+
         elif env.msg_id == MSG_LOAD_PLAYER_DATA_RSP:
             print(f"[Client] MSG_LOAD_PLAYER_DATA_RSP role={env.player_id}")
             rsp = LoadPlayerDataRsp()
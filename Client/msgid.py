@@ -0,0 +1,48 @@
+import importlib
+import json
+import os
+import re
+
+# =====================
+# Msg IDs
+# =====================
+# msgid.json 由 protoc-gen-msgid 按 .proto 里的 (msg_id) 标注生成，不要手改；
+# 这里把 MsgLoginReq 转成 MSG_LOGIN_REQ 之类的模块常量，与服务端 protocol 包保持一致
+
+_MANIFEST = os.path.join(os.path.dirname(os.path.abspath(__file__)), "msgid.json")
+
+
+def _const_name(name):
+    # MsgDBNextUIDReq -> MSG_DB_NEXT_UID_REQ
+    s = re.sub(r"([A-Z]+)([A-Z][a-z])", r"\1_\2", name)
+    s = re.sub(r"([a-z0-9])([A-Z])", r"\1_\2", s)
+    return s.upper()
+
+
+with open(_MANIFEST, encoding="utf-8") as f:
+    _manifest = json.load(f)
+
+RANGES = {r["name"]: (r["begin"], r["end"]) for r in _manifest["ranges"]}
+MESSAGES = {m["id"]: m for m in _manifest["messages"]}
+
+for _m in _manifest["messages"]:
+    globals()[_const_name(_m["name"])] = _m["id"]
+
+
+def name_of(msg_id):
+    m = MESSAGES.get(msg_id)
+    return m["name"] if m else f"Unknown({msg_id})"
+
+
+def reply_of(msg_id):
+    m = MESSAGES.get(msg_id)
+    return m.get("reply", 0) if m else 0
+
+
+def message_class(msg_id):
+    """按 msgID 取 internal_pb 里的消息类，未知 msgID 返回 None"""
+    m = MESSAGES.get(msg_id)
+    if m is None:
+        return None
+    module = importlib.import_module("internal_pb." + m["proto"][: -len(".proto")] + "_pb2")
+    return getattr(module, m["type"].rsplit(".", 1)[-1])
@@ -1 +1,132 @@
-package db
+// cmd/db/main.go
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"game-server/internal/common/logging"
+	"game-server/internal/config"
+	"game-server/internal/db"
+	"game-server/internal/db/redis_tools"
+	"game-server/internal/metrics"
+	"game-server/internal/player_db"
+	"game-server/internal/protocol/msgtype"
+	"game-server/internal/transport"
+	"go.uber.org/zap"
+)
+
+func main() {
+	var configPath string
+	flag.StringVar(&configPath, "config", "configs/db.yaml", "db config path")
+	flag.Parse()
+
+	logger, err := logging.NewLogger("db")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() {
+		_ = logger.Sync()
+	}()
+
+	var cfg config.DBConfig
+	if err := config.Load(configPath, &cfg); err != nil {
+		logger.Error("load config failed",
+			zap.String("reason", err.Error()),
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+		)
+		os.Exit(1)
+	}
+	if cfg.MaxEnvelopeSize > 0 {
+		transport.SetMaxEnvelopeSize(cfg.MaxEnvelopeSize)
+	}
+	// ⭐ msgID ↔ 类型表有重复 / 缺失时拒绝启动
+	msgtype.MustValidate(logger)
+	connOptions := transport.ConnOptions{
+		ReadTimeout:  time.Duration(cfg.ConnReadTimeoutSec) * time.Second,
+		WriteTimeout: time.Duration(cfg.ConnWriteTimeoutSec) * time.Second,
+		KeepAlive:    time.Duration(cfg.ConnKeepAliveSec) * time.Second,
+	}
+	internalTLS, err := transport.TLSFromConfig(cfg.InternalTLS, tls.RequireAndVerifyClientCert)
+	if err != nil {
+		logger.Error("load internal tls failed",
+			zap.String("reason", err.Error()),
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+		)
+		os.Exit(1)
+	}
+	connOptions.TLS = internalTLS
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if internalTLS != nil {
+		go transport.WatchTLSReload(ctx, logger, internalTLS)
+	}
+
+	// ⭐ 只有 DB 进程持有 Redis 凭据
+	if err := redis_tools.InitRedis(redis_tools.RedisConfig{
+		Addr:         cfg.Redis.Addr,
+		Password:     cfg.Redis.Password,
+		DB:           cfg.Redis.DB,
+		PoolSize:     cfg.Redis.PoolSize,
+		MinIdleConns: cfg.Redis.MinIdleConns,
+	}); err != nil {
+		log.Fatalf("init redis failed: %v", err)
+	}
+	redis_tools.StartHealthCheck(ctx, logger, time.Duration(cfg.Redis.HealthCheckSec)*time.Second)
+
+	dao := redis_tools.NewRedisDao()
+	server := db.NewServer(cfg.ListenAddr, player_db.NewRedisStore(dao), dao, logger, connOptions)
+
+	if cfg.MetricsListenAddr != "" {
+		go func() {
+			if err := metrics.ListenAndServe(ctx, cfg.MetricsListenAddr); err != nil {
+				logger.Error("metrics listen failed",
+					zap.String("reason", err.Error()),
+					zap.Int("msg_id", 0),
+					zap.Int64("session", 0),
+					zap.Int64("player", 0),
+					zap.Int64("conn_id", 0),
+					zap.String("trace_id", ""),
+				)
+			}
+		}()
+		logger.Info("db listening (metrics)",
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.String("reason", ""),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+			zap.String("addr", cfg.MetricsListenAddr),
+		)
+	}
+
+	logger.Info("db listening",
+		zap.Int("msg_id", 0),
+		zap.Int64("session", 0),
+		zap.Int64("player", 0),
+		zap.String("reason", ""),
+		zap.Int64("conn_id", 0),
+		zap.String("trace_id", ""),
+		zap.String("addr", cfg.ListenAddr),
+	)
+	if err := server.ListenAndServe(ctx); err != nil {
+		log.Fatal(err)
+	}
+}
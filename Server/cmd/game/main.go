@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"flag"
 	"game-server/internal/db/redis_tools"
 	"log"
@@ -13,7 +14,10 @@ import (
 	"game-server/internal/common/logging"
 	"game-server/internal/config"
 	"game-server/internal/game"
+	"game-server/internal/game/player_module"
+	"game-server/internal/metrics"
 	"game-server/internal/player_db"
+	"game-server/internal/protocol/msgtype"
 	"game-server/internal/transport"
 	"go.uber.org/zap"
 )
@@ -46,29 +50,77 @@ func main() {
 	if cfg.MaxEnvelopeSize > 0 {
 		transport.SetMaxEnvelopeSize(cfg.MaxEnvelopeSize)
 	}
+	// ⭐ msgID ↔ 类型表有重复 / 缺失时拒绝启动
+	msgtype.MustValidate(logger, player_module.CheckModules)
 	connOptions := transport.ConnOptions{
 		ReadTimeout:  time.Duration(cfg.ConnReadTimeoutSec) * time.Second,
 		WriteTimeout: time.Duration(cfg.ConnWriteTimeoutSec) * time.Second,
 		KeepAlive:    time.Duration(cfg.ConnKeepAliveSec) * time.Second,
 	}
+	internalTLS, err := transport.TLSFromConfig(cfg.InternalTLS, tls.RequireAndVerifyClientCert)
+	if err != nil {
+		logger.Error("load internal tls failed",
+			zap.String("reason", err.Error()),
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+		)
+		os.Exit(1)
+	}
+	connOptions.TLS = internalTLS
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	// 2️⃣ 初始化 Redis（关键）
-	if err := redis_tools.InitRedis(redis_tools.RedisConfig{
-		Addr:         cfg.Redis.Addr,
-		Password:     cfg.Redis.Password,
-		DB:           cfg.Redis.DB,
-		PoolSize:     cfg.Redis.PoolSize,
-		MinIdleConns: cfg.Redis.MinIdleConns,
-	}); err != nil {
-		log.Fatalf("init redis failed: %v", err)
+	if internalTLS != nil {
+		go transport.WatchTLSReload(ctx, logger, internalTLS)
 	}
-	redis_tools.StartHealthCheck(ctx, logger, time.Duration(cfg.Redis.HealthCheckSec)*time.Second)
 
-	playerStore := player_db.NewRedisStore(redis_tools.NewRedisDao())
+	// 2️⃣ 存储：配置了 db_addr 时走 DB 代理，否则直连 Redis
+	var playerStore player_db.Store
+	if cfg.DBAddr != "" {
+		playerStore = player_db.NewRemoteStore(cfg.DBAddr, connOptions, time.Duration(cfg.DBTimeoutMs)*time.Millisecond, logger)
+		logger.Info("player store via db proxy", zap.String("addr", cfg.DBAddr))
+	} else {
+		if err := redis_tools.InitRedis(redis_tools.RedisConfig{
+			Addr:         cfg.Redis.Addr,
+			Password:     cfg.Redis.Password,
+			DB:           cfg.Redis.DB,
+			PoolSize:     cfg.Redis.PoolSize,
+			MinIdleConns: cfg.Redis.MinIdleConns,
+		}); err != nil {
+			log.Fatalf("init redis failed: %v", err)
+		}
+		redis_tools.StartHealthCheck(ctx, logger, time.Duration(cfg.Redis.HealthCheckSec)*time.Second)
+		playerStore = player_db.NewRedisStore(redis_tools.NewRedisDao())
+	}
 	server := game.NewServer(cfg.ListenAddr, playerStore, logger, connOptions, 30*time.Second)
+	if cfg.MetricsListenAddr != "" {
+		go func() {
+			if err := metrics.ListenAndServe(ctx, cfg.MetricsListenAddr); err != nil {
+				logger.Error("metrics listen failed",
+					zap.String("reason", err.Error()),
+					zap.Int("msg_id", 0),
+					zap.Int64("session", 0),
+					zap.Int64("player", 0),
+					zap.Int64("conn_id", 0),
+					zap.String("trace_id", ""),
+				)
+			}
+		}()
+		logger.Info("game listening (metrics)",
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.String("reason", ""),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+			zap.String("addr", cfg.MetricsListenAddr),
+		)
+	}
+
 	logger.Info("game listening",
 		zap.Int("msg_id", 0),
 		zap.Int64("session", 0),
@@ -3,18 +3,27 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"game-server/internal/common/logging"
 	"game-server/internal/config"
+	"game-server/internal/db/redis_tools"
 	"game-server/internal/gate"
+	"game-server/internal/metrics"
+	"game-server/internal/protocol/internalpb"
+	"game-server/internal/protocol/msgtype"
+	"game-server/internal/router"
 	"game-server/internal/transport"
 	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
@@ -22,9 +31,19 @@ import (
 
 func main() {
 	var configPath string
+	var dumpMessages bool
 	flag.StringVar(&configPath, "config", "configs/gate.yaml", "gate config path")
+	flag.BoolVar(&dumpMessages, "dump-messages", false, "print the msgID table as JSON and exit")
 	flag.Parse()
 
+	// 给客户端团队对照协议用，不加载配置
+	if dumpMessages {
+		if err := msgtype.Dump(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	logger, err := logging.NewLogger("gate")
 	if err != nil {
 		log.Fatal(err)
@@ -48,6 +67,8 @@ func main() {
 	if cfg.MaxEnvelopeSize > 0 {
 		transport.SetMaxEnvelopeSize(cfg.MaxEnvelopeSize)
 	}
+	// ⭐ msgID ↔ 类型表有重复 / 缺失时拒绝启动
+	msgtype.MustValidate(logger)
 
 	// ========== 基础上下文 & 信号 ==========
 	ctx, cancel := context.WithCancel(context.Background())
@@ -56,6 +77,10 @@ func main() {
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
+	// SIGHUP：重新加载配置里的 resume key（新增 / 退役）和 TLS 证书，无需重启
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+
 	// ⭐ MOD 1️⃣：启动错误通道（用于 WS / TCP 启动失败）
 	errCh := make(chan error, 1)
 
@@ -65,7 +90,35 @@ func main() {
 		ReadTimeout:  time.Duration(cfg.ConnReadTimeoutSec) * time.Second,
 		WriteTimeout: time.Duration(cfg.ConnWriteTimeoutSec) * time.Second,
 		KeepAlive:    time.Duration(cfg.ConnKeepAliveSec) * time.Second,
+		WriteBatch:   cfg.WriteCoalesce.MaxBatch,
+		WriteDelay:   time.Duration(cfg.WriteCoalesce.MaxDelayUs) * time.Microsecond,
+	}
+	// ⭐ 两套证书：tls 面向客户端，internal_tls 用于连 service / game（mTLS）
+	clientTLS, err := transport.TLSFromConfig(cfg.TLS, tls.NoClientCert)
+	if err != nil {
+		logger.Error("load tls failed",
+			zap.String("reason", err.Error()),
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+		)
+		os.Exit(1)
 	}
+	internalTLS, err := transport.TLSFromConfig(cfg.InternalTLS, tls.RequireAndVerifyClientCert)
+	if err != nil {
+		logger.Error("load internal tls failed",
+			zap.String("reason", err.Error()),
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+		)
+		os.Exit(1)
+	}
+	connOptions.TLS = internalTLS
 	g.UpdateConfig(
 		time.Duration(cfg.HeartbeatIntervalSec)*time.Second,
 		time.Duration(cfg.HeartbeatTimeoutSec)*time.Second,
@@ -76,12 +129,137 @@ func main() {
 		cfg.UnknownMsgKickCount,
 		connOptions,
 	)
+	if _, err := router.LoadFile(cfg.RoutesPath); err != nil {
+		logger.Error("load routes failed",
+			zap.String("reason", err.Error()),
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+		)
+		os.Exit(1)
+	}
+	g.SetRateLimits(loadRateLimits(cfg.RateLimit.Rules), cfg.RateLimit.KickCount,
+		time.Duration(cfg.RateLimit.KickWindowSec)*time.Second)
+	if err := applyIPFilter(g, cfg.IPFilter); err != nil {
+		logger.Error("load ip filter failed",
+			zap.String("reason", err.Error()),
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+		)
+		os.Exit(1)
+	}
+	resumeKeys, err := loadResumeKeys(cfg.ResumeKeys)
+	if err != nil {
+		logger.Error("load resume keys failed",
+			zap.String("reason", err.Error()),
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+		)
+		os.Exit(1)
+	}
+	if err := g.SetResumeKeys(resumeKeys, time.Duration(cfg.ResumeTokenTTLSec)*time.Second); err != nil {
+		logger.Error("set resume keys failed",
+			zap.String("reason", err.Error()),
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+		)
+		os.Exit(1)
+	}
+	g.SetReplayBufferSize(cfg.ReplayBufferSize)
+	compressions, err := loadCompressions(cfg.Compression)
+	if err != nil {
+		logger.Error("load compression failed",
+			zap.String("reason", err.Error()),
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+		)
+		os.Exit(1)
+	}
+	g.SetCompression(compressions)
+	transport.SetCompressThreshold(cfg.Compression.Threshold)
+	g.SetDeliveryPolicy(loadDeliveryPolicy(cfg.Delivery))
+	g.SetHandshakePolicy(gate.HandshakePolicy{
+		MinProtocolVersion: cfg.Handshake.MinProtocolVersion,
+		MaxProtocolVersion: cfg.Handshake.MaxProtocolVersion,
+		MinClientVersion:   cfg.Handshake.MinClientVersion,
+		UpgradeURL:         cfg.Handshake.UpgradeURL,
+		Required:           cfg.Handshake.Required,
+	})
+	if cfg.GateID != "" {
+		g.SetID(cfg.GateID)
+	}
+	if cfg.SessionStore.Enabled {
+		if cfg.GateID == "" {
+			logger.Error("gate_id required when session_store enabled",
+				zap.String("reason", "missing gate_id"),
+				zap.Int("msg_id", 0),
+				zap.Int64("session", 0),
+				zap.Int64("player", 0),
+				zap.Int64("conn_id", 0),
+				zap.String("trace_id", ""),
+			)
+			os.Exit(1)
+		}
+		if err := redis_tools.InitRedis(redis_tools.RedisConfig{
+			Addr:         cfg.SessionStore.Redis.Addr,
+			Password:     cfg.SessionStore.Redis.Password,
+			DB:           cfg.SessionStore.Redis.DB,
+			PoolSize:     cfg.SessionStore.Redis.PoolSize,
+			MinIdleConns: cfg.SessionStore.Redis.MinIdleConns,
+		}); err != nil {
+			log.Fatalf("init redis failed: %v", err)
+		}
+		redis_tools.StartHealthCheck(ctx, logger, time.Duration(cfg.SessionStore.Redis.HealthCheckSec)*time.Second)
+		g.SetSessionStore(gate.NewRedisSessionStore(
+			redis_tools.NewRedisDao(),
+			time.Duration(cfg.SessionStore.TTLSec)*time.Second,
+			logger,
+		))
+	}
 	g.Start(ctx)
-	g.ConnectService(ctx, cfg.ServiceAddr, cfg.ServicePoolSize)
+	go watchReload(ctx, g, configPath, []*transport.TLSProvider{clientTLS, internalTLS}, hupCh)
+	serviceAddrs := cfg.ServiceAddrs
+	if len(serviceAddrs) == 0 {
+		serviceAddrs = []string{cfg.ServiceAddr}
+	}
+	g.SetServiceHealth(
+		time.Duration(cfg.ServicePingSec)*time.Second,
+		time.Duration(cfg.ServiceHealthSec)*time.Second,
+	)
+	g.ConnectService(ctx, serviceAddrs, cfg.ServicePoolSize)
+	gameShards, err := router.SelectorFromConfig(cfg.GameAddr, cfg.GameShards)
+	if err != nil {
+		logger.Error("load game shards failed",
+			zap.String("reason", err.Error()),
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+		)
+		os.Exit(1)
+	}
+	if gameShards != nil {
+		g.ConnectGame(ctx, gameShards, cfg.GamePoolSize)
+	}
 
 	enableTCP := cfg.EnableTCP
 	enableWS := cfg.EnableWebSocket
-	if !enableTCP && !enableWS {
+	if !enableTCP && !enableWS && !cfg.KCP.Enabled {
 		enableTCP = true
 	}
 
@@ -101,6 +279,9 @@ func main() {
 			)
 			os.Exit(1)
 		}
+		if clientTLS != nil {
+			ln = tls.NewListener(ln, clientTLS.ServerConfig())
+		}
 		tcpListener = ln
 		logger.Info("gate listening (tcp)",
 			zap.Int("msg_id", 0),
@@ -132,7 +313,110 @@ func main() {
 					}
 				}
 
-				go handleConn(g, conn)
+				release, err := g.AdmitConn(conn.RemoteAddr().String())
+				if err != nil {
+					logger.Warn("reject connection",
+						zap.Int("msg_id", 0),
+						zap.Int64("session", 0),
+						zap.Int64("player", 0),
+						zap.String("reason", err.Error()),
+						zap.String("addr", conn.RemoteAddr().String()),
+						zap.String("trace_id", ""),
+					)
+					_ = conn.Close()
+					continue
+				}
+
+				go handleConn(g, conn, release)
+			}
+		}()
+	}
+
+	// ========== KCP Listener ==========
+	var kcpListener *transport.KCPListener
+	if cfg.KCP.Enabled && clientTLS != nil && !cfg.KCP.Plaintext {
+		// ⭐ KCP 监听没有 TLS：客户端 tls 已开启时不能悄悄多出一个明文入口
+		logger.Error("kcp listener is cleartext while tls is configured",
+			zap.String("reason", "set kcp.plaintext=true to accept cleartext kcp"),
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+		)
+		os.Exit(1)
+	}
+	if cfg.KCP.Enabled {
+		ln, err := transport.ListenKCP(cfg.KCP.ListenAddr, transport.KCPOptions{
+			NoDelay:      cfg.KCP.NoDelay,
+			Interval:     time.Duration(cfg.KCP.IntervalMs) * time.Millisecond,
+			Resend:       cfg.KCP.Resend,
+			NoCongestion: cfg.KCP.NoCongestion,
+			SendWindow:   cfg.KCP.SendWindow,
+			RecvWindow:   cfg.KCP.RecvWindow,
+			MTU:          cfg.KCP.MTU,
+			DataShards:   cfg.KCP.DataShards,
+			ParityShards: cfg.KCP.ParityShards,
+			SocketBuffer: cfg.KCP.SocketBuffer,
+		})
+		if err != nil {
+			logger.Error("gate listen failed (kcp)",
+				zap.String("reason", err.Error()),
+				zap.Int("msg_id", 0),
+				zap.Int64("session", 0),
+				zap.Int64("player", 0),
+				zap.Int64("conn_id", 0),
+				zap.String("trace_id", ""),
+			)
+			os.Exit(1)
+		}
+		kcpListener = ln
+		g.SetKCPHeartbeatTimeout(time.Duration(cfg.KCP.HeartbeatTimeoutSec) * time.Second)
+		logger.Info("gate listening (kcp)",
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.String("reason", ""),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+			zap.String("addr", ln.Addr().String()),
+		)
+
+		go func() {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					select {
+					case <-ctx.Done():
+						return
+					default:
+						logger.Warn("accept error (kcp)",
+							zap.Int("msg_id", 0),
+							zap.Int64("session", 0),
+							zap.Int64("player", 0),
+							zap.String("reason", err.Error()),
+							zap.Int64("conn_id", 0),
+							zap.String("trace_id", ""),
+						)
+						continue
+					}
+				}
+
+				release, err := g.AdmitConn(conn.RemoteAddr().String())
+				if err != nil {
+					logger.Warn("reject kcp connection",
+						zap.Int("msg_id", 0),
+						zap.Int64("session", 0),
+						zap.Int64("player", 0),
+						zap.String("reason", err.Error()),
+						zap.String("addr", conn.RemoteAddr().String()),
+						zap.String("trace_id", ""),
+					)
+					_ = conn.Close()
+					continue
+				}
+
+				go handleKCPConn(g, conn, release)
 			}
 		}()
 	}
@@ -160,11 +444,31 @@ func main() {
 			CheckOrigin: func(r *http.Request) bool {
 				return true
 			},
+			// ⭐ permessage-deflate：客户端也声明支持时才生效
+			EnableCompression: cfg.WebSocketDeflate.Enabled,
 		}
 		mux := http.NewServeMux()
 		mux.HandleFunc(wsPath, func(w http.ResponseWriter, r *http.Request) {
+			release, err := g.AdmitConn(r.RemoteAddr)
+			if err != nil {
+				logger.Warn("reject websocket connection",
+					zap.Int("msg_id", 0),
+					zap.Int64("session", 0),
+					zap.Int64("player", 0),
+					zap.String("reason", err.Error()),
+					zap.String("addr", r.RemoteAddr),
+					zap.String("trace_id", ""),
+				)
+				status := http.StatusTooManyRequests
+				if errors.Is(err, gate.ErrIPDenied) {
+					status = http.StatusForbidden
+				}
+				http.Error(w, err.Error(), status)
+				return
+			}
 			wsConn, err := upgrader.Upgrade(w, r, nil)
 			if err != nil {
+				release()
 				logger.Warn("websocket upgrade failed",
 					zap.String("reason", err.Error()),
 					zap.Int("msg_id", 0),
@@ -175,16 +479,38 @@ func main() {
 				)
 				return
 			}
-			go handleWSConn(g, wsConn, cfg.WebSocketUseJSON)
+			if cfg.WebSocketDeflate.Enabled && cfg.WebSocketDeflate.Level != 0 {
+				if err := wsConn.SetCompressionLevel(cfg.WebSocketDeflate.Level); err != nil {
+					logger.Warn("websocket compression level invalid",
+						zap.String("reason", err.Error()),
+						zap.Int("msg_id", 0),
+						zap.Int64("session", 0),
+						zap.Int64("player", 0),
+						zap.Int64("conn_id", 0),
+						zap.String("trace_id", ""),
+					)
+				}
+			}
+			go handleWSConn(g, wsConn, cfg.WebSocketUseJSON, release)
 		})
 
 		wsServer = &http.Server{
 			Addr:    wsAddr,
 			Handler: mux,
 		}
+		if clientTLS != nil {
+			// WSS：证书由 TLSConfig 提供，ListenAndServeTLS 不再读文件
+			wsServer.TLSConfig = clientTLS.ServerConfig()
+		}
 
 		go func() {
-			if err := wsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			var err error
+			if clientTLS != nil {
+				err = wsServer.ListenAndServeTLS("", "")
+			} else {
+				err = wsServer.ListenAndServe()
+			}
+			if err != nil && err != http.ErrServerClosed {
 				logger.Error("websocket listen failed",
 					zap.String("reason", err.Error()),
 					zap.Int("msg_id", 0),
@@ -210,6 +536,74 @@ func main() {
 		)
 	}
 
+	// ========== Admin Listener ==========
+	var adminServer *http.Server
+	if cfg.AdminListenAddr != "" {
+		if cfg.AdminToken == "" {
+			logger.Error("admin token required",
+				zap.String("reason", "missing admin_token"),
+				zap.Int("msg_id", 0),
+				zap.Int64("session", 0),
+				zap.Int64("player", 0),
+				zap.Int64("conn_id", 0),
+				zap.String("trace_id", ""),
+			)
+			os.Exit(1)
+		}
+		adminServer = &http.Server{
+			Addr:    cfg.AdminListenAddr,
+			Handler: g.AdminHandler(cfg.AdminToken),
+		}
+		go func() {
+			if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("admin listen failed",
+					zap.String("reason", err.Error()),
+					zap.Int("msg_id", 0),
+					zap.Int64("session", 0),
+					zap.Int64("player", 0),
+					zap.Int64("conn_id", 0),
+					zap.String("trace_id", ""),
+				)
+				errCh <- err
+			}
+		}()
+		logger.Info("gate listening (admin)",
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.String("reason", ""),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+			zap.String("addr", cfg.AdminListenAddr),
+		)
+	}
+
+	// ========== Metrics Listener ==========
+	if cfg.MetricsListenAddr != "" {
+		go func() {
+			if err := metrics.ListenAndServe(ctx, cfg.MetricsListenAddr); err != nil {
+				logger.Error("metrics listen failed",
+					zap.String("reason", err.Error()),
+					zap.Int("msg_id", 0),
+					zap.Int64("session", 0),
+					zap.Int64("player", 0),
+					zap.Int64("conn_id", 0),
+					zap.String("trace_id", ""),
+				)
+				errCh <- err
+			}
+		}()
+		logger.Info("gate listening (metrics)",
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.String("reason", ""),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+			zap.String("addr", cfg.MetricsListenAddr),
+		)
+	}
+
 	// ========== 等待退出 ==========
 	logger.Info("gate shutting down",
 		zap.Int("msg_id", 0),
@@ -235,9 +629,15 @@ func main() {
 	if tcpListener != nil {
 		_ = tcpListener.Close()
 	}
+	if kcpListener != nil {
+		_ = kcpListener.Close()
+	}
 	if wsServer != nil {
 		_ = wsServer.Shutdown(context.Background())
 	}
+	if adminServer != nil {
+		_ = adminServer.Shutdown(context.Background())
+	}
 
 	time.Sleep(500 * time.Millisecond)
 	logger.Info("gate exited",
@@ -250,10 +650,155 @@ func main() {
 	)
 }
 
+// loadRateLimits 未配置时返回 nil，沿用 Gate 内置的默认规则
+func loadRateLimits(items []config.RateLimitRuleConfig) []gate.RateLimitRule {
+	if len(items) == 0 {
+		return nil
+	}
+	rules := make([]gate.RateLimitRule, 0, len(items))
+	for _, item := range items {
+		rules = append(rules, gate.RateLimitRule{
+			Name:     item.Name,
+			MsgBegin: item.MsgBegin,
+			MsgEnd:   item.MsgEnd,
+			Burst:    item.Burst,
+			Rate:     item.RatePerSec,
+		})
+	}
+	return rules
+}
+
+func applyIPFilter(g *gate.Gate, cfg config.IPFilterConfig) error {
+	if err := g.IPFilter().SetLists(cfg.Allow, cfg.Deny); err != nil {
+		return err
+	}
+	g.IPFilter().SetLimits(cfg.MaxConnsPerIP, cfg.NewConnsPerSec)
+	return nil
+}
+
+// ================= reload =================
+
+// loadDeliveryPolicy 三个列表按 normal → critical → droppable 的顺序写入，同一 msgID 以后写的为准
+func loadDeliveryPolicy(cfg config.DeliveryConfig) gate.DeliveryPolicy {
+	classes := make(map[int]gate.DeliveryClass)
+	for _, msgID := range cfg.Normal {
+		classes[msgID] = gate.DeliveryNormal
+	}
+	for _, msgID := range cfg.Critical {
+		classes[msgID] = gate.DeliveryCritical
+	}
+	for _, msgID := range cfg.Droppable {
+		classes[msgID] = gate.DeliveryDroppable
+	}
+	return gate.DeliveryPolicy{
+		Classes:        classes,
+		CriticalQueue:  cfg.CriticalQueue,
+		NormalQueue:    cfg.NormalQueue,
+		DroppableQueue: cfg.DroppableQueue,
+	}
+}
+
+// loadCompressions 算法名转为枚举，保持配置中的偏好顺序
+func loadCompressions(cfg config.CompressionConfig) ([]internalpb.Compression, error) {
+	algos := make([]internalpb.Compression, 0, len(cfg.Algorithms))
+	for _, name := range cfg.Algorithms {
+		algo, err := transport.ParseCompression(name)
+		if err != nil {
+			return nil, err
+		}
+		if algo != internalpb.Compression_COMPRESSION_NONE {
+			algos = append(algos, algo)
+		}
+	}
+	return algos, nil
+}
+
+func loadResumeKeys(items []config.ResumeKeyConfig) ([]gate.ResumeKey, error) {
+	keys := make([]gate.ResumeKey, 0, len(items))
+	for _, item := range items {
+		if item.KeyID == "" || item.Secret == "" {
+			return nil, fmt.Errorf("resume key requires key_id and secret")
+		}
+		if strings.Contains(item.KeyID, ".") {
+			return nil, fmt.Errorf("resume key id %q must not contain '.'", item.KeyID)
+		}
+		key := gate.ResumeKey{
+			ID:     item.KeyID,
+			Secret: []byte(item.Secret),
+		}
+		if item.NotBefore != "" {
+			t, err := time.Parse(time.RFC3339, item.NotBefore)
+			if err != nil {
+				return nil, fmt.Errorf("resume key %s not_before: %w", item.KeyID, err)
+			}
+			key.NotBefore = t
+		}
+		if item.NotAfter != "" {
+			t, err := time.Parse(time.RFC3339, item.NotAfter)
+			if err != nil {
+				return nil, fmt.Errorf("resume key %s not_after: %w", item.KeyID, err)
+			}
+			key.NotAfter = t
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func watchReload(ctx context.Context, g *gate.Gate, configPath string, certs []*transport.TLSProvider, hupCh <-chan os.Signal) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hupCh:
+		}
+
+		transport.ReloadTLS(g.Logger(), certs...)
+
+		var cfg config.GateConfig
+		if err := config.Load(configPath, &cfg); err != nil {
+			g.Logger().Warn("reload config failed", zap.String("reason", err.Error()))
+			continue
+		}
+		if table, err := router.LoadFile(cfg.RoutesPath); err != nil {
+			g.Logger().Warn("reload routes failed", zap.String("reason", err.Error()))
+		} else {
+			g.Logger().Info("routes reloaded", zap.Int("routes", table.Len()))
+		}
+
+		if err := applyIPFilter(g, cfg.IPFilter); err != nil {
+			g.Logger().Warn("reload ip filter failed", zap.String("reason", err.Error()))
+		} else {
+			allow, deny := g.IPFilter().Lists()
+			g.Logger().Info("ip filter reloaded",
+				zap.Any("allow", allow),
+				zap.Any("deny", deny),
+				zap.Int("closed", g.CloseDeniedConns()),
+			)
+		}
+
+		keys, err := loadResumeKeys(cfg.ResumeKeys)
+		if err != nil {
+			g.Logger().Warn("reload resume keys failed", zap.String("reason", err.Error()))
+			continue
+		}
+		if len(keys) == 0 {
+			g.Logger().Warn("reload resume keys skipped", zap.String("reason", "resume_keys_empty"))
+			continue
+		}
+		added, retired := g.ResumeKeys().Sync(keys)
+		g.Logger().Info("resume keys reloaded",
+			zap.Any("added", added),
+			zap.Any("retired", retired),
+		)
+	}
+}
+
 // ================= handlers =================
 
-func handleConn(g *gate.Gate, netConn net.Conn) {
+func handleConn(g *gate.Gate, netConn net.Conn, release func()) {
 	c := gate.NewConn(netConn, g)
+	c.OnClose(release)
 
 	// 创建 Session
 	//g.NewSession(c)
@@ -270,8 +815,25 @@ func handleConn(g *gate.Gate, netConn net.Conn) {
 	c.ReadLoop()
 }
 
-func handleWSConn(g *gate.Gate, wsConn *websocket.Conn, useJSON bool) {
+func handleKCPConn(g *gate.Gate, netConn net.Conn, release func()) {
+	c := gate.NewKCPConn(netConn, g)
+	c.OnClose(release)
+
+	g.Logger().Info("gate new kcp connection",
+		zap.Int("msg_id", 0),
+		zap.Int64("player", 0),
+		zap.String("reason", ""),
+		zap.Int64("sesson_Id", c.SessonId()),
+		zap.Any("conv", c.Conv()),
+		zap.String("trace_id", c.TraceID()),
+	)
+
+	c.ReadLoop()
+}
+
+func handleWSConn(g *gate.Gate, wsConn *websocket.Conn, useJSON bool, release func()) {
 	c := gate.NewWSConn(wsConn, g, useJSON)
+	c.OnClose(release)
 
 	g.Logger().Info("gate new websocket connection",
 		zap.Int("msg_id", 0),
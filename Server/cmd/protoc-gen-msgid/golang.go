@@ -0,0 +1,167 @@
+// cmd/protoc-gen-msgid/golang.go
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+type importPaths struct {
+	protocol   protogen.GoImportPath
+	internalpb protogen.GoImportPath
+	msgtype    protogen.GoImportPath
+	handler    protogen.GoImportPath
+	service    protogen.GoImportPath
+	game       protogen.GoImportPath
+}
+
+// newImportPaths .proto 里的 go_package 与实际目录不一致，这里按模块路径拼出真实的包路径
+func newImportPaths(module string) importPaths {
+	return importPaths{
+		protocol:   protogen.GoImportPath(module + "/internal/protocol"),
+		internalpb: protogen.GoImportPath(module + "/internal/protocol/internalpb"),
+		msgtype:    protogen.GoImportPath(module + "/internal/protocol/msgtype"),
+		handler:    protogen.GoImportPath(module + "/internal/handler"),
+		service:    protogen.GoImportPath(module + "/internal/service"),
+		game:       protogen.GoImportPath(module + "/internal/game/player_module"),
+	}
+}
+
+func header(g *protogen.GeneratedFile, t *table, pkg string) {
+	g.P("// Code generated by protoc-gen-msgid. DO NOT EDIT.")
+	g.P("// source: ", strings.Join(t.sources, ", "))
+	g.P()
+	g.P("package ", pkg)
+	g.P()
+}
+
+func genMsgID(gen *protogen.Plugin, t *table) {
+	g := gen.NewGeneratedFile("protocol/msgid.go", "")
+	header(g, t, "protocol")
+
+	g.P("// 号段 [Begin, End)，见 options.proto 的 MsgRange")
+	g.P("const (")
+	for _, r := range t.ranges {
+		g.P("Msg", r.Name, "Begin = ", r.Begin, " // ", r.Comment)
+		g.P("Msg", r.Name, "End = ", r.End)
+	}
+	g.P(")")
+	g.P()
+
+	for _, r := range t.ranges {
+		var msgs []*message
+		for _, m := range t.messages {
+			if m.Range == r {
+				msgs = append(msgs, m)
+			}
+		}
+		if len(msgs) == 0 {
+			continue
+		}
+		g.P("// =======================")
+		g.P("// ", r.Comment)
+		g.P("// =======================")
+		g.P("const (")
+		for _, m := range msgs {
+			comment := m.GoName
+			if m.Internal {
+				comment += "（仅服务间）"
+			}
+			if m.Comment != "" {
+				comment += " " + m.Comment
+			}
+			g.P(m.Const, " = ", m.ID, " // ", comment)
+		}
+		g.P(")")
+		g.P()
+	}
+
+	g.P("// MsgScope 返回 msgID 所在号段（", scopeList(t), "），不在任何号段时返回空")
+	g.P("func MsgScope(msgID int) string {")
+	g.P("switch {")
+	for _, r := range t.ranges {
+		g.P("case msgID >= Msg", r.Name, "Begin && msgID < Msg", r.Name, "End:")
+		g.P("return ", fmt.Sprintf("%q", r.Scope))
+	}
+	g.P("}")
+	g.P(`return ""`)
+	g.P("}")
+}
+
+func scopeList(t *table) string {
+	var names []string
+	for _, r := range t.ranges {
+		names = append(names, r.Scope)
+	}
+	return strings.Join(names, " / ")
+}
+
+func genTable(gen *protogen.Plugin, t *table, paths importPaths) {
+	g := gen.NewGeneratedFile("protocol/msgtype/table.go", paths.msgtype)
+	header(g, t, "msgtype")
+
+	id := func(name string) string {
+		return g.QualifiedGoIdent(paths.protocol.Ident(name))
+	}
+	g.P("func init() {")
+	g.P("Bind(")
+	for _, m := range t.messages {
+		fields := []string{
+			"ID: " + id(m.Const),
+			fmt.Sprintf("Name: %q", m.Const),
+			"Type: Of[*" + g.QualifiedGoIdent(paths.internalpb.Ident(m.GoName)) + "]()",
+		}
+		if m.Reply != 0 {
+			fields = append(fields, "Reply: "+id(t.byID[m.Reply].Const))
+		}
+		if m.Internal {
+			fields = append(fields, "Internal: true")
+		}
+		g.P("Message{", strings.Join(fields, ", "), "},")
+	}
+	g.P(")")
+	g.P("}")
+}
+
+func genServiceStubs(gen *protogen.Plugin, t *table, paths importPaths, scopes []string) {
+	reqs := t.requests(scopes)
+	if len(reqs) == 0 {
+		return
+	}
+	g := gen.NewGeneratedFile("service/msg_handlers.go", paths.service)
+	header(g, t, "service")
+
+	registry := g.QualifiedGoIdent(paths.handler.Ident("Registry"))
+	for _, m := range reqs {
+		rsp := t.byID[m.Reply]
+		g.P("// Register", m.GoName, " ", m.Const, " → ", rsp.Const)
+		g.P("func Register", m.GoName, "(reg *", registry, "[HandlerFunc], fn func(*Context, *",
+			g.QualifiedGoIdent(paths.internalpb.Ident(m.GoName)), ") (*",
+			g.QualifiedGoIdent(paths.internalpb.Ident(rsp.GoName)), ", error)) error {")
+		g.P("return Register(reg, ", g.QualifiedGoIdent(paths.protocol.Ident(m.Const)), ", fn)")
+		g.P("}")
+		g.P()
+	}
+}
+
+func genGameStubs(gen *protogen.Plugin, t *table, paths importPaths, scopes []string) {
+	reqs := t.requests(scopes)
+	if len(reqs) == 0 {
+		return
+	}
+	g := gen.NewGeneratedFile("game/player_module/msg_handlers.go", paths.game)
+	header(g, t, "player_module")
+
+	for _, m := range reqs {
+		rsp := t.byID[m.Reply]
+		g.P("// Handle", m.GoName, " ", m.Const, " → ", rsp.Const)
+		g.P("func Handle", m.GoName, "(t *HandlerTable, fn func(*PlayerContext, *",
+			g.QualifiedGoIdent(paths.internalpb.Ident(m.GoName)), ") (*",
+			g.QualifiedGoIdent(paths.internalpb.Ident(rsp.GoName)), ", error)) {")
+		g.P("Handle(t, ", g.QualifiedGoIdent(paths.protocol.Ident(m.Const)), ", fn)")
+		g.P("}")
+		g.P()
+	}
+}
@@ -0,0 +1,206 @@
+// cmd/protoc-gen-msgid/main.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"game-server/internal/protocol/internalpb"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+)
+
+// protoc 插件：按 .proto 里的 (msg_id) / (reply) / (internal) 标注生成
+//   - protocol/msgid.go                      号段与 msgID 常量、号段检查
+//   - protocol/msgtype/table.go              msgID ↔ 类型表
+//   - service/msg_handlers.go                Service 号段请求的强类型注册桩
+//   - game/player_module/msg_handlers.go     Game 号段请求的强类型注册桩
+//
+// 输出目录为 Server/internal；带 manifest 参数时只生成 msgid.json，给 Client/ 下的 Python 客户端加载。
+// 插件依赖 internalpb 里的选项定义，需先用 protoc-gen-go 生成 options.pb.go，见 scripts/gen_proto_go.bat
+func main() {
+	var flags flag.FlagSet
+	module := flags.String("module", "game-server", "go module path")
+	manifest := flags.Bool("manifest", false, "emit msgid.json instead of Go code")
+	service := flags.String("service", "login:chat", "ranges dispatched by service, separated by ':'")
+	game := flags.String("game", "game", "ranges dispatched by game, separated by ':'")
+
+	protogen.Options{ParamFunc: flags.Set}.Run(func(gen *protogen.Plugin) error {
+		t, err := collect(gen)
+		if err != nil {
+			return err
+		}
+		if *manifest {
+			return genManifest(gen, t)
+		}
+		paths := newImportPaths(*module)
+		genMsgID(gen, t)
+		genTable(gen, t, paths)
+		genServiceStubs(gen, t, paths, strings.Split(*service, ":"))
+		genGameStubs(gen, t, paths, strings.Split(*game, ":"))
+		return nil
+	})
+}
+
+// msgRange 来自 options.proto 的 MsgRange 枚举
+type msgRange struct {
+	Name    string // 常量名片段，如 Gate / DB
+	Scope   string // gate / db
+	Begin   int32
+	End     int32
+	Comment string
+}
+
+type message struct {
+	ID       int32
+	Const    string // MsgLoginReq
+	GoName   string // LoginReq
+	FullName string // internalpb.LoginReq
+	Proto    string // login.proto
+	Reply    int32
+	Internal bool
+	Range    *msgRange
+	Comment  string // .proto 里消息前注释的第一行
+}
+
+type table struct {
+	ranges   []*msgRange
+	messages []*message // 按 ID 升序
+	byID     map[int32]*message
+	sources  []string
+}
+
+func collect(gen *protogen.Plugin) (*table, error) {
+	t := &table{byID: make(map[int32]*message)}
+	for _, f := range gen.Files {
+		for _, e := range f.Enums {
+			if e.Desc.FullName() != "internalpb.MsgRange" {
+				continue
+			}
+			for _, v := range e.Values {
+				name := strings.TrimPrefix(string(v.Desc.Name()), "MSG_RANGE_")
+				end := proto.GetExtension(v.Desc.Options(), internalpb.E_RangeEnd).(int32)
+				t.ranges = append(t.ranges, &msgRange{
+					Name:    constName(name),
+					Scope:   strings.ToLower(name),
+					Begin:   int32(v.Desc.Number()),
+					End:     end,
+					Comment: strings.TrimSpace(string(v.Comments.Trailing)),
+				})
+			}
+		}
+	}
+	if len(t.ranges) == 0 {
+		return nil, fmt.Errorf("enum internalpb.MsgRange not found, options.proto must be part of the input")
+	}
+	sort.Slice(t.ranges, func(i, j int) bool { return t.ranges[i].Begin < t.ranges[j].Begin })
+	for i, r := range t.ranges {
+		if r.End <= r.Begin {
+			return nil, fmt.Errorf("range %s: end %d <= begin %d", r.Scope, r.End, r.Begin)
+		}
+		if i > 0 && r.Begin < t.ranges[i-1].End {
+			return nil, fmt.Errorf("range %s overlaps %s", r.Scope, t.ranges[i-1].Scope)
+		}
+	}
+
+	consts := make(map[string]*message)
+	for _, f := range gen.Files {
+		if !f.Generate {
+			continue
+		}
+		found := false
+		for _, m := range f.Messages {
+			opts := m.Desc.Options()
+			id := proto.GetExtension(opts, internalpb.E_MsgId).(int32)
+			if id == 0 {
+				continue
+			}
+			found = true
+			name := proto.GetExtension(opts, internalpb.E_MsgName).(string)
+			if name == "" {
+				name = m.GoIdent.GoName
+			}
+			msg := &message{
+				ID:       id,
+				Const:    "Msg" + name,
+				GoName:   m.GoIdent.GoName,
+				FullName: string(m.Desc.FullName()),
+				Proto:    f.Desc.Path(),
+				Reply:    proto.GetExtension(opts, internalpb.E_Reply).(int32),
+				Internal: proto.GetExtension(opts, internalpb.E_Internal).(bool),
+				Comment:  strings.TrimSpace(strings.SplitN(string(m.Comments.Leading), "\n", 2)[0]),
+			}
+			if old, ok := t.byID[id]; ok {
+				return nil, fmt.Errorf("msg_id %d used by both %s and %s", id, old.FullName, msg.FullName)
+			}
+			if old, ok := consts[msg.Const]; ok {
+				return nil, fmt.Errorf("%s generated for both %s and %s", msg.Const, old.FullName, msg.FullName)
+			}
+			for _, r := range t.ranges {
+				if id >= r.Begin && id < r.End {
+					msg.Range = r
+				}
+			}
+			if msg.Range == nil || id == msg.Range.Begin {
+				return nil, fmt.Errorf("%s: msg_id %d is outside every MsgRange", msg.FullName, id)
+			}
+			t.byID[id] = msg
+			consts[msg.Const] = msg
+			t.messages = append(t.messages, msg)
+		}
+		if found {
+			t.sources = append(t.sources, f.Desc.Path())
+		}
+	}
+	for _, m := range t.messages {
+		if m.Reply == 0 {
+			continue
+		}
+		rsp, ok := t.byID[m.Reply]
+		if !ok {
+			return nil, fmt.Errorf("%s: reply %d is not a msg_id", m.FullName, m.Reply)
+		}
+		if rsp.Internal != m.Internal {
+			return nil, fmt.Errorf("%s: reply %s differs in (internal)", m.FullName, rsp.FullName)
+		}
+	}
+	sort.Slice(t.messages, func(i, j int) bool { return t.messages[i].ID < t.messages[j].ID })
+	sort.Strings(t.sources)
+	return t, nil
+}
+
+// constName GATE → Gate；两个字母以内按缩写保留大写（DB）
+func constName(s string) string {
+	if len(s) <= 2 {
+		return s
+	}
+	parts := strings.Split(strings.ToLower(s), "_")
+	for i, p := range parts {
+		if p != "" {
+			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+func (t *table) inRanges(m *message, scopes []string) bool {
+	for _, s := range scopes {
+		if m.Range.Scope == s {
+			return true
+		}
+	}
+	return false
+}
+
+// requests 客户端可发、有回包、落在 scopes 号段的请求，生成注册桩用
+func (t *table) requests(scopes []string) []*message {
+	var out []*message
+	for _, m := range t.messages {
+		if m.Reply != 0 && !m.Internal && t.inRanges(m, scopes) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
@@ -0,0 +1,55 @@
+// cmd/protoc-gen-msgid/manifest.go
+package main
+
+import (
+	"encoding/json"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// 字段与 msgtype.Dump 的输出一致，Python 客户端按名字取 msgID，按 proto / type 找到 *_pb2 里的类
+type manifestRange struct {
+	Name  string `json:"name"`
+	Begin int32  `json:"begin"`
+	End   int32  `json:"end"`
+}
+
+type manifestMessage struct {
+	ID       int32  `json:"id"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Proto    string `json:"proto"`
+	Reply    int32  `json:"reply,omitempty"`
+	Scope    string `json:"scope"`
+	Internal bool   `json:"internal,omitempty"`
+}
+
+type manifestFile struct {
+	Ranges   []manifestRange   `json:"ranges"`
+	Messages []manifestMessage `json:"messages"`
+}
+
+func genManifest(gen *protogen.Plugin, t *table) error {
+	var out manifestFile
+	for _, r := range t.ranges {
+		out.Ranges = append(out.Ranges, manifestRange{Name: r.Scope, Begin: r.Begin, End: r.End})
+	}
+	for _, m := range t.messages {
+		out.Messages = append(out.Messages, manifestMessage{
+			ID:       m.ID,
+			Name:     m.Const,
+			Type:     m.FullName,
+			Proto:    m.Proto,
+			Reply:    m.Reply,
+			Scope:    m.Range.Scope,
+			Internal: m.Internal,
+		})
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	g := gen.NewGeneratedFile("msgid.json", "")
+	_, err = g.Write(append(data, '\n'))
+	return err
+}
@@ -3,6 +3,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"game-server/internal/db/redis_tools"
@@ -14,8 +15,12 @@ import (
 
 	"game-server/internal/common/logging"
 	"game-server/internal/config"
+	"game-server/internal/metrics"
 	"game-server/internal/player_db"
+	"game-server/internal/protocol"
 	"game-server/internal/protocol/internalpb"
+	"game-server/internal/protocol/msgtype"
+	"game-server/internal/router"
 	"game-server/internal/service"
 	"game-server/internal/service/modules/chat"
 	"game-server/internal/service/modules/login"
@@ -51,29 +56,92 @@ func main() {
 	if cfg.MaxEnvelopeSize > 0 {
 		transport.SetMaxEnvelopeSize(cfg.MaxEnvelopeSize)
 	}
+	// ⭐ msgID ↔ 类型表有重复 / 缺失时拒绝启动
+	msgtype.MustValidate(logger)
 	connOptions := transport.ConnOptions{
 		ReadTimeout:  time.Duration(cfg.ConnReadTimeoutSec) * time.Second,
 		WriteTimeout: time.Duration(cfg.ConnWriteTimeoutSec) * time.Second,
 		KeepAlive:    time.Duration(cfg.ConnKeepAliveSec) * time.Second,
+		WriteBatch:   cfg.WriteCoalesce.MaxBatch,
+		WriteDelay:   time.Duration(cfg.WriteCoalesce.MaxDelayUs) * time.Microsecond,
+	}
+	internalTLS, err := transport.TLSFromConfig(cfg.InternalTLS, tls.RequireAndVerifyClientCert)
+	if err != nil {
+		logger.Error("load internal tls failed",
+			zap.String("reason", err.Error()),
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+		)
+		os.Exit(1)
+	}
+	connOptions.TLS = internalTLS
+
+	if _, err := router.LoadFile(cfg.RoutesPath); err != nil {
+		logger.Error("load routes failed",
+			zap.String("reason", err.Error()),
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+		)
+		os.Exit(1)
 	}
 
 	srv := service.NewServer(logger)
 
-	// 2️⃣ 初始化 Redis（关键）
-	if err := redis_tools.InitRedis(redis_tools.RedisConfig{
-		Addr:         cfg.Redis.Addr,
-		Password:     cfg.Redis.Password,
-		DB:           cfg.Redis.DB,
-		PoolSize:     cfg.Redis.PoolSize,
-		MinIdleConns: cfg.Redis.MinIdleConns,
-	}); err != nil {
-		log.Fatalf("init redis failed: %v", err)
+	// 2️⃣ 存储：配置了 db_addr 时走 DB 代理，否则直连 Redis
+	var (
+		playerStore player_db.Store
+		uidGen      login.UIDGenerator
+	)
+	if cfg.DBAddr != "" {
+		remote := player_db.NewRemoteStore(cfg.DBAddr, connOptions, time.Duration(cfg.DBTimeoutMs)*time.Millisecond, logger)
+		playerStore, uidGen = remote, remote
+		logger.Info("player store via db proxy", zap.String("addr", cfg.DBAddr))
+	} else {
+		if err := redis_tools.InitRedis(redis_tools.RedisConfig{
+			Addr:         cfg.Redis.Addr,
+			Password:     cfg.Redis.Password,
+			DB:           cfg.Redis.DB,
+			PoolSize:     cfg.Redis.PoolSize,
+			MinIdleConns: cfg.Redis.MinIdleConns,
+		}); err != nil {
+			log.Fatalf("init redis failed: %v", err)
+		}
+		dao := redis_tools.NewRedisDao()
+		playerStore, uidGen = player_db.NewRedisStore(dao), dao
 	}
+	loginSvc := login.NewLoginService(uidGen, playerStore)
 
-	playerStore := player_db.NewRedisStore(redis_tools.NewRedisDao())
-	loginSvc := login.NewLoginService(redis_tools.NewRedisDao(), playerStore)
+	loginModule := login.NewModule(loginSvc)
+	for _, vc := range cfg.Login.Verifiers {
+		var verifier login.TokenVerifier = login.NewHTTPVerifier(login.HTTPVerifierConfig{
+			Endpoint: vc.Endpoint,
+			Timeout:  time.Duration(vc.TimeoutMs) * time.Millisecond,
+			Secret:   vc.Secret,
+		})
+		if vc.CacheTTLSec > 0 {
+			verifier = login.NewCachedVerifier(verifier, time.Duration(vc.CacheTTLSec)*time.Second)
+		}
+		loginModule.RegisterVerifier(vc.Platform, verifier)
+		logger.Info("login verifier registered",
+			zap.Int("platform", int(vc.Platform)),
+			zap.String("endpoint", vc.Endpoint),
+		)
+	}
+
+	if cfg.Login.AllowTestPlatform {
+		loginModule.RegisterVerifier(protocol.PlatformTest, login.TestVerifier{})
+		logger.Warn("test platform login enabled, client account_id is trusted",
+			zap.Int("platform", int(protocol.PlatformTest)),
+		)
+	}
 
-	if err := srv.RegisterModule(login.NewModule(loginSvc)); err != nil {
+	if err := srv.RegisterModule(loginModule); err != nil {
 		logger.Error("register login module failed",
 			zap.String("reason", err.Error()),
 			zap.Int("msg_id", 0),
@@ -99,22 +167,73 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	redis_tools.StartHealthCheck(ctx, logger, time.Duration(cfg.Redis.HealthCheckSec)*time.Second)
-
-	gameRouter := service.NewGameRouter(cfg.GameAddr, logger, connOptions, 2, 5*time.Millisecond)
-	netServer := service.NewNetServer(srv, gameRouter, connOptions)
-	gameRouter.Start(ctx, func(env *internalpb.Envelope) {
-		if err := netServer.ForwardToGate(env); err != nil {
-			logger.Warn("forward game env failed",
-				zap.Int("msg_id", int(env.MsgId)),
-				zap.Int64("session", env.SessionId),
-				zap.Int64("player", env.PlayerId),
-				zap.String("reason", err.Error()),
-				zap.Int64("conn_id", 0),
-				zap.String("trace_id", fmt.Sprintf("session-%d", env.SessionId)),
-			)
-		}
-	})
+	// ⭐ SIGHUP：重新加载路由表和内部链路证书
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	go watchReload(ctx, logger, configPath, internalTLS, hupCh)
+
+	if cfg.DBAddr == "" {
+		redis_tools.StartHealthCheck(ctx, logger, time.Duration(cfg.Redis.HealthCheckSec)*time.Second)
+	}
+
+	gameShards, err := router.SelectorFromConfig(cfg.GameAddr, cfg.GameShards)
+	if err == nil && gameShards == nil {
+		err = router.ErrNoGameShard
+	}
+	if err != nil {
+		logger.Error("load game shards failed",
+			zap.String("reason", err.Error()),
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+		)
+		os.Exit(1)
+	}
+	gameRouters := make(map[string]*service.GameRouter)
+	for _, shard := range gameShards.Shards() {
+		gameRouters[shard.ID] = service.NewGameRouter(shard.Addr, logger, connOptions, 2, 5*time.Millisecond)
+	}
+	netServer := service.NewNetServer(srv, gameShards, gameRouters, connOptions)
+	for _, gameRouter := range gameRouters {
+		gameRouter.Start(ctx, func(env *internalpb.Envelope) {
+			if err := netServer.ForwardToGate(env); err != nil {
+				logger.Warn("forward game env failed",
+					zap.Int("msg_id", int(env.MsgId)),
+					zap.Int64("session", env.SessionId),
+					zap.Int64("player", env.PlayerId),
+					zap.String("reason", err.Error()),
+					zap.Int64("conn_id", 0),
+					zap.String("trace_id", fmt.Sprintf("session-%d", env.SessionId)),
+				)
+			}
+		})
+	}
+
+	if cfg.MetricsListenAddr != "" {
+		go func() {
+			if err := metrics.ListenAndServe(ctx, cfg.MetricsListenAddr); err != nil {
+				logger.Error("metrics listen failed",
+					zap.String("reason", err.Error()),
+					zap.Int("msg_id", 0),
+					zap.Int64("session", 0),
+					zap.Int64("player", 0),
+					zap.Int64("conn_id", 0),
+					zap.String("trace_id", ""),
+				)
+			}
+		}()
+		logger.Info("service listening (metrics)",
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.String("reason", ""),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+			zap.String("addr", cfg.MetricsListenAddr),
+		)
+	}
 
 	logger.Info("service listening",
 		zap.Int("msg_id", 0),
@@ -137,3 +256,29 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// ================= reload =================
+
+func watchReload(ctx context.Context, logger *zap.Logger, configPath string, internalTLS *transport.TLSProvider, hupCh <-chan os.Signal) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hupCh:
+		}
+
+		transport.ReloadTLS(logger, internalTLS)
+
+		var cfg config.ServiceConfig
+		if err := config.Load(configPath, &cfg); err != nil {
+			logger.Warn("reload config failed", zap.String("reason", err.Error()))
+			continue
+		}
+		table, err := router.LoadFile(cfg.RoutesPath)
+		if err != nil {
+			logger.Warn("reload routes failed", zap.String("reason", err.Error()))
+			continue
+		}
+		logger.Info("routes reloaded", zap.Int("routes", table.Len()))
+	}
+}
@@ -0,0 +1,27 @@
+// cmd/verifier_mock/main.go
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"game-server/internal/service/modules/login"
+)
+
+// 本地模拟平台 token 校验服务，service.yaml 的 login.verifiers 可直接指向这里
+func main() {
+	var addr, path, secret string
+	flag.StringVar(&addr, "addr", ":9300", "listen address")
+	flag.StringVar(&path, "path", "/verify", "verify path")
+	flag.StringVar(&secret, "secret", "verify-secret", "hmac secret shared with service")
+	flag.Parse()
+
+	mux := http.NewServeMux()
+	mux.Handle(path, login.NewMockVerifierServer(secret))
+
+	log.Printf("mock verifier listening on %s%s", addr, path)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Fatal(err)
+	}
+}
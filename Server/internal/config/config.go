@@ -16,37 +16,224 @@ type RedisConfig struct {
 	HealthCheckSec int    `json:"health_check_sec"`
 }
 
+// RouteConfig msg_id 非 0 时为单个 id，否则为区间 [msg_begin, msg_end)
+type RouteConfig struct {
+	MsgID    int    `json:"msg_id"`
+	MsgBegin int    `json:"msg_begin"`
+	MsgEnd   int    `json:"msg_end"`
+	Target   string `json:"target"` // service / game / db
+	Module   string `json:"module"`
+}
+
+type RouteTableConfig struct {
+	Routes []RouteConfig `json:"routes"`
+}
+
+type ResumeKeyConfig struct {
+	KeyID     string `json:"key_id"`
+	Secret    string `json:"secret"`
+	NotBefore string `json:"not_before"` // RFC3339，空表示不限制
+	NotAfter  string `json:"not_after"`  // RFC3339，空表示不限制
+}
+
+type RateLimitRuleConfig struct {
+	Name       string  `json:"name"`
+	MsgBegin   int     `json:"msg_begin"`
+	MsgEnd     int     `json:"msg_end"`
+	Burst      int     `json:"burst"`
+	RatePerSec float64 `json:"rate_per_sec"`
+}
+
+type RateLimitConfig struct {
+	Rules         []RateLimitRuleConfig `json:"rules"`
+	KickCount     int                   `json:"kick_count"`
+	KickWindowSec int                   `json:"kick_window_sec"` // kick_count 的统计窗口，0 取默认 60 秒
+}
+
+type IPFilterConfig struct {
+	MaxConnsPerIP  int      `json:"max_conns_per_ip"`
+	NewConnsPerSec float64  `json:"new_conns_per_sec"`
+	Allow          []string `json:"allow"` // IP 或 CIDR，命中后不受 deny / 限额影响
+	Deny           []string `json:"deny"`
+}
+
+type GameShardConfig struct {
+	ID   string `json:"id"`
+	Addr string `json:"addr"`
+}
+
+// GameShardsConfig games 为空时只有 game_addr 一个服（id 为 game-1）
+type GameShardsConfig struct {
+	Games  []GameShardConfig `json:"games"`
+	Assign string            `json:"assign"` // hash：一致性哈希；redis：Redis 显式分配，首次按哈希写入
+	Redis  RedisConfig       `json:"redis"`  // assign=redis 时使用，独立连接，不与会话 / 存储共用 Redis
+}
+
+// CompressionConfig algorithms 为服务端偏好顺序（zstd / snappy），为空不压缩
+type CompressionConfig struct {
+	Algorithms []string `json:"algorithms"`
+	Threshold  int      `json:"threshold"` // payload 超过该字节数才压缩
+}
+
+// WebSocketDeflateConfig permessage-deflate；level 为 flate 压缩级别，0 使用默认
+type WebSocketDeflateConfig struct {
+	Enabled bool `json:"enabled"`
+	Level   int  `json:"level"`
+}
+
+// HandshakeConfig 协议版本为 0 时取当前版本；required 为 true 时客户端必须先握手
+type HandshakeConfig struct {
+	MinProtocolVersion uint32 `json:"min_protocol_version"`
+	MaxProtocolVersion uint32 `json:"max_protocol_version"`
+	MinClientVersion   string `json:"min_client_version"` // 低于该版本的客户端被拒绝并提示升级
+	UpgradeURL         string `json:"upgrade_url"`
+	Required           bool   `json:"required"`
+}
+
+// KCPConfig 可靠 UDP 监听；interval_ms / 窗口 / mtu 为 0 取默认，data_shards + parity_shards 开启 FEC
+type KCPConfig struct {
+	Enabled      bool   `json:"enabled"`
+	ListenAddr   string `json:"listen_addr"`
+	NoDelay      bool   `json:"nodelay"`
+	IntervalMs   int    `json:"interval_ms"`
+	Resend       int    `json:"resend"`
+	NoCongestion bool   `json:"no_congestion"`
+	SendWindow   int    `json:"send_window"`
+	RecvWindow   int    `json:"recv_window"`
+	MTU          int    `json:"mtu"`
+	DataShards   int    `json:"data_shards"`
+	ParityShards int    `json:"parity_shards"`
+	SocketBuffer int    `json:"socket_buffer"`
+
+	// KCP 不走 TLS；配置了 tls 时必须显式设为 true 才允许明文 KCP 与之并存，否则拒绝启动
+	Plaintext bool `json:"plaintext"`
+
+	HeartbeatTimeoutSec int `json:"heartbeat_timeout_sec"` // 0 时为 heartbeat_timeout_sec 的两倍
+}
+
+// WriteCoalesceConfig 写协程合并发送：max_batch 为 0 取默认 64；max_delay_us 为 0 只合并已排队的消息
+type WriteCoalesceConfig struct {
+	MaxBatch   int `json:"max_batch"`
+	MaxDelayUs int `json:"max_delay_us"`
+}
+
+// DeliveryConfig 下行投递等级：列出的 msgID 覆盖内置等级，其余为 normal；队列长度为 0 取默认
+type DeliveryConfig struct {
+	Critical  []int `json:"critical"`
+	Normal    []int `json:"normal"`
+	Droppable []int `json:"droppable"` // 同一 msgID 只保留最新一条
+
+	CriticalQueue  int `json:"critical_queue"`
+	NormalQueue    int `json:"normal_queue"`
+	DroppableQueue int `json:"droppable_queue"`
+}
+
+// TLSConfig cert_file 为空表示不启用；内部链路 client_auth 默认 require_and_verify（mTLS）
+type TLSConfig struct {
+	CertFile   string `json:"cert_file"`
+	KeyFile    string `json:"key_file"`
+	CAFile     string `json:"ca_file"`
+	MinVersion string `json:"min_version"` // 1.2 / 1.3，默认 1.2
+	ClientAuth string `json:"client_auth"` // none / request / require / verify_if_given / require_and_verify
+	ServerName string `json:"server_name"` // 内部链路校验对端证书用的名字，为空取地址中的主机
+}
+
+type SessionStoreConfig struct {
+	Enabled bool        `json:"enabled"`
+	TTLSec  int         `json:"ttl_sec"`
+	Redis   RedisConfig `json:"redis"`
+}
+
 type GateConfig struct {
-	ListenAddr           string `json:"listen_addr"`
-	WebSocketListenAddr  string `json:"websocket_listen_addr"`
-	WebSocketPath        string `json:"websocket_path"`
-	EnableTCP            bool   `json:"enable_tcp"`
-	EnableWebSocket      bool   `json:"enable_websocket"`
-	WebSocketUseJSON     bool   `json:"websocket_use_json"`
-	ServiceAddr          string `json:"service_addr"`
-	GameAddr             string `json:"game_addr"`
-	ServicePoolSize      int    `json:"service_pool_size"`
-	HeartbeatIntervalSec int    `json:"heartbeat_interval_sec"`
-	HeartbeatTimeoutSec  int    `json:"heartbeat_timeout_sec"`
-	GCIntervalSec        int    `json:"gc_interval_sec"`
-	LoginTimeoutSec      int    `json:"login_timeout_sec"`
-	LoginRateLimitCount  int    `json:"login_rate_limit_count"`
-	LoginRateLimitWindow int    `json:"login_rate_limit_window_sec"`
-	UnknownMsgKickCount  int    `json:"unknown_msg_kick_count"`
-	ConnReadTimeoutSec   int    `json:"conn_read_timeout_sec"`
-	ConnWriteTimeoutSec  int    `json:"conn_write_timeout_sec"`
-	ConnKeepAliveSec     int    `json:"conn_keepalive_sec"`
-	MaxEnvelopeSize      uint32 `json:"max_envelope_size"`
+	GateID               string   `json:"gate_id"`
+	ListenAddr           string   `json:"listen_addr"`
+	WebSocketListenAddr  string   `json:"websocket_listen_addr"`
+	WebSocketPath        string   `json:"websocket_path"`
+	EnableTCP            bool     `json:"enable_tcp"`
+	EnableWebSocket      bool     `json:"enable_websocket"`
+	WebSocketUseJSON     bool     `json:"websocket_use_json"`
+	ServiceAddr          string   `json:"service_addr"`
+	ServiceAddrs         []string `json:"service_addrs"` // 多个 service 端点；为空时只用 service_addr
+	GameAddr             string   `json:"game_addr"`
+	RoutesPath           string   `json:"routes_path"`
+	ServicePoolSize      int      `json:"service_pool_size"`
+	GamePoolSize         int      `json:"game_pool_size"` // 配置了 game 服时 Gate 直连 Game
+	ServicePingSec       int      `json:"service_ping_interval_sec"`
+	ServiceHealthSec     int      `json:"service_health_timeout_sec"`
+	HeartbeatIntervalSec int      `json:"heartbeat_interval_sec"`
+	HeartbeatTimeoutSec  int      `json:"heartbeat_timeout_sec"`
+	GCIntervalSec        int      `json:"gc_interval_sec"`
+	LoginTimeoutSec      int      `json:"login_timeout_sec"`
+	LoginRateLimitCount  int      `json:"login_rate_limit_count"`
+	LoginRateLimitWindow int      `json:"login_rate_limit_window_sec"`
+	UnknownMsgKickCount  int      `json:"unknown_msg_kick_count"`
+	ConnReadTimeoutSec   int      `json:"conn_read_timeout_sec"`
+	ConnWriteTimeoutSec  int      `json:"conn_write_timeout_sec"`
+	ConnKeepAliveSec     int      `json:"conn_keepalive_sec"`
+	MaxEnvelopeSize      uint32   `json:"max_envelope_size"`
+	ReplayBufferSize     int      `json:"replay_buffer_size"`
+	AdminListenAddr      string   `json:"admin_listen_addr"`
+	AdminToken           string   `json:"admin_token"`
+	MetricsListenAddr    string   `json:"metrics_listen_addr"`
+
+	ResumeKeys        []ResumeKeyConfig `json:"resume_keys"`
+	ResumeTokenTTLSec int               `json:"resume_token_ttl_sec"`
+
+	SessionStore SessionStoreConfig `json:"session_store"`
+
+	GameShards GameShardsConfig `json:"game_shards"`
+
+	Compression      CompressionConfig      `json:"compression"`
+	WebSocketDeflate WebSocketDeflateConfig `json:"websocket_deflate"`
+
+	Handshake HandshakeConfig `json:"handshake"`
+
+	KCP KCPConfig `json:"kcp"` // 不走 TLS；tls 已配置时需 kcp.plaintext=true 才允许启用
+
+	WriteCoalesce WriteCoalesceConfig `json:"write_coalesce"`
+
+	Delivery DeliveryConfig `json:"delivery"`
+
+	TLS         TLSConfig `json:"tls"`          // 客户端 TCP / WebSocket 监听（WSS）
+	InternalTLS TLSConfig `json:"internal_tls"` // Gate → Service / Game
+
+	RateLimit RateLimitConfig `json:"rate_limit"`
+	IPFilter  IPFilterConfig  `json:"ip_filter"`
+}
+
+type TokenVerifierConfig struct {
+	Platform    int32  `json:"platform"`
+	Endpoint    string `json:"endpoint"`
+	TimeoutMs   int    `json:"timeout_ms"`
+	Secret      string `json:"secret"`
+	CacheTTLSec int    `json:"cache_ttl_sec"`
+}
+
+type LoginConfig struct {
+	Verifiers []TokenVerifierConfig `json:"verifiers"`
+	// AllowTestPlatform 放行测试平台（platform 0，直接信任客户端的 account_id），仅限联调环境
+	AllowTestPlatform bool `json:"allow_test_platform"`
 }
 
 type ServiceConfig struct {
 	ListenAddr          string      `json:"listen_addr"`
 	GameAddr            string      `json:"game_addr"`
+	RoutesPath          string      `json:"routes_path"`
 	ConnReadTimeoutSec  int         `json:"conn_read_timeout_sec"`
 	ConnWriteTimeoutSec int         `json:"conn_write_timeout_sec"`
 	ConnKeepAliveSec    int         `json:"conn_keepalive_sec"`
 	MaxEnvelopeSize     uint32      `json:"max_envelope_size"`
+	MetricsListenAddr   string      `json:"metrics_listen_addr"`
+	DBAddr              string      `json:"db_addr"` // 非空时经 DB 代理访问存储，不再直连 Redis
+	DBTimeoutMs         int         `json:"db_timeout_ms"`
 	Redis               RedisConfig `json:"redis"`
+	Login               LoginConfig `json:"login"`
+
+	GameShards GameShardsConfig `json:"game_shards"`
+
+	WriteCoalesce WriteCoalesceConfig `json:"write_coalesce"`
+
+	InternalTLS TLSConfig `json:"internal_tls"` // Gate → Service 监听与 Service → Game / DB 拨号
 }
 
 type GameConfig struct {
@@ -56,7 +243,24 @@ type GameConfig struct {
 	ConnWriteTimeoutSec int         `json:"conn_write_timeout_sec"`
 	ConnKeepAliveSec    int         `json:"conn_keepalive_sec"`
 	MaxEnvelopeSize     uint32      `json:"max_envelope_size"`
+	MetricsListenAddr   string      `json:"metrics_listen_addr"`
+	DBAddr              string      `json:"db_addr"` // 非空时经 DB 代理访问存储，不再直连 Redis
+	DBTimeoutMs         int         `json:"db_timeout_ms"`
 	Redis               RedisConfig `json:"redis"`
+
+	InternalTLS TLSConfig `json:"internal_tls"`
+}
+
+type DBConfig struct {
+	ListenAddr          string      `json:"listen_addr"`
+	ConnReadTimeoutSec  int         `json:"conn_read_timeout_sec"`
+	ConnWriteTimeoutSec int         `json:"conn_write_timeout_sec"`
+	ConnKeepAliveSec    int         `json:"conn_keepalive_sec"`
+	MaxEnvelopeSize     uint32      `json:"max_envelope_size"`
+	MetricsListenAddr   string      `json:"metrics_listen_addr"`
+	Redis               RedisConfig `json:"redis"`
+
+	InternalTLS TLSConfig `json:"internal_tls"`
 }
 
 func Load(path string, out any) error {
@@ -1 +1,319 @@
+// internal/db/dbserver.go
 package db
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+
+	"game-server/internal/handler"
+	"game-server/internal/metrics"
+	"game-server/internal/player_db"
+	"game-server/internal/protocol"
+	"game-server/internal/protocol/internalpb"
+	"game-server/internal/transport"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	dbRequests = metrics.NewCounterVec("db_requests_total",
+		"DB proxy requests by msg_id and result.", "msg_id", "result")
+	dbRequestLatency = metrics.NewHistogramVec("db_request_duration_seconds",
+		"Time spent serving a DB proxy request.", nil, "msg_id")
+)
+
+const (
+	connWorkers    = 64   // 每条连接同时处理的请求上限，限制单个 game 对 Redis 的并发
+	connWriteQueue = 1024 // 每条连接待写回的响应队列
+)
+
+// UIDAllocator 全局 UID 分配（Redis INCR）
+type UIDAllocator interface {
+	NextUID(ctx context.Context) (int64, error)
+}
+
+// HandlerFunc 解析请求并返回响应；返回 error 表示请求本身无法解析（无法回包）
+type HandlerFunc func(ctx context.Context, payload []byte) (int, proto.Message, error)
+
+// Server DB 代理进程：对内提供 Envelope 协议的存储接口，
+// 只有它持有 Redis 凭据，game / service 通过 player_db.RemoteStore 访问
+type Server struct {
+	addr        string
+	store       player_db.Store
+	uid         UIDAllocator
+	logger      *zap.Logger
+	connOptions transport.ConnOptions
+
+	requestTimeout time.Duration
+
+	handlers *handler.Registry[HandlerFunc]
+}
+
+func NewServer(addr string, store player_db.Store, uid UIDAllocator, logger *zap.Logger, options transport.ConnOptions) *Server {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	s := &Server{
+		addr:           addr,
+		store:          store,
+		uid:            uid,
+		logger:         logger,
+		connOptions:    options,
+		requestTimeout: 3 * time.Second,
+		handlers:       handler.NewRegistry[HandlerFunc](),
+	}
+	if err := s.registerHandlers(); err != nil {
+		logger.Warn("register db handlers failed", zap.String("reason", err.Error()))
+	}
+	metrics.Register(dbRequests, dbRequestLatency)
+	return s
+}
+
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	ln, err := transport.Listen(s.addr, s.connOptions)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	go func() {
+		<-ctx.Done()
+		_ = ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return nil
+			default:
+				continue
+			}
+		}
+		go s.handleConn(ctx, conn)
+	}
+}
+
+// handleConn 读协程只负责取请求：每条请求交给一个 worker 处理，同一连接最多 connWorkers 个并发，
+// 满了读协程暂停读取形成背压；响应统一交给写协程合批写回。
+// RemoteStore 的每次调用都同步等待自己的响应，同一调用方的先写后读不依赖连接上的处理顺序
+func (s *Server) handleConn(ctx context.Context, conn net.Conn) {
+	bc := transport.NewBufferedConnWithOptions(conn, s.connOptions)
+	defer bc.Close()
+
+	addr := conn.RemoteAddr().String()
+	s.logger.Info("db client connected", zap.String("addr", addr))
+
+	out := make(chan *internalpb.Envelope, connWriteQueue)
+	done := make(chan struct{})
+	defer close(done)
+	go s.writeLoop(bc, out, done)
+
+	workers := make(chan struct{}, connWorkers)
+	for {
+		env, err := bc.ReadEnvelope()
+		if err != nil {
+			s.logger.Info("db client disconnected",
+				zap.String("addr", addr),
+				zap.String("reason", err.Error()),
+			)
+			return
+		}
+
+		workers <- struct{}{}
+		go func() {
+			defer func() { <-workers }()
+			rsp := s.dispatch(ctx, env)
+			select {
+			case out <- rsp:
+			case <-done:
+			}
+		}()
+	}
+}
+
+// writeLoop 合批写回响应；写失败关闭连接，读协程随之退出
+func (s *Server) writeLoop(bc *transport.BufferedConn, out <-chan *internalpb.Envelope, done <-chan struct{}) {
+	co := transport.NewCoalescer[*internalpb.Envelope](s.connOptions)
+	for {
+		select {
+		case rsp := <-out:
+			batch := co.Collect(out, rsp, done)
+			if _, err := transport.WriteBatch(bc, batch); err != nil {
+				s.logger.Warn("db write rsp failed",
+					zap.Int("msg_id", int(rsp.MsgId)),
+					zap.String("reason", err.Error()),
+				)
+				_ = bc.Close()
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// dispatch 总是返回一个响应：找不到 handler 或请求解析失败时回 DBErrorRsp，调用方据 req_id 立即失败
+func (s *Server) dispatch(ctx context.Context, env *internalpb.Envelope) *internalpb.Envelope {
+	msgID := int(env.MsgId)
+	label := strconv.Itoa(msgID)
+	start := time.Now()
+
+	h, ok := s.handlers.Get(msgID)
+	if !ok {
+		dbRequests.With("unknown", "no_handler").Inc()
+		s.logger.Warn("unknown db msgID",
+			zap.Int("msg_id", msgID),
+			zap.String("reason", "handler_not_found"),
+		)
+		return errorEnvelope(env, "unknown db msgID "+label)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	rspID, rsp, err := h(reqCtx, env.Payload)
+	dbRequestLatency.With(label).Observe(time.Since(start).Seconds())
+	if err != nil {
+		dbRequests.With(label, "bad_request").Inc()
+		s.logger.Warn("decode db request failed",
+			zap.Int("msg_id", msgID),
+			zap.String("reason", err.Error()),
+		)
+		return errorEnvelope(env, "bad request: "+err.Error())
+	}
+
+	data, err := proto.Marshal(rsp)
+	if err != nil {
+		dbRequests.With(label, "marshal_failed").Inc()
+		s.logger.Warn("marshal db rsp failed",
+			zap.Int("msg_id", rspID),
+			zap.String("reason", err.Error()),
+		)
+		return errorEnvelope(env, "marshal rsp: "+err.Error())
+	}
+	dbRequests.With(label, "ok").Inc()
+	return &internalpb.Envelope{
+		MsgId:     int32(rspID),
+		SessionId: env.SessionId,
+		PlayerId:  env.PlayerId,
+		Payload:   data,
+	}
+}
+
+// errorEnvelope 所有 DB 请求的第 1 个字段都是 req_id，请求体整体解析失败时仍按 DBRspHeader 尽量取出它；
+// 取不到时 req_id 为 0，调用方只能等超时
+func errorEnvelope(env *internalpb.Envelope, msg string) *internalpb.Envelope {
+	var header internalpb.DBRspHeader
+	_ = proto.Unmarshal(env.Payload, &header)
+	data, _ := proto.Marshal(&internalpb.DBErrorRsp{
+		ReqId: header.ReqId,
+		Error: msg,
+	})
+	return &internalpb.Envelope{
+		MsgId:     protocol.MsgDBErrorRsp,
+		SessionId: env.SessionId,
+		PlayerId:  env.PlayerId,
+		Payload:   data,
+	}
+}
+
+func (s *Server) registerHandlers() error {
+	if err := s.handlers.Register(protocol.MsgDBLoadRoleReq, s.onLoadRole); err != nil {
+		return err
+	}
+	if err := s.handlers.Register(protocol.MsgDBSaveRoleReq, s.onSaveRole); err != nil {
+		return err
+	}
+	if err := s.handlers.Register(protocol.MsgDBLoadProfileReq, s.onLoadProfile); err != nil {
+		return err
+	}
+	if err := s.handlers.Register(protocol.MsgDBSaveProfileReq, s.onSaveProfile); err != nil {
+		return err
+	}
+	return s.handlers.Register(protocol.MsgDBNextUIDReq, s.onNextUID)
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func (s *Server) onLoadRole(ctx context.Context, payload []byte) (int, proto.Message, error) {
+	var req internalpb.DBLoadRoleReq
+	if err := proto.Unmarshal(payload, &req); err != nil {
+		return 0, nil, err
+	}
+	roleID, found, err := s.store.LoadRoleID(ctx, req.AccountId)
+	return protocol.MsgDBLoadRoleRsp, &internalpb.DBLoadRoleRsp{
+		ReqId:  req.ReqId,
+		RoleId: roleID,
+		Found:  found,
+		Error:  errString(err),
+	}, nil
+}
+
+func (s *Server) onSaveRole(ctx context.Context, payload []byte) (int, proto.Message, error) {
+	var req internalpb.DBSaveRoleReq
+	if err := proto.Unmarshal(payload, &req); err != nil {
+		return 0, nil, err
+	}
+	err := s.store.SaveRoleID(ctx, req.AccountId, req.RoleId)
+	return protocol.MsgDBSaveRoleRsp, &internalpb.DBSaveRoleRsp{
+		ReqId: req.ReqId,
+		Error: errString(err),
+	}, nil
+}
+
+func (s *Server) onLoadProfile(ctx context.Context, payload []byte) (int, proto.Message, error) {
+	var req internalpb.DBLoadProfileReq
+	if err := proto.Unmarshal(payload, &req); err != nil {
+		return 0, nil, err
+	}
+	profile, found, err := s.store.LoadProfile(ctx, req.RoleId)
+	rsp := &internalpb.DBLoadProfileRsp{
+		ReqId: req.ReqId,
+		Found: found,
+		Error: errString(err),
+	}
+	if profile != nil {
+		rsp.Profile = player_db.ToDBProfile(profile)
+	}
+	return protocol.MsgDBLoadProfileRsp, rsp, nil
+}
+
+func (s *Server) onSaveProfile(ctx context.Context, payload []byte) (int, proto.Message, error) {
+	var req internalpb.DBSaveProfileReq
+	if err := proto.Unmarshal(payload, &req); err != nil {
+		return 0, nil, err
+	}
+	var err error
+	if req.Profile != nil {
+		err = s.store.SaveProfile(ctx, player_db.FromDBProfile(req.Profile))
+	}
+	return protocol.MsgDBSaveProfileRsp, &internalpb.DBSaveProfileRsp{
+		ReqId: req.ReqId,
+		Error: errString(err),
+	}, nil
+}
+
+func (s *Server) onNextUID(ctx context.Context, payload []byte) (int, proto.Message, error) {
+	var req internalpb.DBNextUIDReq
+	if err := proto.Unmarshal(payload, &req); err != nil {
+		return 0, nil, err
+	}
+	rsp := &internalpb.DBNextUIDRsp{ReqId: req.ReqId}
+	if s.uid == nil {
+		rsp.Error = "uid allocator not configured"
+	} else {
+		uid, err := s.uid.NextUID(ctx)
+		rsp.Uid = uid
+		rsp.Error = errString(err)
+	}
+	return protocol.MsgDBNextUIDRsp, rsp, nil
+}
@@ -28,3 +28,23 @@ func AccountRoleKey(accountID string) string {
 func PlayerProfileKey(roleID int64) string {
 	return fmt.Sprintf("%s%s:profile", keyPlayerPrefix, strconv.FormatInt(roleID, 10))
 }
+
+const (
+	KeyGateSessionNext = "gate:session:next"
+
+	keyGateSessionPrefix = "gate:session:"
+	keyGatePrefix        = "gate:"
+)
+
+func GateSessionKey(sessionID int64) string {
+	return fmt.Sprintf("%s%d", keyGateSessionPrefix, sessionID)
+}
+
+func GateControlChannel(gateID string) string {
+	return fmt.Sprintf("%s%s:control", keyGatePrefix, gateID)
+}
+
+// PlayerShardKey 玩家 -> game 服 的显式分配
+func PlayerShardKey(playerID int64) string {
+	return fmt.Sprintf("%s%d:shard", keyPlayerPrefix, playerID)
+}
@@ -24,6 +24,22 @@ var (
 
 // InitRedis：只做一次初始化
 func InitRedis(cfg RedisConfig) error {
+	client, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	redisCfg = cfg
+	redisClient = client
+	mu.Unlock()
+
+	return nil
+}
+
+// NewClient 按配置新建一个独立的 client 并 Ping 一次；不替换全局 RDB()，
+// 用于和主存储分开部署的数据（如 game 分片分配）
+func NewClient(cfg RedisConfig) (*redis.Client, error) {
 	if cfg.PoolSize <= 0 {
 		cfg.PoolSize = 200
 	}
@@ -47,15 +63,9 @@ func InitRedis(cfg RedisConfig) error {
 
 	if err := client.Ping(ctx).Err(); err != nil {
 		_ = client.Close()
-		return err
+		return nil, err
 	}
-
-	mu.Lock()
-	redisCfg = cfg
-	redisClient = client
-	mu.Unlock()
-
-	return nil
+	return client, nil
 }
 
 // RDB：统一出口（永不返回 nil）
@@ -128,6 +128,21 @@ func (rd *RedisDao) Pipe() redis.Pipeliner {
 	return rd.client.Pipeline()
 }
 
+//
+// =======================
+// 发布 / 订阅
+// =======================
+//
+
+func (rd *RedisDao) Publish(ctx context.Context, channel string, message interface{}) error {
+	return rd.client.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe 调用方负责 Close 返回的 PubSub
+func (rd *RedisDao) Subscribe(ctx context.Context, channels ...string) *redis.PubSub {
+	return rd.client.Subscribe(ctx, channels...)
+}
+
 /***************************** 针对redis操作自定义的方法 *****************************/
 
 /*
@@ -0,0 +1,59 @@
+// internal/game/metrics.go
+package game
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"game-server/internal/game/player_module"
+	"game-server/internal/metrics"
+)
+
+var (
+	gameConnectionsVec = metrics.NewGaugeVec("game_connections",
+		"Connected service / gate links.")
+	gameConnections = gameConnectionsVec.With()
+	gameRequests    = metrics.NewCounterVec("game_requests_total",
+		"Envelopes dispatched to players by msg_id and result.", "msg_id", "result")
+	gameRequestLatency = metrics.NewHistogramVec("game_request_duration_seconds",
+		"Time spent in player message handlers.", nil, "msg_id")
+	gameMigrations = metrics.NewCounterVec("game_player_migrations_total",
+		"Player migrations handled by direction (in / out) and result.", "direction", "result")
+)
+
+func observeRequest(msgID int, err error, cost time.Duration) {
+	label, result := strconv.Itoa(msgID), "ok"
+	switch {
+	case errors.Is(err, player_module.ErrUnknownMessage):
+		label, result = "unknown", "unknown_msg"
+	case err != nil:
+		result = "error"
+	}
+	gameRequests.With(label, result).Inc()
+	gameRequestLatency.With(label).Observe(cost.Seconds())
+}
+
+func observeMigration(direction string, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	gameMigrations.With(direction, result).Inc()
+}
+
+func (s *Server) registerMetrics() {
+	metrics.Register(gameConnectionsVec, gameRequests, gameRequestLatency, gameMigrations)
+	metrics.Register(metrics.NewGaugeFunc("game_players", "Resident players by state.", []string{"state"}, func(emit metrics.EmitFunc) {
+		counts := s.players.CountByState()
+		for _, st := range []player_module.PlayerState{
+			player_module.PlayerStateInit,
+			player_module.PlayerStateActive,
+			player_module.PlayerStateOffline,
+			player_module.PlayerStateDestroyed,
+			player_module.PlayerStateMigrating,
+		} {
+			emit(float64(counts[st]), st.String())
+		}
+	}))
+}
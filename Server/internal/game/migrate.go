@@ -0,0 +1,148 @@
+// internal/game/migrate.go
+package game
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"game-server/internal/game/player_module"
+	"game-server/internal/protocol"
+	"game-server/internal/protocol/internalpb"
+	"game-server/internal/transport"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// migrateTimeout 冻结 + 落盘的上限，需小于 Gate 等待迁移响应的超时
+const migrateTimeout = 5 * time.Second
+
+// handleMigrateReq 迁出：BeginMigrate 同步执行，保证同一连接上后续消息都被暂存；
+// 冻结和落盘放到协程里，避免阻塞这条连接上其他玩家的消息
+func (s *Server) handleMigrateReq(ctx context.Context, bc *transport.BufferedConn, env *internalpb.Envelope) {
+	var req internalpb.PlayerMigrateReq
+	if err := proto.Unmarshal(env.Payload, &req); err != nil || req.PlayerId == 0 {
+		s.logger.Warn("invalid migrate req",
+			zap.Int("msg_id", int(env.MsgId)),
+			zap.Int64("session", env.SessionId),
+			zap.String("reason", "bad_payload"),
+		)
+		return
+	}
+
+	reply := func(pending []*internalpb.Envelope, resident bool, err error) {
+		rsp := &internalpb.PlayerMigrateRsp{
+			PlayerId: req.PlayerId,
+			Ok:       err == nil,
+			Resident: resident,
+			Pending:  pending,
+		}
+		if err != nil {
+			rsp.Error = err.Error()
+		}
+		payload, _ := proto.Marshal(rsp)
+		if werr := bc.WriteEnvelope(&internalpb.Envelope{
+			MsgId:     protocol.MsgPlayerMigrateRsp,
+			SessionId: env.SessionId,
+			PlayerId:  req.PlayerId,
+			Payload:   payload,
+		}); werr != nil {
+			s.logger.Warn("send migrate rsp failed",
+				zap.Int("msg_id", protocol.MsgPlayerMigrateRsp),
+				zap.Int64("session", env.SessionId),
+				zap.Int64("player", req.PlayerId),
+				zap.String("reason", werr.Error()),
+			)
+		}
+	}
+
+	if err := s.players.BeginMigrate(req.PlayerId); err != nil {
+		observeMigration("out", err)
+		reply(nil, false, err)
+		return
+	}
+
+	go func() {
+		mctx, cancel := context.WithTimeout(ctx, migrateTimeout)
+		pending, resident, err := s.players.Migrate(mctx, req.PlayerId)
+		cancel()
+
+		observeMigration("out", err)
+		if err != nil {
+			s.logger.Warn("player migrate out failed",
+				zap.Int("msg_id", int(env.MsgId)),
+				zap.Int64("session", env.SessionId),
+				zap.Int64("player", req.PlayerId),
+				zap.String("reason", err.Error()),
+				zap.String("target", req.TargetShard),
+			)
+		} else {
+			s.logger.Info("player migrated out",
+				zap.Int("msg_id", int(env.MsgId)),
+				zap.Int64("session", env.SessionId),
+				zap.Int64("player", req.PlayerId),
+				zap.String("reason", "migrate"),
+				zap.String("target", req.TargetShard),
+				zap.Int("pending", len(pending)),
+			)
+		}
+		reply(pending, resident, err)
+	}()
+}
+
+// handleMigrateIn 迁入：清理墓碑；玩家在线时（带 session）提前加载，避免首条消息等 DB
+func (s *Server) handleMigrateIn(ctx context.Context, env *internalpb.Envelope) {
+	var req internalpb.PlayerMigrateIn
+	if err := proto.Unmarshal(env.Payload, &req); err != nil || req.PlayerId == 0 {
+		s.logger.Warn("invalid migrate in",
+			zap.Int("msg_id", int(env.MsgId)),
+			zap.Int64("session", env.SessionId),
+			zap.String("reason", "bad_payload"),
+		)
+		return
+	}
+	s.players.MigrateIn(req.PlayerId)
+
+	var err error
+	if env.SessionId != 0 {
+		_, err = s.players.GetOrCreate(ctx, env.SessionId, req.PlayerId)
+	}
+	observeMigration("in", err)
+	if err != nil {
+		s.logger.Warn("player migrate in load failed",
+			zap.Int("msg_id", int(env.MsgId)),
+			zap.Int64("session", env.SessionId),
+			zap.Int64("player", req.PlayerId),
+			zap.String("reason", err.Error()),
+		)
+		return
+	}
+	s.logger.Info("player migrated in",
+		zap.Int("msg_id", int(env.MsgId)),
+		zap.Int64("session", env.SessionId),
+		zap.Int64("player", req.PlayerId),
+		zap.String("reason", "migrate"),
+	)
+}
+
+// holdForMigration 玩家迁出中 / 刚迁出时接管消息，返回 true 表示调用方不再处理
+func (s *Server) holdForMigration(playerID int64, env *internalpb.Envelope) bool {
+	held, err := s.players.Hold(playerID, env)
+	if err != nil {
+		s.logger.Warn("drop message of migrated player",
+			zap.Int("msg_id", int(env.MsgId)),
+			zap.Int64("session", env.SessionId),
+			zap.Int64("player", playerID),
+			zap.String("reason", err.Error()),
+		)
+	}
+	return held
+}
+
+// retryHold Dispatch 与 BeginMigrate 并发时玩家可能已冻结，此时改为暂存
+func (s *Server) retryHold(playerID int64, env *internalpb.Envelope, err error) bool {
+	if !errors.Is(err, player_module.ErrPlayerMigrating) {
+		return false
+	}
+	return s.holdForMigration(playerID, env)
+}
@@ -0,0 +1,92 @@
+// game/player/handler_table.go
+package player_module
+
+import (
+	"errors"
+	"fmt"
+
+	"game-server/internal/protocol/codec"
+	"game-server/internal/protocol/internalpb"
+	"game-server/internal/protocol/msgtype"
+	"google.golang.org/protobuf/proto"
+)
+
+type tableHandler func(ctx *PlayerContext, env *internalpb.Envelope) (*internalpb.Envelope, error)
+
+// HandlerTable 模块内的 msgID → 强类型 handler；模块在构造时用 Handle 填表，
+// CanHandle / Handle 委托给 Has / Dispatch 即可
+type HandlerTable struct {
+	handlers map[int]tableHandler
+	errs     []error
+}
+
+func NewHandlerTable() *HandlerTable {
+	return &HandlerTable{handlers: make(map[int]tableHandler)}
+}
+
+// Handle 按 msgtype 表注册：请求按客户端编码解出，返回的 Rsp 按表里的回包 msgID、同一编码回给客户端；
+// Rsp 为 nil 表示没有回包。类型与表不一致 / 重复注册的错误由 Err 返回，启动时 CheckModules 统一检查
+func Handle[Req, Rsp any, PReq interface {
+	*Req
+	proto.Message
+}, PRsp interface {
+	*Rsp
+	proto.Message
+}](t *HandlerTable, msgID int, fn func(*PlayerContext, PReq) (PRsp, error)) {
+	rspID, err := msgtype.RequireReply[PReq, PRsp](msgtype.Default, msgID)
+	if err == nil {
+		if _, exists := t.handlers[msgID]; exists {
+			err = fmt.Errorf("msgID %d already registered", msgID)
+		}
+	}
+	if err != nil {
+		t.errs = append(t.errs, err)
+		return
+	}
+	t.handlers[msgID] = func(ctx *PlayerContext, env *internalpb.Envelope) (*internalpb.Envelope, error) {
+		req := PReq(new(Req))
+		if err := codec.Decode(env, req); err != nil {
+			return nil, err
+		}
+		rsp, err := fn(ctx, req)
+		if err != nil || rsp == nil {
+			return nil, err
+		}
+		data, err := codec.Encode(env.Codec, rsp)
+		if err != nil {
+			return nil, err
+		}
+		return &internalpb.Envelope{
+			MsgId:     int32(rspID),
+			SessionId: env.SessionId,
+			PlayerId:  env.PlayerId,
+			Payload:   data,
+			Codec:     env.Codec,
+		}, nil
+	}
+}
+
+func (t *HandlerTable) Has(msgID int) bool {
+	_, ok := t.handlers[msgID]
+	return ok
+}
+
+// Dispatch 返回值与 Module.Handle 一致
+func (t *HandlerTable) Dispatch(ctx *PlayerContext, msgID int, env *internalpb.Envelope) (*internalpb.Envelope, bool, error) {
+	h, ok := t.handlers[msgID]
+	if !ok {
+		return nil, false, nil
+	}
+	rsp, err := h(ctx, env)
+	return rsp, true, err
+}
+
+// Err 填表时的错误（类型不匹配、重复注册）
+func (t *HandlerTable) Err() error {
+	return errors.Join(t.errs...)
+}
+
+// TableModule 用 HandlerTable 的模块实现它，CheckModules 据此在启动时检查
+type TableModule interface {
+	Handlers() *HandlerTable
+}
@@ -2,6 +2,8 @@
 package player_module
 
 import (
+	"context"
+
 	"game-server/internal/protocol/internalpb"
 )
 
@@ -20,3 +22,8 @@ type Module interface {
 		env *internalpb.Envelope,
 	) (*internalpb.Envelope, bool, error)
 }
+
+// Flusher 可选：模块持有需要单独落盘的数据时实现，迁移前在冻结状态下调用
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
@@ -2,6 +2,11 @@ package player_module
 
 // game/player/module_registry.go
 
+import (
+	"errors"
+	"fmt"
+)
+
 var registeredModules []func() Module
 
 func RegisterModule(f func() Module) {
@@ -15,3 +20,27 @@ func CreateModules() []Module {
 	}
 	return ms
 }
+
+// CheckModules 启动时调用：每个模块实例化一次，检查 HandlerTable 填表错误与 msgID 冲突
+func CheckModules() error {
+	var errs []error
+	owner := make(map[int]string)
+	for _, m := range CreateModules() {
+		tm, ok := m.(TableModule)
+		if !ok {
+			continue
+		}
+		t := tm.Handlers()
+		if err := t.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("module %s: %w", m.Name(), err))
+		}
+		for msgID := range t.handlers {
+			if other, dup := owner[msgID]; dup {
+				errs = append(errs, fmt.Errorf("module %s: msgID %d already handled by %s", m.Name(), msgID, other))
+				continue
+			}
+			owner[msgID] = m.Name()
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -4,24 +4,27 @@ package base
 import (
 	"game-server/internal/game/player_module"
 
-	"game-server/internal/protocol"
 	"game-server/internal/protocol/internalpb"
-	"google.golang.org/protobuf/proto"
 )
 
 type BaseModule struct {
-	p *player_module.Player
+	p        *player_module.Player
+	handlers *player_module.HandlerTable
 }
 
 func New() player_module.Module {
-	return &BaseModule{}
+	m := &BaseModule{handlers: player_module.NewHandlerTable()}
+	player_module.HandlePlayerEnterGameReq(m.handlers, m.onEnterGame)
+	player_module.HandleLoadPlayerDataReq(m.handlers, m.onLoadPlayerData)
+	return m
 }
 
 func (m *BaseModule) Name() string { return "base" }
 
+func (m *BaseModule) Handlers() *player_module.HandlerTable { return m.handlers }
+
 func (m *BaseModule) CanHandle(msgID int) bool {
-	return msgID == protocol.MsgPlayerEnterGameReq ||
-		msgID == protocol.MsgLoadPlayerDataReq
+	return m.handlers.Has(msgID)
 }
 
 func (m *BaseModule) Init(p *player_module.Player) error {
@@ -33,35 +36,19 @@ func (m *BaseModule) Handle(
 	msgID int,
 	env *internalpb.Envelope,
 ) (*internalpb.Envelope, bool, error) {
+	return m.handlers.Dispatch(&m.p.Context, msgID, env)
+}
 
-	switch msgID {
-
-	case protocol.MsgPlayerEnterGameReq:
-		rsp := &internalpb.PlayerInitRsp{
-			Data: m.p.ToPlayerData(),
-		}
-		data, _ := proto.Marshal(rsp)
-		return &internalpb.Envelope{
-			MsgId:     protocol.MsgPlayerEnterGameRsp,
-			SessionId: env.SessionId,
-			PlayerId:  env.PlayerId,
-			Payload:   data,
-		}, true, nil
-
-	case protocol.MsgLoadPlayerDataReq:
-		rsp := &internalpb.LoadPlayerDataRsp{
-			Data: m.p.ToPlayerData(),
-		}
-		data, _ := proto.Marshal(rsp)
-		return &internalpb.Envelope{
-			MsgId:     protocol.MsgLoadPlayerDataRsp,
-			SessionId: env.SessionId,
-			PlayerId:  env.PlayerId,
-			Payload:   data,
-		}, true, nil
-	}
+func (m *BaseModule) onEnterGame(_ *player_module.PlayerContext, _ *internalpb.PlayerEnterGameReq) (*internalpb.PlayerInitRsp, error) {
+	return &internalpb.PlayerInitRsp{
+		Data: m.p.ToPlayerData(),
+	}, nil
+}
 
-	return nil, false, nil
+func (m *BaseModule) onLoadPlayerData(_ *player_module.PlayerContext, _ *internalpb.LoadPlayerDataReq) (*internalpb.LoadPlayerDataRsp, error) {
+	return &internalpb.LoadPlayerDataRsp{
+		Data: m.p.ToPlayerData(),
+	}, nil
 }
 
 func (m *BaseModule) OnResume() {
@@ -0,0 +1,19 @@
+// Code generated by protoc-gen-msgid. DO NOT EDIT.
+// source: chat.proto, db.proto, error.proto, game.proto, gate.proto, login.proto
+
+package player_module
+
+import (
+	protocol "game-server/internal/protocol"
+	internalpb "game-server/internal/protocol/internalpb"
+)
+
+// HandlePlayerEnterGameReq MsgPlayerEnterGameReq → MsgPlayerEnterGameRsp
+func HandlePlayerEnterGameReq(t *HandlerTable, fn func(*PlayerContext, *internalpb.PlayerEnterGameReq) (*internalpb.PlayerInitRsp, error)) {
+	Handle(t, protocol.MsgPlayerEnterGameReq, fn)
+}
+
+// HandleLoadPlayerDataReq MsgLoadPlayerDataReq → MsgLoadPlayerDataRsp
+func HandleLoadPlayerDataReq(t *HandlerTable, fn func(*PlayerContext, *internalpb.LoadPlayerDataReq) (*internalpb.LoadPlayerDataRsp, error)) {
+	Handle(t, protocol.MsgLoadPlayerDataReq, fn)
+}
@@ -2,6 +2,7 @@
 package player_module
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -18,6 +19,7 @@ var (
 	ErrUnknownMessage     = errors.New("unknown player message")
 	ErrPlayerDestroyed    = errors.New("player destroyed")
 	ErrPlayerReplyTimeout = errors.New("player reply timeout")
+	ErrPlayerMigrating    = errors.New("player migrating")
 )
 
 type Message struct {
@@ -125,6 +127,62 @@ func (p *Player) Destroy() {
 	// loop 会自然退出
 }
 
+// ================= migrate =================
+
+// Freeze 迁移第一步：Active / Offline -> Migrating，不再接收新消息，
+// 并等 inbox 里已有的消息处理完（投递一个屏障消息）
+func (p *Player) Freeze(ctx context.Context) (PlayerState, error) {
+	prev := p.State()
+	if prev != PlayerStateActive && prev != PlayerStateOffline {
+		return prev, ErrPlayerClosed
+	}
+	if !atomic.CompareAndSwapInt32(&p.state, int32(prev), int32(PlayerStateMigrating)) {
+		return p.State(), ErrPlayerClosed
+	}
+
+	barrier := make(chan dispatchResult, 1)
+	select {
+	case p.inbox <- Message{Reply: barrier}:
+	case <-ctx.Done():
+		p.Thaw(prev)
+		return prev, ctx.Err()
+	}
+	select {
+	case <-barrier:
+		return prev, nil
+	case <-ctx.Done():
+		// 屏障已入队，loop 迟早会处理；此时恢复状态即可
+		p.Thaw(prev)
+		return prev, ctx.Err()
+	}
+}
+
+// Thaw 迁移失败时回到冻结前的状态
+func (p *Player) Thaw(prev PlayerState) {
+	atomic.CompareAndSwapInt32(&p.state, int32(PlayerStateMigrating), int32(prev))
+}
+
+// Flush 冻结后调用：Profile 与实现了 Flusher 的模块数据落盘
+func (p *Player) Flush(ctx context.Context, store player_db.Store) error {
+	for _, m := range p.modules {
+		if f, ok := m.(Flusher); ok {
+			if err := f.Flush(ctx); err != nil {
+				return fmt.Errorf("flush module %s: %w", m.Name(), err)
+			}
+		}
+	}
+	return store.SaveProfile(ctx, &p.Profile)
+}
+
+// Release 所有权已交出（或从未生效）：直接置为 Destroyed，并唤醒 loop 退出
+func (p *Player) Release() {
+	atomic.StoreInt32(&p.state, int32(PlayerStateDestroyed))
+	select {
+	case p.inbox <- Message{}:
+	default:
+	}
+}
+
 // ================= message =================
 
 func (p *Player) Post(msg Message) error {
@@ -132,6 +190,9 @@ func (p *Player) Post(msg Message) error {
 	if st == PlayerStateDestroyed {
 		return ErrPlayerDestroyed
 	}
+	if st == PlayerStateMigrating {
+		return ErrPlayerMigrating
+	}
 	if st != PlayerStateActive {
 		return ErrPlayerClosed
 	}
@@ -238,6 +299,11 @@ func (p *Player) handle(msg Message) {
 	var lastErr error
 	replied := false
 
+	// ⭐ 在 actor 内更新，模块读取无需加锁
+	if msg.Env != nil && msg.Env.Client != nil {
+		p.Context.Client = msg.Env.Client
+	}
+
 	defer func() {
 		if r := recover(); r != nil {
 			lastErr = fmt.Errorf("player panic: %v", r)
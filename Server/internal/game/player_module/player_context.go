@@ -1,7 +1,11 @@
 package player_module
 
+import "game-server/internal/protocol/internalpb"
+
 type PlayerContext struct {
 	PlayerID  int64
 	SessionID int64
 	GateID    string
+
+	Client *internalpb.ClientInfo // 最近一次进入游戏 / 恢复时 Gate 转发的握手信息，可能为空
 }
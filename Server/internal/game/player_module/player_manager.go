@@ -3,8 +3,24 @@ package player_module
 
 import (
 	"context"
+	"errors"
 	"game-server/internal/player_db"
+	"game-server/internal/protocol/internalpb"
 	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrMigrationInProgress = errors.New("player migration in progress")
+	ErrMigrateBufferFull   = errors.New("player migrate buffer full")
+)
+
+const (
+	// 迁出后短时间内仍可能收到发往本服的消息（其他进程的选服缓存），直接丢弃
+	migrateTombstoneTTL = time.Minute
+	maxMigratePending   = 1024
 )
 
 type PlayerState int32
@@ -14,13 +30,41 @@ const (
 	PlayerStateActive                // 正常在线，可收消息
 	PlayerStateOffline               // 离线，不再接收新消息
 	PlayerStateDestroyed             // 已销毁，不可再用
+	PlayerStateMigrating             // 迁移中，消息由 PlayerManager 暂存
 )
 
+func (st PlayerState) String() string {
+	switch st {
+	case PlayerStateInit:
+		return "init"
+	case PlayerStateActive:
+		return "active"
+	case PlayerStateOffline:
+		return "offline"
+	case PlayerStateDestroyed:
+		return "destroyed"
+	case PlayerStateMigrating:
+		return "migrating"
+	default:
+		return "unknown"
+	}
+}
+
 type PlayerManager struct {
 	mu       sync.RWMutex
 	players  map[int64]*Player
 	sessions map[int64]int64
 	store    player_db.Store
+
+	migrating map[int64]*migration
+}
+
+// migration 迁出中的玩家：done 之前暂存消息，done 之后作为墓碑丢弃消息
+type migration struct {
+	pending []*internalpb.Envelope
+	done    bool
+	aborted bool // 迁出完成前收到 MigrateIn：Gate 已放弃迁移，完成后不留墓碑
+	expire  time.Time
 }
 
 func NewPlayerManager(store player_db.Store) *PlayerManager {
@@ -28,6 +72,8 @@ func NewPlayerManager(store player_db.Store) *PlayerManager {
 		players:  make(map[int64]*Player),
 		sessions: make(map[int64]int64),
 		store:    store,
+
+		migrating: make(map[int64]*migration),
 	}
 }
 
@@ -35,6 +81,9 @@ func (m *PlayerManager) GetOrCreate(ctx context.Context, sessionID, playerID int
 	m.mu.RLock()
 	p := m.players[playerID]
 	m.mu.RUnlock()
+	if p != nil && p.State() == PlayerStateMigrating {
+		return nil, ErrPlayerMigrating
+	}
 	if p != nil {
 		p.SessionID = sessionID
 		p.OnResume(sessionID)
@@ -59,6 +108,11 @@ func (m *PlayerManager) GetOrCreate(ctx context.Context, sessionID, playerID int
 	p = NewPlayer(playerID, sessionID, *profile, CreateModules())
 
 	m.mu.Lock()
+	if m.migratingLocked(playerID) {
+		m.mu.Unlock()
+		p.Release()
+		return nil, ErrPlayerMigrating
+	}
 	m.players[playerID] = p
 	m.sessions[sessionID] = playerID
 	m.mu.Unlock()
@@ -101,6 +155,18 @@ func (m *PlayerManager) GetBySessionID(sessionID int64) *Player {
 	return p
 }
 
+// CountByState 常驻内存的玩家按状态计数
+func (m *PlayerManager) CountByState() map[PlayerState]int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := make(map[PlayerState]int, 4)
+	for _, p := range m.players {
+		counts[p.State()]++
+	}
+	return counts
+}
+
 func (m *PlayerManager) SaveAll(ctx context.Context) {
 	m.mu.RLock()
 	players := make([]*Player, 0, len(m.players))
@@ -112,3 +178,115 @@ func (m *PlayerManager) SaveAll(ctx context.Context) {
 		_ = m.store.SaveProfile(ctx, &p.Profile)
 	}
 }
+
+// ================= migrate =================
+
+// BeginMigrate 标记迁出；之后到达的该玩家消息由 Hold 暂存，需在读取下一条消息前同步调用
+func (m *PlayerManager) BeginMigrate(playerID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.migratingLocked(playerID) {
+		return ErrMigrationInProgress
+	}
+	m.migrating[playerID] = &migration{}
+	return nil
+}
+
+// Hold 玩家迁移中时接管消息：迁出前暂存，迁出后丢弃；返回 false 表示正常处理
+func (m *PlayerManager) Hold(playerID int64, env *internalpb.Envelope) (bool, error) {
+	if playerID == 0 {
+		return false, nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.migratingLocked(playerID) {
+		return false, nil
+	}
+	mg := m.migrating[playerID]
+	if mg.done {
+		return true, ErrPlayerDestroyed
+	}
+	if len(mg.pending) >= maxMigratePending {
+		return true, ErrMigrateBufferFull
+	}
+	mg.pending = append(mg.pending, proto.Clone(env).(*internalpb.Envelope))
+	return true, nil
+}
+
+// Migrate 冻结、落盘并交出所有权，返回冻结期间暂存的消息；
+// 失败时玩家恢复原状态，暂存的消息也一并返回，由调用方重放回本服
+func (m *PlayerManager) Migrate(ctx context.Context, playerID int64) (pending []*internalpb.Envelope, resident bool, err error) {
+	m.mu.RLock()
+	p := m.players[playerID]
+	m.mu.RUnlock()
+
+	if p != nil {
+		resident = true
+		prev, ferr := p.Freeze(ctx)
+		if ferr == nil {
+			if ferr = p.Flush(ctx, m.store); ferr != nil {
+				p.Thaw(prev)
+			}
+		}
+		if ferr != nil {
+			m.mu.Lock()
+			if mg := m.migrating[playerID]; mg != nil {
+				pending = mg.pending
+			}
+			delete(m.migrating, playerID)
+			m.mu.Unlock()
+			return pending, resident, ferr
+		}
+	}
+
+	m.mu.Lock()
+	if p != nil && m.players[playerID] == p {
+		delete(m.players, playerID)
+		if m.sessions[p.SessionID] == playerID {
+			delete(m.sessions, p.SessionID)
+		}
+	}
+	if mg := m.migrating[playerID]; mg != nil {
+		pending = mg.pending
+		mg.pending = nil
+		if mg.aborted {
+			// 玩家已落盘释放，之后的消息按常规从存储重新加载
+			delete(m.migrating, playerID)
+		} else {
+			mg.done = true
+			mg.expire = time.Now().Add(migrateTombstoneTTL)
+		}
+	}
+	m.mu.Unlock()
+
+	if p != nil {
+		p.Release()
+	}
+	return pending, resident, nil
+}
+
+// MigrateIn 本服接管玩家：清掉之前迁出留下的墓碑（玩家迁回 / Gate 放弃迁移）；
+// 迁出还没完成时只做标记，由 Migrate 完成后清理
+func (m *PlayerManager) MigrateIn(playerID int64) {
+	m.mu.Lock()
+	if mg := m.migrating[playerID]; mg != nil {
+		if mg.done {
+			delete(m.migrating, playerID)
+		} else {
+			mg.aborted = true
+		}
+	}
+	m.mu.Unlock()
+}
+
+func (m *PlayerManager) migratingLocked(playerID int64) bool {
+	mg := m.migrating[playerID]
+	if mg == nil {
+		return false
+	}
+	if mg.done && time.Now().After(mg.expire) {
+		delete(m.migrating, playerID)
+		return false
+	}
+	return true
+}
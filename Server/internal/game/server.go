@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"game-server/internal/protocol"
+	"game-server/internal/protocol/internalpb"
 	"game-server/internal/transport"
 	"go.uber.org/zap"
 
@@ -32,17 +33,19 @@ func NewServer(addr string,
 	if logger == nil {
 		logger = zap.NewNop()
 	}
-	return &Server{
+	s := &Server{
 		addr:            addr,
 		players:         player_module.NewPlayerManager(store),
 		logger:          logger,
 		connOptions:     options,
 		persistInterval: persistInterval,
 	}
+	s.registerMetrics()
+	return s
 }
 
 func (s *Server) ListenAndServe(ctx context.Context) error {
-	ln, err := net.Listen("tcp", s.addr)
+	ln, err := transport.Listen(s.addr, s.connOptions)
 	if err != nil {
 		return err
 	}
@@ -75,12 +78,21 @@ func (s *Server) handleConn(ctx context.Context, conn net.Conn) {
 	bc := transport.NewBufferedConnWithOptions(conn, s.connOptions)
 	defer bc.Close()
 
+	gameConnections.Inc()
+	defer gameConnections.Dec()
+
 	for {
 		env, err := bc.ReadEnvelope()
 		if err != nil {
 			return
 		}
 
+		// ---------- health check ----------
+		if env.MsgId == protocol.MsgServicePing {
+			_ = bc.WriteEnvelope(&internalpb.Envelope{MsgId: protocol.MsgServicePong})
+			continue
+		}
+
 		// ---------- resolve playerID ----------
 		playerID := env.PlayerId
 		if playerID == 0 && env.SessionId != 0 {
@@ -89,6 +101,19 @@ func (s *Server) handleConn(ctx context.Context, conn net.Conn) {
 			}
 		}
 
+		// ---------- migrate ----------
+		switch env.MsgId {
+		case protocol.MsgPlayerMigrateReq:
+			s.handleMigrateReq(ctx, bc, env)
+			continue
+		case protocol.MsgPlayerMigrateIn:
+			s.handleMigrateIn(ctx, env)
+			continue
+		}
+		if s.holdForMigration(playerID, env) {
+			continue
+		}
+
 		// ---------- offline notify ----------
 		if env.MsgId == protocol.MsgPlayerOfflineNotify {
 			if playerID != 0 {
@@ -108,12 +133,15 @@ func (s *Server) handleConn(ctx context.Context, conn net.Conn) {
 
 			player, err := s.players.GetOrCreate(ctx, env.SessionId, playerID)
 			if err != nil {
+				if s.retryHold(playerID, env, err) {
+					continue
+				}
 				s.logger.Warn("resume get player failed", zap.Error(err))
 				continue
 			}
 
 			_, err = player.Dispatch(int(env.MsgId), env)
-			if err != nil {
+			if err != nil && !s.retryHold(playerID, env, err) {
 				s.logger.Warn("player resume dispatch failed",
 					zap.Error(err),
 					zap.Int64("session", env.SessionId),
@@ -126,6 +154,9 @@ func (s *Server) handleConn(ctx context.Context, conn net.Conn) {
 		// ---------- normal player message ----------
 		player, err := s.players.GetOrCreate(ctx, env.SessionId, playerID)
 		if err != nil {
+			if s.retryHold(playerID, env, err) {
+				continue
+			}
 			s.logger.Warn("get player failed", zap.Error(err))
 			continue
 		}
@@ -139,7 +170,12 @@ func (s *Server) handleConn(ctx context.Context, conn net.Conn) {
 			zap.String("trace_id", fmt.Sprintf("session-%d", env.SessionId)),
 		)
 
+		start := time.Now()
 		rsp, err := player.Dispatch(int(env.MsgId), env)
+		if s.retryHold(playerID, env, err) {
+			continue
+		}
+		observeRequest(int(env.MsgId), err, time.Since(start))
 		if err != nil {
 			if errors.Is(err, player_module.ErrUnknownMessage) {
 				s.logger.Warn("unknown player message",
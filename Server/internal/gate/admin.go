@@ -0,0 +1,517 @@
+// internal/gate/admin.go
+package gate
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"game-server/internal/router"
+	"go.uber.org/zap"
+)
+
+// 运维管理接口（与客户端 WebSocket 端口分开监听）
+//
+//	GET    /admin/sessions?player_id=&state=&offset=&limit=
+//	GET    /admin/sessions/{id}
+//	POST   /admin/sessions/{id}/kick        {"reason": "..."}
+//	POST   /admin/push                      {"session_id"|"player_id", "msg_id", "payload"(base64)}
+//	POST   /admin/broadcast                 {"msg_id", "payload"(base64)}
+//	GET    /admin/resume-keys
+//	POST   /admin/resume-keys               {"key_id", "secret", "not_before", "not_after"}
+//	DELETE /admin/resume-keys/{id}
+//	GET    /admin/ip-filter
+//	POST   /admin/ip-filter/deny            {"entry": "1.2.3.4" | "10.0.0.0/8"}
+//	DELETE /admin/ip-filter/deny?entry=
+//	GET    /admin/services
+//	GET    /admin/players/{id}/shard
+//	PUT    /admin/players/{id}/shard        {"shard": "game-2"}
+//	POST   /admin/players/{id}/migrate      {"shard": "game-2"}
+const defaultAdminListLimit = 100
+
+type SessionInfo struct {
+	SessionID int64     `json:"session_id"`
+	PlayerID  int64     `json:"player_id"`
+	State     string    `json:"state"`
+	LastSeen  time.Time `json:"last_seen"`
+	Online    bool      `json:"online"`
+
+	ConnType    string     `json:"conn_type,omitempty"`
+	TraceID     string     `json:"trace_id,omitempty"`
+	ConnectedAt *time.Time `json:"connected_at,omitempty"`
+	LastAlive   *time.Time `json:"last_alive,omitempty"`
+
+	Groups []string `json:"groups,omitempty"`
+}
+
+type ResumeKeyInfo struct {
+	KeyID     string     `json:"key_id"`
+	NotBefore *time.Time `json:"not_before,omitempty"`
+	NotAfter  *time.Time `json:"not_after,omitempty"`
+	Active    bool       `json:"active"`
+}
+
+type adminPushReq struct {
+	SessionID int64  `json:"session_id"`
+	PlayerID  int64  `json:"player_id"`
+	MsgID     int    `json:"msg_id"`
+	Payload   []byte `json:"payload"`
+}
+
+type adminKickReq struct {
+	Reason string `json:"reason"`
+}
+
+type adminDenyReq struct {
+	Entry string `json:"entry"`
+}
+
+type adminAssignShardReq struct {
+	Shard string `json:"shard"`
+}
+
+type adminAddKeyReq struct {
+	KeyID     string `json:"key_id"`
+	Secret    string `json:"secret"`
+	NotBefore string `json:"not_before"`
+	NotAfter  string `json:"not_after"`
+}
+
+func sessionInfo(s *Session) SessionInfo {
+	info := SessionInfo{
+		SessionID: s.ID,
+		PlayerID:  s.PlayerID,
+		State:     s.State().String(),
+		LastSeen:  s.LastSeen(),
+	}
+	// ⭐ admin 在 HTTP 协程里读，连接读协程同时可能在换连接 / 改状态
+	if c := s.currentConn(); c != nil {
+		connectedAt := c.connectedAt
+		lastAlive := c.lastAlive()
+		info.Online = true
+		info.ConnType = c.connType.String()
+		info.TraceID = c.traceID
+		info.ConnectedAt = &connectedAt
+		info.LastAlive = &lastAlive
+	}
+	return info
+}
+
+// AdminHandler 返回管理接口；token 为空时拒绝所有请求
+func (g *Gate) AdminHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/sessions", g.adminListSessions)
+	mux.HandleFunc("GET /admin/sessions/{id}", g.adminGetSession)
+	mux.HandleFunc("POST /admin/sessions/{id}/kick", g.adminKickSession)
+	mux.HandleFunc("POST /admin/push", g.adminPush)
+	mux.HandleFunc("POST /admin/broadcast", g.adminBroadcast)
+	mux.HandleFunc("GET /admin/resume-keys", g.adminListKeys)
+	mux.HandleFunc("POST /admin/resume-keys", g.adminAddKey)
+	mux.HandleFunc("DELETE /admin/resume-keys/{id}", g.adminRetireKey)
+	mux.HandleFunc("GET /admin/ip-filter", g.adminListIPFilter)
+	mux.HandleFunc("POST /admin/ip-filter/deny", g.adminDenyIP)
+	mux.HandleFunc("DELETE /admin/ip-filter/deny", g.adminUndenyIP)
+	mux.HandleFunc("GET /admin/services", g.adminListServices)
+	mux.HandleFunc("GET /admin/players/{id}/shard", g.adminGetPlayerShard)
+	mux.HandleFunc("PUT /admin/players/{id}/shard", g.adminAssignPlayerShard)
+	mux.HandleFunc("POST /admin/players/{id}/migrate", g.adminMigratePlayer)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !checkBearer(r, token) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeAdminError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func checkBearer(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+	auth := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(auth, prefix) {
+		return false
+	}
+	given := strings.TrimSpace(auth[len(prefix):])
+	return subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeAdminError(w http.ResponseWriter, status int, reason string) {
+	writeAdminJSON(w, status, map[string]string{"error": reason})
+}
+
+func queryInt64(r *http.Request, key string) (int64, error) {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(v, 10, 64)
+}
+
+// ================= sessions =================
+
+func (g *Gate) adminListSessions(w http.ResponseWriter, r *http.Request) {
+	playerID, err := queryInt64(r, "player_id")
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid player_id")
+		return
+	}
+	offset, err := queryInt64(r, "offset")
+	if err != nil || offset < 0 {
+		writeAdminError(w, http.StatusBadRequest, "invalid offset")
+		return
+	}
+	limit, err := queryInt64(r, "limit")
+	if err != nil || limit < 0 {
+		writeAdminError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+	if limit == 0 {
+		limit = defaultAdminListLimit
+	}
+	state := r.URL.Query().Get("state")
+
+	var sessions []*Session
+	if playerID != 0 {
+		if s := g.sessions.GetByPlayer(playerID); s != nil {
+			sessions = append(sessions, s)
+		}
+	} else {
+		sessions = g.sessions.snapshot()
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID < sessions[j].ID })
+
+	items := make([]SessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		if state != "" && s.State().String() != state {
+			continue
+		}
+		items = append(items, sessionInfo(s))
+	}
+	total := len(items)
+	if offset > int64(total) {
+		offset = int64(total)
+	}
+	end := offset + limit
+	if end > int64(total) {
+		end = int64(total)
+	}
+
+	writeAdminJSON(w, http.StatusOK, map[string]any{
+		"total":    total,
+		"sessions": items[offset:end],
+	})
+}
+
+func (g *Gate) adminSession(w http.ResponseWriter, r *http.Request) *Session {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid session id")
+		return nil
+	}
+	s := g.sessions.Get(id)
+	if s == nil {
+		writeAdminError(w, http.StatusNotFound, ErrSessionNotFound.Error())
+		return nil
+	}
+	return s
+}
+
+func (g *Gate) adminGetSession(w http.ResponseWriter, r *http.Request) {
+	s := g.adminSession(w, r)
+	if s == nil {
+		return
+	}
+	info := sessionInfo(s)
+	info.Groups = g.groups.groupsOf(s.ID)
+	sort.Strings(info.Groups)
+	writeAdminJSON(w, http.StatusOK, info)
+}
+
+func (g *Gate) adminKickSession(w http.ResponseWriter, r *http.Request) {
+	s := g.adminSession(w, r)
+	if s == nil {
+		return
+	}
+	var req adminKickReq
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeAdminError(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+	}
+	if req.Reason == "" {
+		req.Reason = "admin kick"
+	}
+	if err := g.Kick(s.ID, req.Reason); err != nil {
+		writeAdminError(w, http.StatusConflict, err.Error())
+		return
+	}
+	fields := append(sessionFields(s), zap.String("reason", req.Reason), zap.Int("msg_id", 0))
+	g.logger.Info("admin kick session", fields...)
+	writeAdminJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
+
+// ================= push / broadcast =================
+
+func (g *Gate) adminPush(w http.ResponseWriter, r *http.Request) {
+	var req adminPushReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.MsgID == 0 {
+		writeAdminError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	sessionID := req.SessionID
+	if sessionID == 0 && req.PlayerID != 0 {
+		if s := g.sessions.GetByPlayer(req.PlayerID); s != nil {
+			sessionID = s.ID
+		}
+	}
+	if sessionID == 0 {
+		writeAdminError(w, http.StatusNotFound, ErrSessionNotFound.Error())
+		return
+	}
+	if err := g.Push(sessionID, req.MsgID, req.Payload); err != nil {
+		status := http.StatusConflict
+		if errors.Is(err, ErrSessionNotFound) {
+			status = http.StatusNotFound
+		}
+		writeAdminError(w, status, err.Error())
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, map[string]any{"ok": true, "session_id": sessionID})
+}
+
+func (g *Gate) adminBroadcast(w http.ResponseWriter, r *http.Request) {
+	var req adminPushReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.MsgID == 0 {
+		writeAdminError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	sent := g.broadcast(req.MsgID, "", req.Payload)
+	g.logger.Info("admin broadcast",
+		zap.Int("msg_id", req.MsgID),
+		zap.Int("sent", sent),
+		zap.String("reason", "admin_broadcast"),
+	)
+	writeAdminJSON(w, http.StatusOK, map[string]any{"ok": true, "sent": sent})
+}
+
+// ================= resume keys =================
+
+func (g *Gate) adminListKeys(w http.ResponseWriter, _ *http.Request) {
+	now := time.Now()
+	keys := g.resumeKeys.List()
+	items := make([]ResumeKeyInfo, 0, len(keys))
+	for _, k := range keys {
+		info := ResumeKeyInfo{KeyID: k.ID, Active: k.activeAt(now)}
+		if !k.NotBefore.IsZero() {
+			t := k.NotBefore
+			info.NotBefore = &t
+		}
+		if !k.NotAfter.IsZero() {
+			t := k.NotAfter
+			info.NotAfter = &t
+		}
+		items = append(items, info)
+	}
+	writeAdminJSON(w, http.StatusOK, map[string]any{"keys": items})
+}
+
+func (g *Gate) adminAddKey(w http.ResponseWriter, r *http.Request) {
+	var req adminAddKeyReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	key := ResumeKey{ID: req.KeyID, Secret: []byte(req.Secret)}
+	if req.NotBefore != "" {
+		t, err := time.Parse(time.RFC3339, req.NotBefore)
+		if err != nil {
+			writeAdminError(w, http.StatusBadRequest, "invalid not_before")
+			return
+		}
+		key.NotBefore = t
+	}
+	if req.NotAfter != "" {
+		t, err := time.Parse(time.RFC3339, req.NotAfter)
+		if err != nil {
+			writeAdminError(w, http.StatusBadRequest, "invalid not_after")
+			return
+		}
+		key.NotAfter = t
+	}
+	if err := g.resumeKeys.Add(key); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrResumeKeyExists) {
+			status = http.StatusConflict
+		}
+		writeAdminError(w, status, err.Error())
+		return
+	}
+	g.logger.Info("admin add resume key", zap.String("key_id", key.ID))
+	writeAdminJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
+
+func (g *Gate) adminRetireKey(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := g.resumeKeys.Retire(id); err != nil {
+		writeAdminError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	g.logger.Info("admin retire resume key", zap.String("key_id", id))
+	writeAdminJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
+
+// ================= ip filter =================
+// 运行时增删的 deny 条目在 SIGHUP 重载配置时会被配置文件覆盖
+
+func (g *Gate) adminListIPFilter(w http.ResponseWriter, _ *http.Request) {
+	allow, deny := g.ipFilter.Lists()
+	writeAdminJSON(w, http.StatusOK, map[string]any{"allow": allow, "deny": deny})
+}
+
+func (g *Gate) adminDenyIP(w http.ResponseWriter, r *http.Request) {
+	var req adminDenyReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if err := g.ipFilter.Deny(req.Entry); err != nil {
+		writeAdminError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	closed := g.CloseDeniedConns()
+	g.logger.Info("admin deny ip",
+		zap.String("entry", req.Entry),
+		zap.Int("closed", closed),
+	)
+	writeAdminJSON(w, http.StatusOK, map[string]any{"ok": true, "closed": closed})
+}
+
+func (g *Gate) adminUndenyIP(w http.ResponseWriter, r *http.Request) {
+	entry := r.URL.Query().Get("entry")
+	removed, err := g.ipFilter.Undeny(entry)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !removed {
+		writeAdminError(w, http.StatusNotFound, "entry not found")
+		return
+	}
+	g.logger.Info("admin undeny ip", zap.String("entry", entry))
+	writeAdminJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
+
+// ================= services =================
+
+func (g *Gate) adminListServices(w http.ResponseWriter, _ *http.Request) {
+	writeAdminJSON(w, http.StatusOK, map[string]any{"services": g.ServiceEndpoints()})
+}
+
+// ================= game shard =================
+
+func (g *Gate) adminPlayerID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	if g.gameSelector == nil {
+		writeAdminError(w, http.StatusNotFound, "game shards not configured")
+		return 0, false
+	}
+	playerID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || playerID <= 0 {
+		writeAdminError(w, http.StatusBadRequest, "invalid player id")
+		return 0, false
+	}
+	return playerID, true
+}
+
+func (g *Gate) adminGetPlayerShard(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := g.adminPlayerID(w, r)
+	if !ok {
+		return
+	}
+	shard, source, err := g.gameSelector.Lookup(r.Context(), playerID)
+	if err != nil {
+		writeAdminError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, map[string]any{
+		"player_id": playerID,
+		"shard":     shard.ID,
+		"addr":      shard.Addr,
+		"source":    source,
+	})
+}
+
+// adminAssignPlayerShard 只改分配，不迁移：玩家在线时拒绝
+func (g *Gate) adminAssignPlayerShard(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := g.adminPlayerID(w, r)
+	if !ok {
+		return
+	}
+	var req adminAssignShardReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Shard == "" {
+		writeAdminError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if s := g.sessions.GetByPlayer(playerID); s != nil && s.State() == SessionAuthenticated {
+		writeAdminError(w, http.StatusConflict, "player online")
+		return
+	}
+	if err := g.gameSelector.Assign(r.Context(), playerID, req.Shard); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, router.ErrUnknownGameShard) || errors.Is(err, router.ErrNoAssignStore) {
+			status = http.StatusBadRequest
+		}
+		writeAdminError(w, status, err.Error())
+		return
+	}
+	g.broadcastShardChanged(playerID, req.Shard)
+	g.logger.Info("admin assign player shard",
+		zap.Int64("player", playerID),
+		zap.String("shard", req.Shard),
+	)
+	writeAdminJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
+
+// adminMigratePlayer 在线迁移到指定 game 服，迁移期间发往 game 的消息由 Gate 缓存
+func (g *Gate) adminMigratePlayer(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := g.adminPlayerID(w, r)
+	if !ok {
+		return
+	}
+	var req adminAssignShardReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Shard == "" {
+		writeAdminError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if err := g.MigratePlayer(r.Context(), playerID, req.Shard); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, router.ErrUnknownGameShard), errors.Is(err, router.ErrNoAssignStore), errors.Is(err, ErrAlreadyOnShard):
+			status = http.StatusBadRequest
+		case errors.Is(err, ErrMigrationInProgress):
+			status = http.StatusConflict
+		}
+		writeAdminError(w, status, err.Error())
+		return
+	}
+	g.logger.Info("admin migrate player",
+		zap.Int64("player", playerID),
+		zap.String("shard", req.Shard),
+	)
+	writeAdminJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
@@ -0,0 +1,95 @@
+package gate
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"game-server/internal/protocol"
+	"game-server/internal/protocol/codec"
+	"game-server/internal/protocol/internalpb"
+)
+
+const testAdminToken = "admin-test-token"
+
+func adminGet(t *testing.T, h http.Handler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// 连接读协程不停地 resume / 断开，同时 admin 列会话；用 go test -race 跑
+func TestAdminListSessionsDuringChurn(t *testing.T) {
+	g := newTestGate(t)
+	h := g.AdminHandler(testAdminToken)
+
+	const sessionCount = 4
+	sessions := make([]*Session, 0, sessionCount)
+	for i := 0; i < sessionCount; i++ {
+		c, _ := startConn(g, newPipeConn())
+		s, err := g.createSessionForConn(c)
+		if err != nil {
+			t.Fatalf("create session: %v", err)
+		}
+		sessions = append(sessions, s)
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, s := range sessions {
+		wg.Add(1)
+		go func(s *Session) {
+			defer wg.Done()
+			payload, _ := codec.Encode("", &internalpb.ResumeReq{SessionId: s.ID, Token: s.Token})
+			env := &internalpb.Envelope{MsgId: protocol.MsgResumeReq, Payload: payload}
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				c, _ := startConn(g, newPipeConn())
+				g.handleResume(c, env)
+				if i%2 == 0 {
+					// 当前连接断开：读协程走 onConnClose，会话转为离线
+					c.Close()
+				}
+			}
+		}(s)
+	}
+
+	for i := 0; i < 200; i++ {
+		path := "/admin/sessions"
+		if i%2 == 1 {
+			path += "?state=offline"
+		}
+		rec := adminGet(t, h, path)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("list sessions status %d: %s", rec.Code, rec.Body.String())
+		}
+		var body struct {
+			Total    int           `json:"total"`
+			Sessions []SessionInfo `json:"sessions"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if i%2 == 0 && body.Total != sessionCount {
+			t.Fatalf("total = %d, want %d", body.Total, sessionCount)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	for _, s := range sessions {
+		if rec := adminGet(t, h, "/admin/sessions/"+strconv.FormatInt(s.ID, 10)); rec.Code != http.StatusOK {
+			t.Fatalf("get session %d status %d", s.ID, rec.Code)
+		}
+	}
+}
@@ -5,4 +5,15 @@ type Gateway interface {
 	Reply(sessionID int64, msgID int, data []byte) error
 	Push(sessionID int64, msgID int, data []byte) error
 	Kick(sessionID int64, reason string) error
+
+	// 广播 / 组播：payload 只编码一次，所有接收者共享
+	Broadcast(msgID int, data []byte) error
+	Multicast(playerIDs []int64, msgID int, data []byte) error
+
+	// 命名频道分组
+	JoinGroup(group string, sessionID int64) error
+	LeaveGroup(group string, sessionID int64) error
+	PublishGroup(group string, msgID int, data []byte) error
 }
+
+var _ Gateway = (*Gate)(nil)
@@ -6,7 +6,9 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type ResumeReq struct {
@@ -14,35 +16,56 @@ type ResumeReq struct {
 	Token     string
 }
 
-func (g *Gate) signResumeToken(s *Session) string {
+// resume token 格式：keyID.expireUnix.nonceHex.signature
+//
+//	没有生效的密钥或取随机数失败时返回错误，不签发可伪造的 token
+func (g *Gate) signResumeToken(s *Session) (string, error) {
+	now := time.Now()
+	key, err := g.resumeKeys.signingKey(now)
+	if err != nil {
+		return "", err
+	}
 	nonce := make([]byte, 32)
 	if _, err := rand.Read(nonce); err != nil {
-		return fmt.Sprintf("session:%d", s.ID)
+		return "", err
 	}
 	nonceHex := hex.EncodeToString(nonce)
-	signature := g.signTokenPayload(s.ID, nonceHex)
-	return fmt.Sprintf("%s.%s", nonceHex, signature)
+	expireAt := now.Add(g.resumeTokenTTL).Unix()
+	signature := signTokenPayload(key, s.ID, expireAt, nonceHex)
+	return fmt.Sprintf("%s.%d.%s.%s", key.ID, expireAt, nonceHex, signature), nil
 }
 
 func (g *Gate) verifyToken(s *Session, token string) bool {
 	parts := strings.Split(token, ".")
-	if len(parts) != 2 {
+	if len(parts) != 4 {
+		return false
+	}
+	keyID, expireStr, nonceHex, signature := parts[0], parts[1], parts[2], parts[3]
+
+	expireAt, err := strconv.ParseInt(expireStr, 10, 64)
+	if err != nil {
+		return false
+	}
+	now := time.Now()
+	if now.Unix() >= expireAt {
 		return false
 	}
-	nonceHex := parts[0]
-	signature := parts[1]
-	expected := g.signTokenPayload(s.ID, nonceHex)
+
+	key, ok := g.resumeKeys.Get(keyID)
+	if !ok || !key.activeAt(now) {
+		return false
+	}
+
+	expected := signTokenPayload(key, s.ID, expireAt, nonceHex)
 	if !hmac.Equal([]byte(signature), []byte(expected)) {
 		return false
 	}
 	return token == s.Token
 }
 
-func (g *Gate) secret() string { return "gate-secret" }
-
-func (g *Gate) signTokenPayload(sessionID int64, nonceHex string) string {
-	payload := fmt.Sprintf("%d:%s", sessionID, nonceHex)
-	mac := hmac.New(sha256.New, []byte(g.secret()))
+func signTokenPayload(key ResumeKey, sessionID int64, expireAt int64, nonceHex string) string {
+	payload := fmt.Sprintf("%d:%s:%d:%s", sessionID, key.ID, expireAt, nonceHex)
+	mac := hmac.New(sha256.New, key.Secret)
 	_, _ = mac.Write([]byte(payload))
 	return hex.EncodeToString(mac.Sum(nil))
 }
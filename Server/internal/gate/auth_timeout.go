@@ -13,7 +13,7 @@ func (g *Gate) checkAuthingTimeout() {
 	now := time.Now()
 
 	for _, s := range g.sessions.snapshot() {
-		if s.State != SessionAuthing {
+		if s.State() != SessionAuthing {
 			continue
 		}
 
@@ -0,0 +1,219 @@
+package gate
+
+import (
+	"errors"
+
+	"game-server/internal/protocol/codec"
+	"game-server/internal/protocol/internalpb"
+	"game-server/internal/protocol/msgtype"
+	"game-server/internal/transport"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrInvalidGroup = errors.New("invalid group")
+
+// broadcastSet 一条广播按接收者协商的编码各预编码一份：源编码直接用，其他编码按 msgtype 表解出后转码。
+// 广播类消息不编号、不进离线补发缓冲
+type broadcastSet struct {
+	g        *Gate
+	msgID    int
+	codec    string // payload 的编码，protobuf 为空
+	data     []byte
+	decoded  proto.Message
+	failed   bool // 转码失败后其他编码的接收者也收源编码，Envelope.Codec 如实标注
+	prepared map[string]*transport.PreparedEnvelope
+}
+
+func (g *Gate) newBroadcastSet(msgID int, codecName string, data []byte) *broadcastSet {
+	if codecName == codec.NameProtobuf {
+		codecName = ""
+	}
+	return &broadcastSet{
+		g:        g,
+		msgID:    msgID,
+		codec:    codecName,
+		data:     data,
+		prepared: make(map[string]*transport.PreparedEnvelope, 1),
+	}
+}
+
+func (b *broadcastSet) envelope(codecName string, data []byte) *transport.PreparedEnvelope {
+	p := transport.NewPreparedEnvelope(&internalpb.Envelope{
+		MsgId:   int32(b.msgID),
+		Payload: data,
+		Codec:   codecName,
+	})
+	b.prepared[codecName] = p
+	return p
+}
+
+// forCodec 取目标编码的预编码消息，同一编码只转码一次
+func (b *broadcastSet) forCodec(target string) *transport.PreparedEnvelope {
+	if p, ok := b.prepared[target]; ok {
+		return p
+	}
+	if target == b.codec || b.failed {
+		return b.source()
+	}
+	data, err := b.transcode(target)
+	if err != nil {
+		b.failed = true
+		b.g.logger.Warn("transcode broadcast failed, send source codec",
+			zap.String("reason", err.Error()),
+			zap.Int("msg_id", b.msgID),
+			zap.String("codec", b.codec),
+			zap.String("target", target),
+		)
+		return b.source()
+	}
+	return b.envelope(target, data)
+}
+
+func (b *broadcastSet) source() *transport.PreparedEnvelope {
+	if p, ok := b.prepared[b.codec]; ok {
+		return p
+	}
+	return b.envelope(b.codec, b.data)
+}
+
+func (b *broadcastSet) transcode(target string) ([]byte, error) {
+	if b.decoded == nil {
+		m, err := msgtype.New(b.msgID)
+		if err != nil {
+			return nil, err
+		}
+		src, err := codec.Get(b.codec)
+		if err != nil {
+			return nil, err
+		}
+		if err := src.Unmarshal(b.data, m); err != nil {
+			return nil, err
+		}
+		b.decoded = m
+	}
+	return codec.Encode(target, b.decoded)
+}
+
+func (g *Gate) sendBroadcast(s *Session, b *broadcastSet) bool {
+	if s == nil || s.State() != SessionAuthenticated {
+		return false
+	}
+	conn := s.Conn
+	if conn == nil {
+		return false
+	}
+	return conn.SendPrepared(b.forCodec(conn.payloadCodec())) == nil
+}
+
+// Broadcast 发给本 Gate 上所有已登录会话；data 为 protobuf 编码
+func (g *Gate) Broadcast(msgID int, data []byte) error {
+	g.broadcast(msgID, "", data)
+	return nil
+}
+
+func (g *Gate) broadcast(msgID int, codecName string, data []byte) int {
+	b := g.newBroadcastSet(msgID, codecName, data)
+	sent := 0
+	for _, s := range g.sessions.snapshot() {
+		if g.sendBroadcast(s, b) {
+			sent++
+		}
+	}
+	return sent
+}
+
+// Multicast 发给指定玩家（不在本 Gate 的玩家直接跳过）；data 为 protobuf 编码
+func (g *Gate) Multicast(playerIDs []int64, msgID int, data []byte) error {
+	g.multicast(playerIDs, msgID, "", data)
+	return nil
+}
+
+func (g *Gate) multicast(playerIDs []int64, msgID int, codecName string, data []byte) int {
+	b := g.newBroadcastSet(msgID, codecName, data)
+	sent := 0
+	for _, playerID := range playerIDs {
+		if g.sendBroadcast(g.sessions.GetByPlayer(playerID), b) {
+			sent++
+		}
+	}
+	return sent
+}
+
+func (g *Gate) JoinGroup(group string, sessionID int64) error {
+	if group == "" {
+		return ErrInvalidGroup
+	}
+	if g.sessions.Get(sessionID) == nil {
+		return ErrSessionNotFound
+	}
+	g.groups.join(group, sessionID)
+	return nil
+}
+
+func (g *Gate) LeaveGroup(group string, sessionID int64) error {
+	if group == "" {
+		return ErrInvalidGroup
+	}
+	g.groups.leave(group, sessionID)
+	return nil
+}
+
+// PublishGroup 发给分组内所有在线会话；data 为 protobuf 编码
+func (g *Gate) PublishGroup(group string, msgID int, data []byte) error {
+	if group == "" {
+		return ErrInvalidGroup
+	}
+	g.publishGroup(group, msgID, "", data)
+	return nil
+}
+
+func (g *Gate) publishGroup(group string, msgID int, codecName string, data []byte) int {
+	b := g.newBroadcastSet(msgID, codecName, data)
+	sent := 0
+	for _, sessionID := range g.groups.members(group) {
+		if g.sendBroadcast(g.sessions.Get(sessionID), b) {
+			sent++
+		}
+	}
+	return sent
+}
+
+// onGateControl 处理后端经 MsgGateControl 发来的广播 / 分组指令
+func (g *Gate) onGateControl(payload []byte) {
+	var ctrl internalpb.GateControl
+	if err := proto.Unmarshal(payload, &ctrl); err != nil {
+		g.logger.Warn("invalid gate control",
+			zap.String("reason", err.Error()),
+		)
+		return
+	}
+
+	msgID := int(ctrl.MsgId)
+	switch ctrl.Op {
+	case internalpb.GateControl_OP_UNSPECIFIED:
+		g.logger.Warn("gate control without op",
+			zap.Int("msg_id", msgID),
+			zap.String("reason", "unspecified_control_op"),
+		)
+	case internalpb.GateControl_BROADCAST:
+		g.broadcast(msgID, ctrl.Codec, ctrl.Payload)
+	case internalpb.GateControl_MULTICAST:
+		g.multicast(ctrl.PlayerIds, msgID, ctrl.Codec, ctrl.Payload)
+	case internalpb.GateControl_GROUP_JOIN:
+		for _, sessionID := range ctrl.SessionIds {
+			_ = g.JoinGroup(ctrl.Group, sessionID)
+		}
+	case internalpb.GateControl_GROUP_LEAVE:
+		for _, sessionID := range ctrl.SessionIds {
+			_ = g.LeaveGroup(ctrl.Group, sessionID)
+		}
+	case internalpb.GateControl_GROUP_CAST:
+		g.publishGroup(ctrl.Group, msgID, ctrl.Codec, ctrl.Payload)
+	default:
+		g.logger.Warn("unknown gate control op",
+			zap.Int("op", int(ctrl.Op)),
+			zap.String("reason", "unknown_control_op"),
+		)
+	}
+}
@@ -4,15 +4,18 @@ package gate
 import (
 	"errors"
 	"net"
+	"net/netip"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"game-server/internal/protocol"
+	"game-server/internal/protocol/codec"
 	"game-server/internal/protocol/internalpb"
 	"game-server/internal/transport"
 	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
 )
 
 type ConnType uint8
@@ -20,33 +23,63 @@ type ConnType uint8
 const (
 	ConnTCP ConnType = iota
 	ConnWS
+	ConnKCP
 )
 
+func (t ConnType) String() string {
+	switch t {
+	case ConnTCP:
+		return "tcp"
+	case ConnWS:
+		return "ws"
+	case ConnKCP:
+		return "kcp"
+	default:
+		return "unknown"
+	}
+}
+
 type Conn struct {
 	gate *Gate
 
-	connType ConnType // ⭐ 关键
+	connType ConnType   // ⭐ 关键
+	conv     uint32     // KCP 会话号，其他传输为 0
+	remoteIP netip.Addr // 取不到 IP 时为零值，不参与 IP 过滤
 
 	conn transport.Conn
 
 	sessionID int64
 	traceID   string
 
-	sendCh chan *internalpb.Envelope
-	closed chan struct{}
-	once   sync.Once
+	// ⭐ 按投递等级分队列，写协程按 critical → normal → droppable 取
+	criticalCh chan outbound
+	sendCh     chan outbound // normal
+	latest     *latestQueue  // droppable
+	wake       chan struct{} // 任一队列入队时通知写协程
+	closed     chan struct{}
+	once       sync.Once
 
 	id int64
 
 	connectedAt time.Time
-	busyCount   uint32
 
 	lastSeen atomic.Int64 // UnixNano
+
+	client  atomic.Pointer[internalpb.ClientInfo] // 握手结果，未握手为空
+	closing atomic.Bool                           // SendAndClose 之后不再处理上行
+
+	onClose func() // 例如归还 IP 连接名额
 }
 
 func NewConn(nc net.Conn, g *Gate) *Conn {
-	c := NewConnWithTransport(transport.NewBufferedConnWithOptions(nc, g.connOptions), g)
-	c.connType = ConnTCP
+	return NewConnWithTransport(transport.NewBufferedConnWithOptions(nc, g.connOptions), g, ConnTCP)
+}
+
+// NewKCPConn nc 为 transport.KCPListener 接受的会话
+func NewKCPConn(nc net.Conn, g *Gate) *Conn {
+	kc := transport.NewKCPConn(nc, g.connOptions)
+	c := NewConnWithTransport(kc, g, ConnKCP)
+	c.conv = kc.Conv()
 	return c
 }
 
@@ -81,26 +114,37 @@ func NewWSConn(ws *websocket.Conn, g *Gate, useJSON bool) *Conn {
 		}
 	}()
 
-	c := NewConnWithTransport(transport.NewWSConn(ws, useJSON), g)
-	c.connType = ConnWS
-	return c
+	return NewConnWithTransport(transport.NewWSConn(ws, useJSON), g, ConnWS)
 }
 
-func NewConnWithTransport(conn transport.Conn, g *Gate) *Conn {
+// NewConnWithTransport gate_connections 在这里按 connType 加一，Close 里按同一个 connType 减一
+func NewConnWithTransport(conn transport.Conn, g *Gate, connType ConnType) *Conn {
 	now := time.Now()
 
 	c := &Conn{
-		gate: g,
-		conn: conn,
+		gate:     g,
+		conn:     conn,
+		connType: connType,
 
-		sendCh: make(chan *internalpb.Envelope, 8*1024),
-		closed: make(chan struct{}),
+		criticalCh: make(chan outbound, g.delivery.CriticalQueue),
+		sendCh:     make(chan outbound, g.delivery.NormalQueue),
+		latest:     newLatestQueue(g.delivery.DroppableQueue),
+		wake:       make(chan struct{}, 1),
+		closed:     make(chan struct{}),
 
 		traceID:     g.newTraceID(),
 		connectedAt: time.Now(),
 	}
 
+	if ra, ok := conn.(interface{ RemoteAddr() net.Addr }); ok && ra.RemoteAddr() != nil {
+		if ip, ok := remoteIP(ra.RemoteAddr().String()); ok {
+			c.remoteIP = ip.Unmap()
+		}
+	}
+
 	c.lastSeen.Store(now.UnixNano())
+	gateConnections.With(connType.String()).Inc()
+	g.liveConns.add(c)
 
 	go c.writeLoop()
 	return c
@@ -118,6 +162,11 @@ func (c *Conn) lastAlive() time.Time {
 	return time.Unix(0, n)
 }
 
+// OnClose 设置连接关闭时的回调，需在 ReadLoop 之前调用
+func (c *Conn) OnClose(fn func()) {
+	c.onClose = fn
+}
+
 func (c *Conn) SessonId() int64 {
 	return c.sessionID
 }
@@ -126,38 +175,211 @@ func (c *Conn) TraceID() string {
 	return c.traceID
 }
 
+func (c *Conn) Conv() uint32 {
+	return c.conv
+}
+
+// ClientInfo 握手协商的结果；未握手的老客户端返回 nil
+func (c *Conn) ClientInfo() *internalpb.ClientInfo {
+	if c == nil {
+		return nil
+	}
+	return c.client.Load()
+}
+
+// payloadCodec 握手协商的 payload 编码，写在 Envelope.Codec 上；未握手或 protobuf 为空
+func (c *Conn) payloadCodec() string {
+	info := c.ClientInfo()
+	if info == nil || info.Codec == codec.NameProtobuf {
+		return ""
+	}
+	return info.Codec
+}
+
+// encodePayload Gate 自己下发的消息按连接协商的编码序列化，返回 payload 与 Envelope.Codec
+func (c *Conn) encodePayload(m proto.Message) ([]byte, string) {
+	name := c.payloadCodec()
+	data, err := codec.Encode(name, m)
+	if err != nil {
+		data, _ = proto.Marshal(m)
+		return data, ""
+	}
+	return data, name
+}
+
+// outbound 下行队列元素：普通 Envelope 或广播共享的预编码消息
+type outbound struct {
+	env      *internalpb.Envelope
+	prepared *transport.PreparedEnvelope
+	last     bool // 写完后关闭连接
+}
+
+func (o outbound) msgID() int32 {
+	if o.prepared != nil {
+		return o.prepared.Env.MsgId
+	}
+	return o.env.MsgId
+}
+
+// closeFlushTimeout SendAndClose 等待最后一条消息写出的上限
+const closeFlushTimeout = 3 * time.Second
+
 func (c *Conn) writeLoop() {
 	defer c.Close()
 
+	// ⭐ 支持批量写的连接（TCP / KCP）把已排队的消息合并成一次 Flush
+	bw, batching := c.conn.(transport.BatchWriter)
+	var co *transport.Coalescer[outbound]
+	if batching {
+		co = transport.NewCoalescer[outbound](c.gate.connOptions)
+	}
+
 	for {
-		select {
-		case env := <-c.sendCh:
-			if err := c.conn.WriteEnvelope(env); err != nil {
+		out, ok := c.next()
+		if !ok {
+			select {
+			case <-c.wake:
+				continue
+			case <-c.closed:
 				return
 			}
-
-		case <-c.closed:
+		}
+		if !batching {
+			if err := c.write(out); err != nil || out.last {
+				return
+			}
+			continue
+		}
+		last, err := c.writeBatch(bw, co.CollectFunc(out, c.next, c.wake, c.closed))
+		if err != nil || last {
 			return
 		}
 	}
 }
 
-func (c *Conn) Send(env *internalpb.Envelope) error {
+// next 按等级非阻塞地取下一条
+func (c *Conn) next() (outbound, bool) {
 	select {
-	case c.sendCh <- env:
-		return nil
+	case out := <-c.criticalCh:
+		return out, true
 	default:
-		atomic.AddUint64(&c.gate.connBusyCount, 1)
-		if atomic.AddUint32(&c.busyCount, 1) >= 5 {
-			c.gate.logger.Warn("conn send buffer full, closing",
+	}
+	select {
+	case out := <-c.sendCh:
+		return out, true
+	default:
+	}
+	return c.latest.pop()
+}
+
+func (c *Conn) queueDepth() int {
+	return len(c.criticalCh) + len(c.sendCh) + c.latest.len()
+}
+
+// writeBatch 写到 SendAndClose 的那条为止，之后的消息丢弃
+func (c *Conn) writeBatch(bw transport.BatchWriter, batch []outbound) (bool, error) {
+	last := false
+	for _, out := range batch {
+		var err error
+		if out.prepared != nil {
+			err = bw.BufferPrepared(out.prepared)
+		} else {
+			err = bw.BufferEnvelope(out.env)
+		}
+		if err != nil {
+			return false, err
+		}
+		if out.last {
+			last = true
+			break
+		}
+	}
+	return last, bw.Flush()
+}
+
+func (c *Conn) write(out outbound) error {
+	if out.prepared == nil {
+		return c.conn.WriteEnvelope(out.env)
+	}
+	if pw, ok := c.conn.(transport.PreparedWriter); ok {
+		return pw.WritePrepared(out.prepared)
+	}
+	return c.conn.WriteEnvelope(out.prepared.Env)
+}
+
+func (c *Conn) Send(env *internalpb.Envelope) error {
+	return c.enqueue(outbound{env: env})
+}
+
+// SendPrepared 发送广播共享的预编码消息
+func (c *Conn) SendPrepared(p *transport.PreparedEnvelope) error {
+	return c.enqueue(outbound{prepared: p})
+}
+
+// SendAndClose 发完这条消息（如拒绝原因）再关闭连接，之后收到的上行消息丢弃
+func (c *Conn) SendAndClose(env *internalpb.Envelope) {
+	if !c.closing.CompareAndSwap(false, true) {
+		return
+	}
+	if err := c.enqueue(outbound{env: env, last: true}); err != nil {
+		c.Close()
+		return
+	}
+	time.AfterFunc(closeFlushTimeout, c.Close)
+}
+
+// enqueue 客户端读得慢时：droppable 覆盖或丢弃；normal 满了先清掉 droppable 积压再丢本条；
+// 只有 critical 也放不下时才断开连接
+func (c *Conn) enqueue(out outbound) error {
+	msgID := out.msgID()
+	class := c.gate.deliveryClass(msgID)
+	if out.last {
+		class = DeliveryCritical
+	}
+
+	switch class {
+	case DeliveryDroppable:
+		coalesced, dropped := c.latest.push(msgID, out)
+		if coalesced {
+			atomic.AddUint64(&c.gate.sendCoalescedCount, 1)
+		}
+		if dropped {
+			c.gate.onSendDropped(c, class, msgID, 1)
+			return nil
+		}
+
+	case DeliveryCritical:
+		select {
+		case c.criticalCh <- out:
+		default:
+			c.gate.onSendDropped(c, class, msgID, 1)
+			c.gate.logger.Warn("conn critical queue full, closing",
 				zap.String("reason", "conn_busy"),
+				zap.Int("msg_id", int(msgID)),
 				zap.String("trace_id", c.traceID),
 				zap.Int64("session", c.sessionID),
 			)
 			c.Close()
+			return ErrConnBusy
 		}
-		return ErrConnBusy
+
+	default:
+		select {
+		case c.sendCh <- out:
+		default:
+			if n := c.latest.clear(); n > 0 {
+				c.gate.onSendDropped(c, DeliveryDroppable, 0, n)
+			}
+			c.gate.onSendDropped(c, class, msgID, 1)
+			return ErrConnBusy
+		}
+	}
+
+	select {
+	case c.wake <- struct{}{}:
+	default:
 	}
+	return nil
 }
 
 var ErrConnBusy = errors.New("connection send buffer full")
@@ -170,13 +392,20 @@ func (c *Conn) ReadLoop() {
 		env, err := c.conn.ReadEnvelope()
 		if err != nil {
 			c.gate.onConnClose(c)
+			// 读失败后释放写协程与 IP 名额
+			c.Close()
 			return
 		}
 
 		// ⭐ 任意数据到达即视为连接活跃
-		c.markAlive(time.Now())
+		now := time.Now()
+		c.markAlive(now)
 
+		if c.closing.Load() {
+			continue
+		}
 		c.gate.OnEnvelope(c, env)
+		observeRequest(int(env.MsgId), time.Since(now))
 	}
 }
 
@@ -187,6 +416,11 @@ func (c *Conn) Close() {
 	c.once.Do(func() {
 		close(c.closed)
 		_ = c.conn.Close()
+		gateConnections.With(c.connType.String()).Dec()
+		c.gate.liveConns.remove(c)
+		if c.onClose != nil {
+			c.onClose()
+		}
 	})
 }
 
@@ -199,11 +433,15 @@ func (g *Gate) onConnClose(c *Conn) {
 		return
 	}
 
-	s.Conn = nil
-	s.State = SessionOffline
-	s.LastSeen = time.Now()
+	if !s.detachConnIf(c) {
+		// 会话已经换到别的连接（resume）或已被摘下，旧连接关闭不影响会话
+		return
+	}
+	s.SetState(SessionOffline)
+	s.setLastSeen(time.Now())
 
 	g.notifyPlayerOffline(s)
+	g.saveSessionRecord(s)
 	g.logger.Info("client connection closed",
 		zap.String("reason", "read_error"),
 		zap.Int64("session", s.ID),
@@ -225,9 +463,9 @@ func (g *Gate) onResume(c *Conn, req *ResumeReq) error {
 
 	now := time.Now()
 
-	s.Conn = c
-	s.State = SessionOnline
-	s.LastSeen = now
+	s.setConn(c)
+	s.SetState(SessionOnline)
+	s.setLastSeen(now)
 
 	c.sessionID = s.ID
 	c.markAlive(now)
@@ -0,0 +1,169 @@
+// internal/gate/delivery.go
+package gate
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"game-server/internal/protocol"
+	"go.uber.org/zap"
+)
+
+// DeliveryClass 下行消息的投递等级：客户端读得慢时先丢 Droppable，Critical 投递不了才断开
+type DeliveryClass uint8
+
+const (
+	DeliveryNormal    DeliveryClass = iota
+	DeliveryCritical                // 登录 / 恢复 / 握手结果等，丢了客户端状态就错了
+	DeliveryDroppable               // 位置同步等，同一 msgID 只保留最新一条
+	deliveryClassCount
+)
+
+func (d DeliveryClass) String() string {
+	switch d {
+	case DeliveryNormal:
+		return "normal"
+	case DeliveryCritical:
+		return "critical"
+	case DeliveryDroppable:
+		return "droppable"
+	default:
+		return "unknown"
+	}
+}
+
+// DeliveryPolicy msgID → 等级（未列出的为 Normal）与每个等级的队列长度；0 取默认
+type DeliveryPolicy struct {
+	Classes        map[int]DeliveryClass
+	CriticalQueue  int
+	NormalQueue    int
+	DroppableQueue int // 最多同时缓存多少个不同 msgID 的最新消息
+}
+
+// 队列按连接预分配（每项 24 字节），默认值按万级连接估算；积压超过这个量说明客户端已经跟不上
+const (
+	defaultCriticalQueue  = 64
+	defaultNormalQueue    = 512
+	defaultDroppableQueue = 64
+)
+
+// DefaultDeliveryClasses 内置的等级；配置里的同名 msgID 覆盖这里
+func DefaultDeliveryClasses() map[int]DeliveryClass {
+	return map[int]DeliveryClass{
+		protocol.MsgResumeRsp:          DeliveryCritical,
+		protocol.MsgSessionInit:        DeliveryCritical,
+		protocol.MsgHandshakeRsp:       DeliveryCritical,
+		protocol.MsgErrorRsp:           DeliveryCritical,
+		protocol.MsgLoginRsp:           DeliveryCritical,
+		protocol.MsgPlayerEnterGameRsp: DeliveryCritical,
+		protocol.MsgHeartbeatRsp:       DeliveryDroppable,
+	}
+}
+
+func defaultDeliveryPolicy() DeliveryPolicy {
+	return DeliveryPolicy{
+		Classes:        DefaultDeliveryClasses(),
+		CriticalQueue:  defaultCriticalQueue,
+		NormalQueue:    defaultNormalQueue,
+		DroppableQueue: defaultDroppableQueue,
+	}
+}
+
+// SetDeliveryPolicy 需在接受连接之前调用；Classes 与内置等级合并
+func (g *Gate) SetDeliveryPolicy(p DeliveryPolicy) {
+	classes := DefaultDeliveryClasses()
+	for msgID, class := range p.Classes {
+		classes[msgID] = class
+	}
+	p.Classes = classes
+	if p.CriticalQueue <= 0 {
+		p.CriticalQueue = defaultCriticalQueue
+	}
+	if p.NormalQueue <= 0 {
+		p.NormalQueue = defaultNormalQueue
+	}
+	if p.DroppableQueue <= 0 {
+		p.DroppableQueue = defaultDroppableQueue
+	}
+	g.delivery = p
+}
+
+func (g *Gate) deliveryClass(msgID int32) DeliveryClass {
+	return g.delivery.Classes[int(msgID)]
+}
+
+// onSendDropped 下行队列放不下时计数；droppable 丢弃是预期行为，不打日志
+func (g *Gate) onSendDropped(c *Conn, class DeliveryClass, msgID int32, n int) {
+	atomic.AddUint64(&g.sendDroppedCount[class], uint64(n))
+	atomic.AddUint64(&g.connBusyCount, uint64(n))
+	if class == DeliveryDroppable {
+		return
+	}
+	g.logger.Warn("conn send queue full, drop message",
+		zap.String("reason", "conn_busy"),
+		zap.String("class", class.String()),
+		zap.Int("msg_id", int(msgID)),
+		zap.Int64("session", c.sessionID),
+		zap.String("trace_id", c.traceID),
+	)
+}
+
+// latestQueue Droppable 消息队列：同一 msgID 后到的覆盖先到的，按首次入队顺序发出
+type latestQueue struct {
+	mu    sync.Mutex
+	order []int32
+	items map[int32]outbound
+	limit int
+}
+
+func newLatestQueue(limit int) *latestQueue {
+	return &latestQueue{
+		items: make(map[int32]outbound),
+		limit: limit,
+	}
+}
+
+// push 返回 coalesced：覆盖了同 msgID 的旧消息；dropped：队列已满被丢弃
+func (q *latestQueue) push(msgID int32, out outbound) (coalesced, dropped bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.items[msgID]; ok {
+		q.items[msgID] = out
+		return true, false
+	}
+	if len(q.order) >= q.limit {
+		return false, true
+	}
+	q.order = append(q.order, msgID)
+	q.items[msgID] = out
+	return false, false
+}
+
+func (q *latestQueue) pop() (outbound, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.order) == 0 {
+		return outbound{}, false
+	}
+	msgID := q.order[0]
+	q.order = q.order[1:]
+	out := q.items[msgID]
+	delete(q.items, msgID)
+	return out, true
+}
+
+// clear 普通队列满时先让出 Droppable 的积压，返回丢弃条数
+func (q *latestQueue) clear() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := len(q.order)
+	q.order = q.order[:0]
+	clear(q.items)
+	return n
+}
+
+func (q *latestQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.order)
+}
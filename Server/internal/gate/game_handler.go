@@ -0,0 +1,26 @@
+package gate
+
+import (
+	"game-server/internal/protocol"
+	"game-server/internal/protocol/internalpb"
+)
+
+// internal/gate/game_handler.go
+// OnGameEnvelope Game 直连回包 / 推送：控制消息在 Gate 内处理，其余原样转发给客户端
+func (g *Gate) OnGameEnvelope(env *internalpb.Envelope) {
+	if env.MsgId == protocol.MsgServicePong {
+		return
+	}
+
+	if env.MsgId == protocol.MsgGateControl {
+		g.onGateControl(env.Payload)
+		return
+	}
+
+	if env.MsgId == protocol.MsgPlayerMigrateRsp {
+		g.onMigrateRsp(env.Payload)
+		return
+	}
+
+	_ = g.reply(env.SessionId, int(env.MsgId), env.Codec, env.Payload)
+}
@@ -4,26 +4,38 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"sync/atomic"
 	"time"
 
 	"game-server/internal/handler"
 	"game-server/internal/protocol"
 	"game-server/internal/protocol/internalpb"
+	"game-server/internal/router"
 	"game-server/internal/transport"
 	"go.uber.org/zap"
 	"google.golang.org/protobuf/proto"
 )
 
-var ErrSessionNotFound = errors.New("session not found")
+var (
+	ErrSessionNotFound      = errors.New("session not found")
+	ErrSessionIDUnavailable = errors.New("session id unavailable")
+)
 
 type Gate struct {
 	logger         *zap.Logger
 	debugHeartbeat bool // ⭐ 新增
 
 	sessions *SessionManager
+	groups   *groupManager
+
+	servicePool  *servicePool
+	gamePools    map[string]*remoteClientPool // shard id -> pool
+	gameSelector *router.ShardSelector
+	migrations   *migrationTable
 
-	servicePool *remoteClientPool
+	servicePingInterval  time.Duration
+	serviceHealthTimeout time.Duration
 
 	connOptions transport.ConnOptions
 
@@ -31,6 +43,8 @@ type Gate struct {
 	heartbeatTimeout  time.Duration
 	gcInterval        time.Duration
 
+	kcpHeartbeatTimeout time.Duration // 0 时为 heartbeatTimeout 的两倍
+
 	nextID int64
 
 	loginTimeout         time.Duration
@@ -38,16 +52,37 @@ type Gate struct {
 	loginRateLimitWindow time.Duration
 	unknownMsgKickCount  int
 
+	rateLimits          []RateLimitRule
+	rateLimitKickCount  int
+	rateLimitKickWindow time.Duration // 违规只在窗口内累计，窗口过后重新计数
+
+	ipFilter  *IPFilter
+	liveConns *connRegistry // 已接入的客户端连接，deny 规则变化时据此断开命中的连接
+
 	id        string // 比如 "gate1"
 	nextTrace uint64
 
 	handlers *handler.Registry[HandlerFunc]
 
+	resumeKeys     *KeyRing
+	resumeTokenTTL time.Duration
+
+	sessionStore SessionStore
+
+	replayBufferSize int
+
+	compressions []internalpb.Compression
+	handshake    HandshakePolicy
+	delivery     DeliveryPolicy
+
 	heartbeatTimeoutCount uint64
 	loginTimeoutCount     uint64
 	loginRateLimitCounted uint64
 	unknownMsgCount       uint64
 	connBusyCount         uint64
+	sendDroppedCount      [deliveryClassCount]uint64
+	sendCoalescedCount    uint64
+	connRejectedCount     uint64
 }
 
 func (g *Gate) newTraceID() string {
@@ -66,7 +101,9 @@ func NewGate(logger *zap.Logger) *Gate {
 	}
 	g := &Gate{
 		logger:               logger,
+		id:                   fmt.Sprintf("gate-%d", os.Getpid()),
 		sessions:             NewSessionManager(),
+		groups:               newGroupManager(),
 		heartbeatInterval:    100 * time.Second,
 		heartbeatTimeout:     300 * time.Second,
 		gcInterval:           10 * time.Minute,
@@ -74,11 +111,23 @@ func NewGate(logger *zap.Logger) *Gate {
 		loginRateLimitCount:  5,
 		loginRateLimitWindow: 10 * time.Second,
 		unknownMsgKickCount:  3,
+		rateLimits:           defaultRateLimitRules(),
+		rateLimitKickCount:   20,
+		rateLimitKickWindow:  time.Minute,
+		ipFilter:             NewIPFilter(),
+		liveConns:            newConnRegistry(),
 		handlers:             handler.NewRegistry[HandlerFunc](),
+		handshake:            HandshakePolicy{MinProtocolVersion: protocol.ProtocolVersion, MaxProtocolVersion: protocol.ProtocolVersion},
+		delivery:             defaultDeliveryPolicy(),
+		resumeKeys:           NewKeyRing(), // 启动时由 SetResumeKeys 填充，没有密钥不签发 token
+		resumeTokenTTL:       24 * time.Hour,
+		replayBufferSize:     defaultReplayBufferSize,
+		migrations:           newMigrationTable(),
 	}
 	if err := g.registerHandlers(); err != nil {
 		g.logger.Warn("register gate handlers failed", zap.String("reason", err.Error()))
 	}
+	g.registerMetrics()
 	return g
 }
 
@@ -86,15 +135,50 @@ func (g *Gate) Start(ctx context.Context) {
 	go g.heartbeatLoop(ctx)
 	go g.gcLoop(ctx)
 	go g.reportStats(ctx, time.Minute)
+	go g.watchSessionDrops(ctx)
+	go g.sessionRefreshLoop(ctx)
+}
+
+// SetID 设置 Gate 标识；启用共享会话目录时各 Gate 必须唯一
+func (g *Gate) SetID(id string) {
+	g.id = id
 }
 
-func (g *Gate) nextSessionID() int64 {
-	return atomic.AddInt64(&g.nextID, 1)
+func (g *Gate) ID() string {
+	return g.id
+}
+
+func (g *Gate) registerEnvelope() *internalpb.Envelope {
+	data, _ := proto.Marshal(&internalpb.GateRegister{GateId: g.id})
+	return &internalpb.Envelope{
+		MsgId:   protocol.MsgGateRegister,
+		Payload: data,
+	}
+}
+
+// nextSessionID 启用共享会话目录时只从共享计数器分配；分配失败返回错误，
+// 不退回本地计数，否则会与其他 Gate 已分配的 ID 冲突
+func (g *Gate) nextSessionID() (int64, error) {
+	if g.sessionStore == nil {
+		return atomic.AddInt64(&g.nextID, 1), nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sessionStoreTimeout)
+	defer cancel()
+	id, err := g.sessionStore.NextSessionID(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrSessionIDUnavailable, err)
+	}
+	return id, nil
 }
 
 func (g *Gate) Reply(sessionID int64, msgID int, data []byte) error {
+	return g.reply(sessionID, msgID, "", data)
+}
+
+// reply codecName 为 payload 的编码，Service / Game 回包时原样带回请求的编码
+func (g *Gate) reply(sessionID int64, msgID int, codecName string, data []byte) error {
 	s := g.sessions.Get(sessionID)
-	if s == nil || s.Conn == nil {
+	if s == nil {
 		return ErrSessionNotFound
 	}
 
@@ -103,33 +187,47 @@ func (g *Gate) Reply(sessionID int64, msgID int, data []byte) error {
 		SessionId: sessionID,
 		PlayerId:  s.PlayerID,
 		Payload:   data,
+		Codec:     codecName,
 	}
-	return s.Conn.Send(env)
+	if isSequenced(msgID) {
+		return g.sendSequenced(s, env)
+	}
+
+	conn := s.Conn
+	if conn == nil {
+		return ErrSessionNotFound
+	}
+	return conn.Send(env)
 }
 
-func (g *Gate) NewSession(conn *Conn) *Session {
-	s := g.newSession()
+func (g *Gate) NewSession(conn *Conn) (*Session, error) {
+	s, err := g.newSession()
+	if err != nil {
+		return nil, err
+	}
 	s.Conn = conn
-	s.State = SessionOnline
-	s.LastSeen = time.Now()
+	s.SetState(SessionOnline)
+	s.setLastSeen(time.Now())
 	conn.sessionID = s.ID
 	g.sessions.Add(s)
+	g.saveSessionRecord(s)
 
 	// ⭐ 核心：SessionInit
 	init := &internalpb.SessionInit{
 		SessionId: s.ID,
 		Token:     s.Token, // 现在可以 mock
 	}
-	data, _ := proto.Marshal(init)
+	data, codecName := conn.encodePayload(init)
 
 	_ = conn.Send(&internalpb.Envelope{
 		MsgId:     protocol.MsgSessionInit,
 		SessionId: s.ID,
 		Payload:   data,
+		Codec:     codecName,
 	})
 
 	g.logger.Info("session init", append(sessionFields(s), connFields(conn)...)...)
-	return s
+	return s, nil
 }
 
 func (g *Gate) Push(sessionID int64, msgID int, data []byte) error {
@@ -145,11 +243,37 @@ func (g *Gate) Kick(sessionID int64, reason string) error {
 	return nil
 }
 
-func (g *Gate) ConnectService(ctx context.Context, addr string, poolSize int) {
-	g.servicePool = newRemoteClientPool("service", addr, g.logger, g.OnServiceEnvelope, poolSize, g.connOptions, 2, 5*time.Millisecond)
+// ConnectService 每个地址一个 service 端点，每个端点 poolSize 条连接
+func (g *Gate) ConnectService(ctx context.Context, addrs []string, poolSize int) {
+	g.servicePool = newServicePool(addrs, g.logger, g.OnServiceEnvelope, poolSize, g.connOptions, 2, 5*time.Millisecond)
+	g.servicePool.SetHello(g.registerEnvelope())
+	g.servicePool.SetHealth(g.servicePingInterval, g.serviceHealthTimeout)
 	g.servicePool.Start(ctx)
 }
 
+// ConnectGame Gate 直连各 game 服：TargetGame 消息按玩家所在服发送，不再经 service 中转
+func (g *Gate) ConnectGame(ctx context.Context, selector *router.ShardSelector, poolSize int) {
+	pools := make(map[string]*remoteClientPool)
+	for _, shard := range selector.Shards() {
+		pool := newRemoteClientPool("game", shard.Addr, g.logger, g.OnGameEnvelope, poolSize, g.connOptions, 2, 5*time.Millisecond)
+		pool.Start(ctx)
+		pools[shard.ID] = pool
+	}
+	g.gameSelector = selector
+	g.gamePools = pools
+}
+
+// SetServiceHealth 设置 service 健康检查参数，需在 ConnectService 之前调用
+func (g *Gate) SetServiceHealth(pingInterval, timeout time.Duration) {
+	g.servicePingInterval = pingInterval
+	g.serviceHealthTimeout = timeout
+}
+
+// SetKCPHeartbeatTimeout KCP 连接的心跳超时；0 时为普通超时的两倍
+func (g *Gate) SetKCPHeartbeatTimeout(timeout time.Duration) {
+	g.kcpHeartbeatTimeout = timeout
+}
+
 func (g *Gate) UpdateConfig(interval, timeout, gc, loginTimeout time.Duration, loginLimitCount int, loginWindow time.Duration, unknownMsgKick int, connOptions transport.ConnOptions) {
 	if interval > 0 {
 		g.heartbeatInterval = interval
@@ -175,28 +299,44 @@ func (g *Gate) UpdateConfig(interval, timeout, gc, loginTimeout time.Duration, l
 	g.connOptions = connOptions
 }
 
+// SetResumeKeys 设置 resume token 签名密钥；keys 为空时返回 ErrNoResumeKeys，Gate 应拒绝启动
+func (g *Gate) SetResumeKeys(keys []ResumeKey, tokenTTL time.Duration) error {
+	if len(keys) == 0 {
+		return ErrNoResumeKeys
+	}
+	g.resumeKeys = NewKeyRing(keys...)
+	if tokenTTL > 0 {
+		g.resumeTokenTTL = tokenTTL
+	}
+	return nil
+}
+
+func (g *Gate) ResumeKeys() *KeyRing {
+	return g.resumeKeys
+}
+
 func (g *Gate) Logger() *zap.Logger {
 	return g.logger
 }
 
 func (g *Gate) onSessionOffline(s *Session, reason string) {
-	if s == nil || s.State == SessionClosed {
+	if s == nil || s.State() == SessionClosed {
 		return
 	}
-	if s.State == SessionOffline && s.Conn == nil {
+	if s.State() == SessionOffline && s.Conn == nil {
 		return
 	}
-	conn := s.Conn
+	conn := s.detachConn()
 	if conn != nil {
 		conn.Close()
 	}
-	s.Conn = nil
-	s.LastSeen = time.Now()
-	wasOnline := s.State != SessionOffline
-	s.State = SessionOffline
+	s.setLastSeen(time.Now())
+	wasOnline := s.State() != SessionOffline
+	s.SetState(SessionOffline)
 	if wasOnline {
 		g.notifyPlayerOffline(s)
 	}
+	g.saveSessionRecord(s)
 	fields := append(sessionFields(s),
 		zap.String("reason", reason),
 		zap.Int("msg_id", 0),
@@ -0,0 +1,94 @@
+package gate
+
+import (
+	"sync"
+)
+
+// groupManager 命名频道分组：group -> sessions，以及反向索引用于会话销毁时清理
+type groupManager struct {
+	mu        sync.RWMutex
+	groups    map[string]map[int64]struct{}
+	bySession map[int64]map[string]struct{}
+}
+
+func newGroupManager() *groupManager {
+	return &groupManager{
+		groups:    make(map[string]map[int64]struct{}),
+		bySession: make(map[int64]map[string]struct{}),
+	}
+}
+
+func (gm *groupManager) join(group string, sessionID int64) {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	members := gm.groups[group]
+	if members == nil {
+		members = make(map[int64]struct{})
+		gm.groups[group] = members
+	}
+	members[sessionID] = struct{}{}
+
+	joined := gm.bySession[sessionID]
+	if joined == nil {
+		joined = make(map[string]struct{})
+		gm.bySession[sessionID] = joined
+	}
+	joined[group] = struct{}{}
+}
+
+func (gm *groupManager) leave(group string, sessionID int64) {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	gm.leaveLocked(group, sessionID)
+}
+
+func (gm *groupManager) leaveLocked(group string, sessionID int64) {
+	if members := gm.groups[group]; members != nil {
+		delete(members, sessionID)
+		if len(members) == 0 {
+			delete(gm.groups, group)
+		}
+	}
+	if joined := gm.bySession[sessionID]; joined != nil {
+		delete(joined, group)
+		if len(joined) == 0 {
+			delete(gm.bySession, sessionID)
+		}
+	}
+}
+
+// removeSession 会话彻底销毁时退出所有分组
+func (gm *groupManager) removeSession(sessionID int64) {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	for group := range gm.bySession[sessionID] {
+		gm.leaveLocked(group, sessionID)
+	}
+}
+
+func (gm *groupManager) members(group string) []int64 {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+
+	members := gm.groups[group]
+	items := make([]int64, 0, len(members))
+	for id := range members {
+		items = append(items, id)
+	}
+	return items
+}
+
+func (gm *groupManager) groupsOf(sessionID int64) []string {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+
+	joined := gm.bySession[sessionID]
+	items := make([]string, 0, len(joined))
+	for group := range joined {
+		items = append(items, group)
+	}
+	return items
+}
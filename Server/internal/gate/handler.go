@@ -15,16 +15,27 @@ type HandlerFunc func(s *Session, c *Conn, env *internalpb.Envelope)
 
 func (g *Gate) OnEnvelope(c *Conn, env *internalpb.Envelope) {
 	msgID := int(env.MsgId)
+	// ⭐ 客户端信息只信任 Gate 自己握手得到的
+	env.Client = nil
+	if !g.normalizeCodec(c, env) {
+		return
+	}
 
 	// =========================
-	// 1️⃣ Resume 协商：优先处理
+	// 1️⃣ 握手 / Resume 协商：优先处理
 	// =========================
-	if msgID == protocol.MsgResumeReq {
+	if msgID == protocol.MsgResumeReq || msgID == protocol.MsgHandshakeReq {
+		if msgID == protocol.MsgResumeReq && !g.requireHandshake(c, msgID) {
+			return
+		}
 		g.dispatchHandler(nil, c, env)
 		return
 	}
 
 	if c.sessionID == 0 {
+		if msgID == protocol.MsgLoginReq && !g.requireHandshake(c, msgID) {
+			return
+		}
 		if msgID != protocol.MsgLoginReq {
 			g.logger.Warn("reject msg before session init",
 				zap.Int("msg_id", msgID),
@@ -39,7 +50,27 @@ func (g *Gate) OnEnvelope(c *Conn, env *internalpb.Envelope) {
 		}
 
 		// ⭐ 只有这里才创建 Session
-		s := g.createSessionForConn(c)
+		s, err := g.createSessionForConn(c)
+		if err != nil {
+			g.logger.Warn("create session failed, reject conn",
+				zap.String("reason", err.Error()),
+				zap.Int("msg_id", msgID),
+				zap.Int64("session", 0),
+				zap.Int64("player", 0),
+				zap.Int64("sesson_id", c.sessionID),
+				zap.String("trace_id", c.traceID),
+			)
+			data, codecName := c.encodePayload(&internalpb.ErrorRsp{
+				Code:    int32(protocol.ErrUnknown),
+				Message: "session unavailable, retry later",
+			})
+			c.SendAndClose(&internalpb.Envelope{
+				MsgId:   protocol.MsgErrorRsp,
+				Payload: data,
+				Codec:   codecName,
+			})
+			return
+		}
 
 		if !g.allowLogin(s) {
 			atomic.AddUint64(&g.loginRateLimitCounted, 1)
@@ -59,6 +90,8 @@ func (g *Gate) OnEnvelope(c *Conn, env *internalpb.Envelope) {
 			MsgId:     int32(msgID),
 			SessionId: s.ID,
 			Payload:   env.Payload,
+			Client:    c.ClientInfo(),
+			Codec:     env.Codec,
 		})
 		return
 	}
@@ -76,7 +109,7 @@ func (g *Gate) OnEnvelope(c *Conn, env *internalpb.Envelope) {
 		c.Close()
 		return
 	}
-	if s.State == SessionOffline {
+	if s.State() == SessionOffline {
 		g.logger.Warn("reject msg on offline session",
 			zap.Int("msg_id", msgID),
 			zap.String("reason", "session_offline"),
@@ -88,6 +121,11 @@ func (g *Gate) OnEnvelope(c *Conn, env *internalpb.Envelope) {
 		return
 	}
 
+	if rule, ok := g.allowMessage(s, msgID); !ok {
+		g.onRateLimited(s, c, rule, msgID)
+		return
+	}
+
 	// =========================
 	// 2️⃣ Login 流程
 	// =========================
@@ -104,7 +142,7 @@ func (g *Gate) OnEnvelope(c *Conn, env *internalpb.Envelope) {
 			)
 			return
 		}
-		switch s.State {
+		switch s.State() {
 
 		case SessionAuthenticated:
 			// 重复登录策略（下面第四部分讲）
@@ -113,7 +151,7 @@ func (g *Gate) OnEnvelope(c *Conn, env *internalpb.Envelope) {
 
 		case SessionOnline:
 			// ⭐ 进入 Authing
-			s.State = SessionAuthing
+			s.SetState(SessionAuthing)
 			s.AuthStart = time.Now()
 
 		case SessionAuthing:
@@ -128,12 +166,13 @@ func (g *Gate) OnEnvelope(c *Conn, env *internalpb.Envelope) {
 			)
 			return
 		}
+		env.Client = c.ClientInfo()
 	}
 
 	// =========================
 	// 3️⃣ 权限判断
 	// =========================
-	if s.State == SessionOnline {
+	if s.State() == SessionOnline {
 		g.logger.Warn("unauth msg",
 			zap.String("reason", "unauthenticated"),
 			zap.Int("msg_id", msgID),
@@ -174,13 +213,23 @@ func (g *Gate) OnEnvelope(c *Conn, env *internalpb.Envelope) {
 		return
 	}
 
-	s.LastSeen = time.Now()
+	s.setLastSeen(time.Now())
 
 	switch rule.Target {
 	case router.TargetService:
 		g.sendToService(rule.Module, env)
 	case router.TargetGame:
 		g.sendToGame(env)
+	case router.TargetDB:
+		// 客户端不允许直连 DB
+		g.logger.Warn("client msg routed to db",
+			zap.Int("msg_id", msgID),
+			zap.Int64("session", s.ID),
+			zap.Int64("player", s.PlayerID),
+			zap.String("reason", "db_route_forbidden"),
+			zap.Int64("sesson_id", c.sessionID),
+			zap.String("trace_id", c.traceID),
+		)
 	default:
 		g.logger.Warn("unknown route target",
 			zap.Int("msg_id", msgID),
@@ -197,6 +246,9 @@ func (g *Gate) registerHandlers() error {
 	if err := g.handlers.Register(protocol.MsgResumeReq, g.onResumeHandler); err != nil {
 		return err
 	}
+	if err := g.handlers.Register(protocol.MsgHandshakeReq, g.onHandshakeHandler); err != nil {
+		return err
+	}
 	return g.handlers.Register(protocol.MsgHeartbeatReq, g.onHeartbeatHandler)
 }
 
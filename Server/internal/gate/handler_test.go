@@ -0,0 +1,54 @@
+package gate
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"game-server/internal/protocol"
+	"game-server/internal/protocol/internalpb"
+	"game-server/internal/router"
+)
+
+// 路由表热加载后，Gate 的上行路由立即按新表走
+func TestOnEnvelopeSeesReloadedRoutes(t *testing.T) {
+	t.Cleanup(func() {
+		if _, err := router.Load(router.DefaultEntries()); err != nil {
+			t.Fatalf("restore routes: %v", err)
+		}
+	})
+
+	g := newTestGate(t)
+	c, _ := startConn(g, newPipeConn())
+	t.Cleanup(c.Close)
+	s, err := g.createSessionForConn(c)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	s.SetState(SessionAuthenticated)
+
+	const msgID = protocol.MsgChatBegin + 7
+	send := func() uint64 {
+		before := atomic.LoadUint64(&g.unknownMsgCount)
+		g.OnEnvelope(c, &internalpb.Envelope{MsgId: msgID, SessionId: s.ID})
+		return atomic.LoadUint64(&g.unknownMsgCount) - before
+	}
+
+	// DB 目标只记日志，不依赖后端连接
+	if _, err := router.Load([]router.RouteEntry{
+		{MsgBegin: protocol.MsgChatBegin, MsgEnd: protocol.MsgChatEnd, Target: router.TargetDB},
+	}); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if n := send(); n != 0 {
+		t.Fatalf("msg %d counted as unknown with a route for it", msgID)
+	}
+
+	if _, err := router.Load([]router.RouteEntry{
+		{MsgBegin: protocol.MsgGameBegin, MsgEnd: protocol.MsgGameEnd, Target: router.TargetDB},
+	}); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if n := send(); n != 1 {
+		t.Fatalf("msg %d still routed after the reload dropped its range", msgID)
+	}
+}
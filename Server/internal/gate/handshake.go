@@ -0,0 +1,272 @@
+// internal/gate/handshake.go
+package gate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"game-server/internal/protocol"
+	"game-server/internal/protocol/codec"
+	"game-server/internal/protocol/internalpb"
+	"game-server/internal/transport"
+	"go.uber.org/zap"
+)
+
+// HandshakePolicy 握手时接受的协议版本区间与最低客户端版本
+type HandshakePolicy struct {
+	MinProtocolVersion uint32
+	MaxProtocolVersion uint32
+	MinClientVersion   string // 为空不校验；按点分段逐段比较数字，如 1.4.2
+	UpgradeURL         string // 拒绝时下发给客户端的升级地址
+	Required           bool   // 为 true 时未握手的连接不允许 Login / Resume
+}
+
+// SetCompression 下行压缩算法的偏好顺序，握手时取第一个客户端也支持的；为空表示不压缩
+func (g *Gate) SetCompression(algos []internalpb.Compression) {
+	g.compressions = algos
+}
+
+// SetHandshakePolicy 版本为 0 时取当前协议版本
+func (g *Gate) SetHandshakePolicy(p HandshakePolicy) {
+	if p.MaxProtocolVersion == 0 {
+		p.MaxProtocolVersion = protocol.ProtocolVersion
+	}
+	if p.MinProtocolVersion == 0 {
+		p.MinProtocolVersion = protocol.ProtocolVersion
+	}
+	if p.MinProtocolVersion > p.MaxProtocolVersion {
+		p.MinProtocolVersion = p.MaxProtocolVersion
+	}
+	g.handshake = p
+}
+
+func (g *Gate) onHandshakeHandler(_ *Session, c *Conn, env *internalpb.Envelope) {
+	g.handleHandshake(c, env)
+}
+
+// handleHandshake 协商协议版本 / 编码 / 压缩；不发握手的老客户端按 protobuf、不压缩处理
+func (g *Gate) handleHandshake(c *Conn, env *internalpb.Envelope) {
+	var req internalpb.HandshakeReq
+	if err := codec.Decode(env, &req); err != nil {
+		g.logger.Warn("invalid handshake",
+			zap.Int("msg_id", protocol.MsgHandshakeReq),
+			zap.Int64("session", c.sessionID),
+			zap.String("reason", "bad_payload"),
+			zap.String("trace_id", c.traceID),
+		)
+		c.Close()
+		return
+	}
+	if c.ClientInfo() != nil {
+		g.logger.Warn("duplicate handshake",
+			zap.Int("msg_id", protocol.MsgHandshakeReq),
+			zap.Int64("session", c.sessionID),
+			zap.String("reason", "duplicate_handshake"),
+			zap.String("trace_id", c.traceID),
+		)
+		return
+	}
+
+	// 协议版本协商之前的客户端不带版本号，视为 1
+	version := req.ProtocolVersion
+	if version == 0 {
+		version = 1
+	}
+	policy := g.handshake
+	if version < policy.MinProtocolVersion {
+		g.rejectHandshake(c, env, &req, internalpb.HandshakeReject_PROTOCOL_TOO_OLD,
+			fmt.Sprintf("protocol %d not supported, min %d", version, policy.MinProtocolVersion))
+		return
+	}
+	if version > policy.MaxProtocolVersion {
+		version = policy.MaxProtocolVersion
+	}
+	if policy.MinClientVersion != "" && compareVersion(req.ClientVersion, policy.MinClientVersion) < 0 {
+		g.rejectHandshake(c, env, &req, internalpb.HandshakeReject_CLIENT_TOO_OLD,
+			fmt.Sprintf("client %q too old, min %s", req.ClientVersion, policy.MinClientVersion))
+		return
+	}
+	codecName, ok := pickCodec(req.Codecs)
+	if !ok {
+		g.rejectHandshake(c, env, &req, internalpb.HandshakeReject_CODEC_UNSUPPORTED,
+			fmt.Sprintf("codecs %v not supported", req.Codecs))
+		return
+	}
+
+	// ⭐ WebSocket 的 json 走文本帧，回包可以直接用新帧格式
+	if cs, ok := c.conn.(transport.CodecSwitcher); ok {
+		_ = cs.SetCodec(codecName)
+	}
+	algo := g.pickCompression(req.Compression)
+	if cc, ok := c.conn.(transport.Compressible); ok {
+		cc.SetCompression(algo)
+	} else {
+		algo = internalpb.Compression_COMPRESSION_NONE
+	}
+
+	c.client.Store(&internalpb.ClientInfo{
+		ProtocolVersion: version,
+		ClientVersion:   req.ClientVersion,
+		Platform:        req.Platform,
+		Codec:           codecName,
+	})
+
+	// 握手回包与请求同一编码，客户端一定能解；之后的下行按协商结果
+	payload, _ := codec.Encode(env.Codec, &internalpb.HandshakeRsp{
+		Compression:       algo,
+		CompressThreshold: uint32(transport.CompressThreshold()),
+		Ok:                true,
+		ProtocolVersion:   version,
+		Codec:             codecName,
+		ServerTimeMs:      time.Now().UnixMilli(),
+	})
+	_ = c.Send(&internalpb.Envelope{
+		MsgId:     protocol.MsgHandshakeRsp,
+		SessionId: c.sessionID,
+		Payload:   payload,
+		Codec:     env.Codec,
+	})
+	gateHandshakes.With("ok").Inc()
+
+	g.logger.Debug("handshake",
+		zap.Int("msg_id", protocol.MsgHandshakeReq),
+		zap.Int64("session", c.sessionID),
+		zap.String("reason", ""),
+		zap.Any("protocol_version", version),
+		zap.String("client_version", req.ClientVersion),
+		zap.String("platform", req.Platform),
+		zap.String("codec", codecName),
+		zap.String("compression", algo.String()),
+		zap.String("trace_id", c.traceID),
+	)
+}
+
+// rejectHandshake 回复拒绝原因后断开；客户端据此提示升级
+func (g *Gate) rejectHandshake(c *Conn, env *internalpb.Envelope, req *internalpb.HandshakeReq, reason internalpb.HandshakeReject_Reason, msg string) {
+	policy := g.handshake
+	payload, _ := codec.Encode(env.Codec, &internalpb.HandshakeRsp{
+		Ok:           false,
+		ServerTimeMs: time.Now().UnixMilli(),
+		Reject: &internalpb.HandshakeReject{
+			Reason:             reason,
+			Message:            msg,
+			MinProtocolVersion: policy.MinProtocolVersion,
+			MaxProtocolVersion: policy.MaxProtocolVersion,
+			MinClientVersion:   policy.MinClientVersion,
+			UpgradeUrl:         policy.UpgradeURL,
+		},
+	})
+	c.SendAndClose(&internalpb.Envelope{
+		MsgId:     protocol.MsgHandshakeRsp,
+		SessionId: c.sessionID,
+		Payload:   payload,
+		Codec:     env.Codec,
+	})
+
+	label := strings.ToLower(reason.String())
+	gateHandshakes.With(label).Inc()
+	g.logger.Info("handshake rejected",
+		zap.Int("msg_id", protocol.MsgHandshakeReq),
+		zap.Int64("session", c.sessionID),
+		zap.String("reason", label),
+		zap.Any("protocol_version", req.ProtocolVersion),
+		zap.String("client_version", req.ClientVersion),
+		zap.String("platform", req.Platform),
+		zap.String("trace_id", c.traceID),
+	)
+}
+
+// requireHandshake 配置要求握手时，未握手的连接发 Login / Resume 直接断开
+func (g *Gate) requireHandshake(c *Conn, msgID int) bool {
+	if !g.handshake.Required || c.ClientInfo() != nil {
+		return true
+	}
+	gateHandshakes.With("missing").Inc()
+	g.logger.Warn("reject msg before handshake",
+		zap.Int("msg_id", msgID),
+		zap.Int64("session", c.sessionID),
+		zap.String("reason", "handshake_required"),
+		zap.String("trace_id", c.traceID),
+	)
+	c.Close()
+	return false
+}
+
+// normalizeCodec 上行 payload 未标记编码的按连接协商结果补上；标记了未注册编码的丢弃
+func (g *Gate) normalizeCodec(c *Conn, env *internalpb.Envelope) bool {
+	if env.Codec == "" {
+		env.Codec = c.payloadCodec()
+		return true
+	}
+	cc, err := codec.Get(env.Codec)
+	if err != nil {
+		g.logger.Warn("drop msg with unknown codec",
+			zap.Int("msg_id", int(env.MsgId)),
+			zap.Int64("session", c.sessionID),
+			zap.String("reason", "unknown_codec"),
+			zap.String("codec", env.Codec),
+			zap.String("trace_id", c.traceID),
+		)
+		return false
+	}
+	env.Codec = cc.Name()
+	if env.Codec == codec.NameProtobuf {
+		env.Codec = ""
+	}
+	return true
+}
+
+func (g *Gate) pickCompression(supported []internalpb.Compression) internalpb.Compression {
+	for _, want := range g.compressions {
+		for _, have := range supported {
+			if want == have {
+				return want
+			}
+		}
+	}
+	return internalpb.Compression_COMPRESSION_NONE
+}
+
+// pickCodec 按客户端偏好取第一个已注册的编码；payload 编码与传输无关，所有连接都支持；
+// 客户端未声明时用 protobuf
+func pickCodec(wanted []string) (string, bool) {
+	if len(wanted) == 0 {
+		return codec.NameProtobuf, true
+	}
+	for _, want := range wanted {
+		if c, err := codec.Get(want); err == nil && strings.TrimSpace(want) != "" {
+			return c.Name(), true
+		}
+	}
+	return "", false
+}
+
+// compareVersion 点分版本逐段比较数字，缺的段按 0；段内忽略非数字后缀（如 1.4.2-beta）
+func compareVersion(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		x, y := versionSegment(as, i), versionSegment(bs, i)
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionSegment(parts []string, i int) int {
+	if i >= len(parts) {
+		return 0
+	}
+	s := strings.TrimSpace(parts[i])
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(s[:end])
+	return n
+}
@@ -16,7 +16,7 @@ func (g *Gate) onHeartbeat(sessionID int64) {
 	}
 
 	now := time.Now()
-	s.LastSeen = now
+	s.setLastSeen(now)
 
 	// ⭐ 同时刷新 Conn 的活跃时间
 	if s.Conn != nil {
@@ -62,7 +62,7 @@ func (g *Gate) checkHeartbeat() {
 
 	now := time.Now()
 	for _, s := range g.sessions.snapshot() {
-		if s.State != SessionOnline || s.Conn == nil {
+		if s.State() != SessionOnline || s.Conn == nil {
 			continue
 		}
 
@@ -70,9 +70,15 @@ func (g *Gate) checkHeartbeat() {
 		last := s.Conn.lastAlive()
 		timeout := g.heartbeatTimeout
 
-		// ⭐ WS 给更宽容的窗口
-		if s.Conn.connType == ConnWS {
+		// ⭐ WS 给更宽容的窗口；KCP 面向弱网，单独配置
+		switch s.Conn.connType {
+		case ConnWS:
 			timeout += timeout / 2
+		case ConnKCP:
+			timeout = g.kcpHeartbeatTimeout
+			if timeout <= 0 {
+				timeout = 2 * g.heartbeatTimeout
+			}
 		}
 
 		if now.Sub(last) <= timeout {
@@ -108,7 +114,12 @@ func (g *Gate) gcLoop(ctx context.Context) {
 			return
 		case <-ticker.C:
 			g.checkAuthingTimeout()
-			g.sessions.GC(g.heartbeatTimeout)
+			g.ipFilter.sweep(time.Now())
+			for _, s := range g.sessions.GC(g.heartbeatTimeout) {
+				g.groups.removeSession(s.ID)
+				g.forgetServiceSession(s.ID)
+				g.deleteSessionRecord(s)
+			}
 		}
 	}
 }
@@ -0,0 +1,341 @@
+// internal/gate/ip_filter.go
+package gate
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"game-server/internal/metrics"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrIPDenied       = errors.New("ip denied")
+	ErrIPConnLimit    = errors.New("too many connections from ip")
+	ErrIPRateLimit    = errors.New("too many new connections from ip")
+	ErrInvalidIPEntry = errors.New("invalid ip or cidr")
+)
+
+var gateConnRejected = metrics.NewCounterVec("gate_conn_rejected_total",
+	"Connections rejected before NewConn by reason.", "reason")
+
+// ipIdleExpire 没有连接且超过该时间未出现的 IP 记录会被 GC
+const ipIdleExpire = time.Minute
+
+type ipState struct {
+	conns  int
+	bucket tokenBucket
+}
+
+// IPFilter 接入前的按 IP 过滤：
+//   - allow 命中：跳过 deny 和所有限额（运维 / 压测机器）
+//   - deny 命中：直接拒绝
+//   - 其余：单 IP 并发连接数上限 + 每秒新建连接数上限
+type IPFilter struct {
+	mu sync.Mutex
+
+	allow []netip.Prefix
+	deny  []netip.Prefix
+
+	maxConnsPerIP  int
+	newConnsPerSec float64
+
+	states map[netip.Addr]*ipState
+}
+
+func NewIPFilter() *IPFilter {
+	return &IPFilter{states: make(map[netip.Addr]*ipState)}
+}
+
+// SetLimits <= 0 表示不限制
+func (f *IPFilter) SetLimits(maxConnsPerIP int, newConnsPerSec float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.maxConnsPerIP = maxConnsPerIP
+	f.newConnsPerSec = newConnsPerSec
+}
+
+// SetLists 整体替换 allow / deny 列表，任一条目非法时不做任何修改
+func (f *IPFilter) SetLists(allow, deny []string) error {
+	allowList, err := parsePrefixes(allow)
+	if err != nil {
+		return err
+	}
+	denyList, err := parsePrefixes(deny)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.allow = allowList
+	f.deny = denyList
+	return nil
+}
+
+// Deny 追加一条拒绝规则，已存在时忽略
+func (f *IPFilter) Deny(entry string) error {
+	p, err := parsePrefix(entry)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, cur := range f.deny {
+		if cur == p {
+			return nil
+		}
+	}
+	f.deny = append(f.deny, p)
+	return nil
+}
+
+// Undeny 移除一条拒绝规则，返回是否存在
+func (f *IPFilter) Undeny(entry string) (bool, error) {
+	p, err := parsePrefix(entry)
+	if err != nil {
+		return false, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, cur := range f.deny {
+		if cur == p {
+			f.deny = append(f.deny[:i], f.deny[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Lists 返回当前 allow / deny 列表（字符串形式）
+func (f *IPFilter) Lists() (allow, deny []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return formatPrefixes(f.allow), formatPrefixes(f.deny)
+}
+
+// Denied 地址当前是否命中 deny（allow 优先，命中 allow 的不算）
+func (f *IPFilter) Denied(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return !containsAddr(f.allow, addr) && containsAddr(f.deny, addr)
+}
+
+// Admit 接入检查；成功时返回的 release 必须在连接关闭时调用一次
+func (f *IPFilter) Admit(addr netip.Addr, now time.Time) (func(), error) {
+	addr = addr.Unmap()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if containsAddr(f.allow, addr) {
+		return func() {}, nil
+	}
+	if containsAddr(f.deny, addr) {
+		return nil, ErrIPDenied
+	}
+
+	st := f.states[addr]
+	if st == nil {
+		st = &ipState{}
+		f.states[addr] = st
+	}
+	if f.maxConnsPerIP > 0 && st.conns >= f.maxConnsPerIP {
+		return nil, ErrIPConnLimit
+	}
+	if f.newConnsPerSec > 0 {
+		burst := int(f.newConnsPerSec)
+		if burst < 1 {
+			burst = 1
+		}
+		if !st.bucket.allow(now, burst, f.newConnsPerSec) {
+			return nil, ErrIPRateLimit
+		}
+	}
+
+	st.conns++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			f.mu.Lock()
+			st.conns--
+			f.mu.Unlock()
+		})
+	}, nil
+}
+
+// sweep 清理无连接且长期未出现的 IP，防止 map 无限增长
+func (f *IPFilter) sweep(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for addr, st := range f.states {
+		if st.conns <= 0 && now.Sub(st.bucket.last) > ipIdleExpire {
+			delete(f.states, addr)
+		}
+	}
+}
+
+func parsePrefixes(entries []string) ([]netip.Prefix, error) {
+	out := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		p, err := parsePrefix(entry)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// parsePrefix 接受单个 IP（视为 /32 或 /128）或 CIDR
+func parsePrefix(entry string) (netip.Prefix, error) {
+	entry = strings.TrimSpace(entry)
+	if strings.Contains(entry, "/") {
+		p, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("%w: %s", ErrInvalidIPEntry, entry)
+		}
+		return p.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%w: %s", ErrInvalidIPEntry, entry)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func formatPrefixes(list []netip.Prefix) []string {
+	out := make([]string, 0, len(list))
+	for _, p := range list {
+		out = append(out, p.String())
+	}
+	return out
+}
+
+func containsAddr(list []netip.Prefix, addr netip.Addr) bool {
+	for _, p := range list {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIP 从 net.Addr / "host:port" 中取出 IP
+func remoteIP(addr string) (netip.Addr, bool) {
+	if ap, err := netip.ParseAddrPort(addr); err == nil {
+		return ap.Addr(), true
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip, true
+}
+
+// ========================
+// Gate 接入
+// ========================
+
+func (g *Gate) IPFilter() *IPFilter {
+	return g.ipFilter
+}
+
+// AdmitConn 在 NewConn / NewWSConn 之前调用；拒绝时计数并返回原因
+func (g *Gate) AdmitConn(remoteAddr string) (func(), error) {
+	ip, ok := remoteIP(remoteAddr)
+	if !ok {
+		// 非 IP 地址（例如 unix socket）不做限制
+		return func() {}, nil
+	}
+	release, err := g.ipFilter.Admit(ip, time.Now())
+	if err != nil {
+		reason := rejectReason(err)
+		gateConnRejected.With(reason).Inc()
+		atomic.AddUint64(&g.connRejectedCount, 1)
+		return nil, err
+	}
+	return release, nil
+}
+
+func rejectReason(err error) string {
+	switch {
+	case errors.Is(err, ErrIPDenied):
+		return "denied"
+	case errors.Is(err, ErrIPConnLimit):
+		return "conn_limit"
+	case errors.Is(err, ErrIPRateLimit):
+		return "rate_limit"
+	default:
+		return "unknown"
+	}
+}
+
+// connRegistry 已接入的客户端连接；AdmitConn 只拦新连接，deny 生效前已经连上的靠它找出来断开
+type connRegistry struct {
+	mu    sync.Mutex
+	conns map[*Conn]struct{}
+}
+
+func newConnRegistry() *connRegistry {
+	return &connRegistry{conns: make(map[*Conn]struct{})}
+}
+
+func (r *connRegistry) add(c *Conn) {
+	r.mu.Lock()
+	r.conns[c] = struct{}{}
+	r.mu.Unlock()
+}
+
+func (r *connRegistry) remove(c *Conn) {
+	r.mu.Lock()
+	delete(r.conns, c)
+	r.mu.Unlock()
+}
+
+func (r *connRegistry) snapshot() []*Conn {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]*Conn, 0, len(r.conns))
+	for c := range r.conns {
+		out = append(out, c)
+	}
+	return out
+}
+
+// CloseDeniedConns 断开远端 IP 命中 deny 的已接入连接，返回断开的数量；
+// 新增 deny 规则或重载 allow / deny 列表后调用。会话照常转为离线，新连接会被 AdmitConn 拒绝
+func (g *Gate) CloseDeniedConns() int {
+	closed := 0
+	for _, c := range g.liveConns.snapshot() {
+		if !c.remoteIP.IsValid() || !g.ipFilter.Denied(c.remoteIP) {
+			continue
+		}
+		fields := append([]zap.Field{
+			zap.String("reason", "ip_denied"),
+			zap.String("addr", c.remoteIP.String()),
+			zap.Int64("session", c.sessionID),
+		}, connFields(c)...)
+		g.logger.Info("close denied connection", fields...)
+		c.Close()
+		closed++
+	}
+	return closed
+}
@@ -0,0 +1,151 @@
+package gate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrResumeKeyExists   = errors.New("resume key already exists")
+	ErrResumeKeyNotFound = errors.New("resume key not found")
+	ErrNoActiveResumeKey = errors.New("no active resume key")
+	ErrNoResumeKeys      = errors.New("resume keys not configured")
+)
+
+// ResumeKey 一把 resume token 签名密钥；NotBefore / NotAfter 为零值表示不限制
+type ResumeKey struct {
+	ID        string
+	Secret    []byte
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+func (k ResumeKey) activeAt(t time.Time) bool {
+	if !k.NotBefore.IsZero() && t.Before(k.NotBefore) {
+		return false
+	}
+	if !k.NotAfter.IsZero() && !t.Before(k.NotAfter) {
+		return false
+	}
+	return true
+}
+
+// KeyRing 可轮换的签名密钥环
+//
+//	签名：使用当前生效且 NotBefore 最新的 key
+//	校验：接受任意当前生效的 key
+type KeyRing struct {
+	mu   sync.RWMutex
+	keys map[string]ResumeKey
+}
+
+func NewKeyRing(keys ...ResumeKey) *KeyRing {
+	r := &KeyRing{keys: make(map[string]ResumeKey)}
+	for _, k := range keys {
+		r.keys[k.ID] = k
+	}
+	return r
+}
+
+func (r *KeyRing) Add(k ResumeKey) error {
+	if k.ID == "" || strings.Contains(k.ID, ".") || len(k.Secret) == 0 {
+		return fmt.Errorf("invalid resume key %q", k.ID)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.keys[k.ID]; exists {
+		return ErrResumeKeyExists
+	}
+	r.keys[k.ID] = k
+	return nil
+}
+
+// Retire 让 key 立即失效；用它签发的 token 之后都无法 resume
+func (r *KeyRing) Retire(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k, ok := r.keys[id]
+	if !ok {
+		return ErrResumeKeyNotFound
+	}
+	now := time.Now()
+	if k.NotAfter.IsZero() || k.NotAfter.After(now) {
+		k.NotAfter = now
+	}
+	r.keys[id] = k
+	return nil
+}
+
+// Sync 以配置为准：新增/更新配置里的 key，退役配置里已删除的 key
+func (r *KeyRing) Sync(keys []ResumeKey) (added, retired []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	wanted := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		wanted[k.ID] = struct{}{}
+		if _, exists := r.keys[k.ID]; !exists {
+			added = append(added, k.ID)
+		}
+		r.keys[k.ID] = k
+	}
+	for id, k := range r.keys {
+		if _, ok := wanted[id]; ok {
+			continue
+		}
+		if !k.activeAt(now) {
+			continue
+		}
+		k.NotAfter = now
+		r.keys[id] = k
+		retired = append(retired, id)
+	}
+	return added, retired
+}
+
+func (r *KeyRing) Get(id string) (ResumeKey, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	k, ok := r.keys[id]
+	return k, ok
+}
+
+func (r *KeyRing) signingKey(now time.Time) (ResumeKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var best ResumeKey
+	found := false
+	for _, k := range r.keys {
+		if !k.activeAt(now) {
+			continue
+		}
+		if !found || k.NotBefore.After(best.NotBefore) ||
+			(k.NotBefore.Equal(best.NotBefore) && k.ID > best.ID) {
+			best = k
+			found = true
+		}
+	}
+	if !found {
+		return ResumeKey{}, ErrNoActiveResumeKey
+	}
+	return best, nil
+}
+
+// List 按 ID 排序返回所有 key（含已退役）
+func (r *KeyRing) List() []ResumeKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := make([]ResumeKey, 0, len(r.keys))
+	for _, k := range r.keys {
+		items = append(items, k)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items
+}
@@ -2,12 +2,138 @@ package gate
 
 import (
 	"context"
+	"strconv"
 	"sync/atomic"
 	"time"
 
+	"game-server/internal/metrics"
+	"game-server/internal/protocol"
+	"game-server/internal/router"
 	"go.uber.org/zap"
 )
 
+var (
+	gateConnections = metrics.NewGaugeVec("gate_connections",
+		"Open client connections by transport.", "type")
+	gateRequests = metrics.NewCounterVec("gate_requests_total",
+		"Client envelopes received by msg_id.", "msg_id")
+	gateRequestLatency = metrics.NewHistogramVec("gate_request_duration_seconds",
+		"Time spent handling a client envelope in the gate.", nil, "msg_id")
+	gateMigrations = metrics.NewCounterVec("gate_player_migrations_total",
+		"Player migrations between game shards by result.", "result")
+	gateHandshakes = metrics.NewCounterVec("gate_handshakes_total",
+		"Client handshakes by result (ok or reject reason).", "result")
+)
+
+// msgLabel 未知路由的 msgID 合并成一个 label，避免客户端乱发撑爆时序
+func msgLabel(msgID int) string {
+	if msgID >= 0 && msgID < protocol.MsgGateEnd {
+		return strconv.Itoa(msgID)
+	}
+	if _, ok := router.GetRoute(msgID); ok {
+		return strconv.Itoa(msgID)
+	}
+	return "unknown"
+}
+
+func observeRequest(msgID int, cost time.Duration) {
+	label := msgLabel(msgID)
+	gateRequests.With(label).Inc()
+	gateRequestLatency.With(label).Observe(cost.Seconds())
+}
+
+// registerMetrics 抓取时现算的指标，直接读 Gate 内部状态
+func (g *Gate) registerMetrics() {
+	metrics.Register(gateConnections, gateRequests, gateRequestLatency, gateRateLimited, gateConnRejected, gateMigrations, gateHandshakes)
+	metrics.Register(metrics.NewGaugeFunc("gate_sessions", "Sessions by state.", []string{"state"}, func(emit metrics.EmitFunc) {
+		counts := make(map[SessionState]int)
+		for _, s := range g.sessions.snapshot() {
+			counts[s.State()]++
+		}
+		for _, st := range []SessionState{SessionInit, SessionOnline, SessionAuthing, SessionAuthenticated, SessionOffline, SessionClosed} {
+			emit(float64(counts[st]), st.String())
+		}
+	}))
+	metrics.Register(metrics.NewGaugeFunc("gate_send_queue_depth", "Queued downstream envelopes by transport.", []string{"type"}, func(emit metrics.EmitFunc) {
+		depth := make(map[ConnType]int)
+		for _, s := range g.sessions.snapshot() {
+			if c := s.Conn; c != nil {
+				depth[c.connType] += c.queueDepth()
+			}
+		}
+		for _, t := range []ConnType{ConnTCP, ConnWS, ConnKCP} {
+			emit(float64(depth[t]), t.String())
+		}
+	}))
+	metrics.Register(metrics.NewGaugeFunc("gate_remote_queue_depth", "Queued upstream envelopes by backend.", []string{"remote"}, func(emit metrics.EmitFunc) {
+		if g.servicePool != nil {
+			emit(float64(g.servicePool.queueDepth()), "service")
+		}
+		if len(g.gamePools) > 0 {
+			depth := 0
+			for _, pool := range g.gamePools {
+				depth += pool.queueDepth()
+			}
+			emit(float64(depth), "game")
+		}
+	}))
+	metrics.Register(metrics.NewCounterFunc("gate_remote_drops_total", "Upstream envelopes dropped by backend and reason.", []string{"remote", "reason"}, func(emit metrics.EmitFunc) {
+		if g.servicePool != nil {
+			busy, dropped := g.servicePool.stats()
+			emit(float64(busy), "service", "queue_full")
+			emit(float64(dropped), "service", "disconnected")
+		}
+		if len(g.gamePools) > 0 {
+			var busy, dropped uint64
+			for _, pool := range g.gamePools {
+				b, d := pool.stats()
+				busy += b
+				dropped += d
+			}
+			emit(float64(busy), "game", "queue_full")
+			emit(float64(dropped), "game", "disconnected")
+		}
+	}))
+	metrics.Register(metrics.NewGaugeFunc("gate_service_endpoint_healthy", "Service endpoint health (1 healthy, 0 not).", []string{"addr"}, func(emit metrics.EmitFunc) {
+		for _, info := range g.ServiceEndpoints() {
+			v := 0.0
+			if info.Healthy {
+				v = 1
+			}
+			emit(v, info.Addr)
+		}
+	}))
+	metrics.Register(metrics.NewCounterFunc("gate_service_failovers_total", "Sessions moved off an unhealthy service endpoint.", nil, func(emit metrics.EmitFunc) {
+		if g.servicePool != nil {
+			emit(float64(atomic.LoadUint64(&g.servicePool.failoverCount)))
+		}
+	}))
+	metrics.Register(metrics.NewCounterFunc("gate_conn_busy_total", "Downstream envelopes rejected because the conn queue was full.", nil, func(emit metrics.EmitFunc) {
+		emit(float64(atomic.LoadUint64(&g.connBusyCount)))
+	}))
+	metrics.Register(metrics.NewCounterFunc("gate_send_dropped_total", "Downstream envelopes dropped on a slow conn by delivery class.", []string{"class"}, func(emit metrics.EmitFunc) {
+		for class := DeliveryClass(0); class < deliveryClassCount; class++ {
+			emit(float64(atomic.LoadUint64(&g.sendDroppedCount[class])), class.String())
+		}
+	}))
+	metrics.Register(metrics.NewCounterFunc("gate_send_coalesced_total", "Droppable envelopes replaced by a newer one with the same msg_id.", nil, func(emit metrics.EmitFunc) {
+		emit(float64(atomic.LoadUint64(&g.sendCoalescedCount)))
+	}))
+	metrics.Register(metrics.NewCounterFunc("gate_heartbeat_timeouts_total", "Sessions dropped by heartbeat timeout.", nil, func(emit metrics.EmitFunc) {
+		emit(float64(atomic.LoadUint64(&g.heartbeatTimeoutCount)))
+	}))
+	metrics.Register(metrics.NewCounterFunc("gate_login_timeouts_total", "Connections closed before login completed.", nil, func(emit metrics.EmitFunc) {
+		emit(float64(atomic.LoadUint64(&g.loginTimeoutCount)))
+	}))
+	metrics.Register(metrics.NewCounterFunc("gate_login_rate_limited_total", "Login requests rejected by the rate limit.", nil, func(emit metrics.EmitFunc) {
+		emit(float64(atomic.LoadUint64(&g.loginRateLimitCounted)))
+	}))
+	metrics.Register(metrics.NewCounterFunc("gate_unknown_msg_total", "Client envelopes without a route.", nil, func(emit metrics.EmitFunc) {
+		emit(float64(atomic.LoadUint64(&g.unknownMsgCount)))
+	}))
+}
+
+// reportStats 每个周期打印一次增量；计数器本身保持单调，供 /metrics 抓取
 func (g *Gate) reportStats(ctx context.Context, interval time.Duration) {
 	if interval <= 0 {
 		interval = time.Minute
@@ -15,18 +141,32 @@ func (g *Gate) reportStats(ctx context.Context, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	var lastHeartbeat, lastLogin, lastLimited, lastUnknown, lastBusy, lastRejected uint64
+	var lastDropped [deliveryClassCount]uint64
+	delta := func(counter *uint64, last *uint64) uint64 {
+		cur := atomic.LoadUint64(counter)
+		d := cur - *last
+		*last = cur
+		return d
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			heartbeatTimeouts := atomic.SwapUint64(&g.heartbeatTimeoutCount, 0)
-			loginTimeouts := atomic.SwapUint64(&g.loginTimeoutCount, 0)
-			loginLimited := atomic.SwapUint64(&g.loginRateLimitCounted, 0)
-			unknownMsgs := atomic.SwapUint64(&g.unknownMsgCount, 0)
-			connBusy := atomic.SwapUint64(&g.connBusyCount, 0)
+			heartbeatTimeouts := delta(&g.heartbeatTimeoutCount, &lastHeartbeat)
+			loginTimeouts := delta(&g.loginTimeoutCount, &lastLogin)
+			loginLimited := delta(&g.loginRateLimitCounted, &lastLimited)
+			unknownMsgs := delta(&g.unknownMsgCount, &lastUnknown)
+			connBusy := delta(&g.connBusyCount, &lastBusy)
+			connRejected := delta(&g.connRejectedCount, &lastRejected)
+			var dropped [deliveryClassCount]uint64
+			for class := range dropped {
+				dropped[class] = delta(&g.sendDroppedCount[class], &lastDropped[class])
+			}
 
-			if heartbeatTimeouts == 0 && loginTimeouts == 0 && loginLimited == 0 && unknownMsgs == 0 && connBusy == 0 {
+			if heartbeatTimeouts == 0 && loginTimeouts == 0 && loginLimited == 0 && unknownMsgs == 0 && connBusy == 0 && connRejected == 0 {
 				continue
 			}
 			g.logger.Info("gate stats",
@@ -35,6 +175,10 @@ func (g *Gate) reportStats(ctx context.Context, interval time.Duration) {
 				zap.Uint64("login_rate_limited", loginLimited),
 				zap.Uint64("unknown_msg", unknownMsgs),
 				zap.Uint64("conn_busy", connBusy),
+				zap.Uint64("conn_rejected", connRejected),
+				zap.Uint64("drop_critical", dropped[DeliveryCritical]),
+				zap.Uint64("drop_normal", dropped[DeliveryNormal]),
+				zap.Uint64("drop_droppable", dropped[DeliveryDroppable]),
 			)
 		}
 	}
@@ -0,0 +1,289 @@
+// internal/gate/migrate.go
+package gate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"game-server/internal/protocol"
+	"game-server/internal/protocol/internalpb"
+	"game-server/internal/router"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrGameNotDirect       = errors.New("game shards not configured")
+	ErrAlreadyOnShard      = errors.New("player already on target shard")
+	ErrMigrationInProgress = errors.New("player migration in progress")
+	ErrMigrationFailed     = errors.New("player migration failed")
+)
+
+const (
+	// migrateRspTimeout 需大于 game 侧冻结 + 落盘的上限
+	migrateRspTimeout = 10 * time.Second
+	maxMigrateBuffer  = 1024
+)
+
+// playerMigration Gate 侧的迁移状态：迁移期间发往 game 的消息先缓存在这里
+type playerMigration struct {
+	buffer  []*internalpb.Envelope
+	dropped int
+	rsp     chan *internalpb.PlayerMigrateRsp
+}
+
+type migrationTable struct {
+	mu      sync.Mutex
+	players map[int64]*playerMigration
+}
+
+func newMigrationTable() *migrationTable {
+	return &migrationTable{players: make(map[int64]*playerMigration)}
+}
+
+// MigratePlayer 把玩家从当前 game 服迁到 target：
+//  1. Gate 开始缓存该玩家发往 game 的消息
+//  2. 源 game 冻结玩家、落盘并交出所有权，回传冻结期间收到的消息
+//  3. 更新分配（Redis），通知 service 清理选服缓存
+//  4. 目标 game 接管，按顺序重放源服暂存和 Gate 缓存的消息
+//
+// 失败时分配不变，给源服发 MigrateIn 取消迁出，缓存的消息重放回源服
+func (g *Gate) MigratePlayer(ctx context.Context, playerID int64, target string) error {
+	if g.gameSelector == nil {
+		return ErrGameNotDirect
+	}
+	if !g.gameSelector.Persistent() {
+		return router.ErrNoAssignStore
+	}
+	if _, ok := g.gameSelector.Shard(target); !ok {
+		return fmt.Errorf("%w: %s", router.ErrUnknownGameShard, target)
+	}
+	source, err := g.gameSelector.Select(ctx, playerID)
+	if err != nil {
+		return err
+	}
+	if source.ID == target {
+		return ErrAlreadyOnShard
+	}
+	srcPool, dstPool := g.gamePools[source.ID], g.gamePools[target]
+	if srcPool == nil || dstPool == nil {
+		return protocol.InternalErrRemoteNotReady
+	}
+
+	m := &playerMigration{rsp: make(chan *internalpb.PlayerMigrateRsp, 1)}
+	g.migrations.mu.Lock()
+	if g.migrations.players[playerID] != nil {
+		g.migrations.mu.Unlock()
+		return ErrMigrationInProgress
+	}
+	g.migrations.players[playerID] = m
+	g.migrations.mu.Unlock()
+
+	// ⭐ 在线玩家用 session 作为连接池的 key，迁移请求排在该玩家已发出的消息之后
+	var sessionID int64
+	if s := g.sessions.GetByPlayer(playerID); s != nil && s.State() == SessionAuthenticated {
+		sessionID = s.ID
+	}
+	key := sessionID
+	if key == 0 {
+		key = playerID
+	}
+
+	fields := []zap.Field{
+		zap.Int64("session", sessionID),
+		zap.Int64("player", playerID),
+		zap.String("from", source.ID),
+		zap.String("to", target),
+	}
+
+	payload, _ := proto.Marshal(&internalpb.PlayerMigrateReq{PlayerId: playerID, TargetShard: target})
+	if err := srcPool.Send(key, &internalpb.Envelope{
+		MsgId:     protocol.MsgPlayerMigrateReq,
+		SessionId: sessionID,
+		PlayerId:  playerID,
+		Payload:   payload,
+	}); err != nil {
+		g.finishMigration(playerID, source.ID, nil)
+		gateMigrations.With("error").Inc()
+		return err
+	}
+
+	timer := time.NewTimer(migrateRspTimeout)
+	defer timer.Stop()
+
+	var rsp *internalpb.PlayerMigrateRsp
+	select {
+	case rsp = <-m.rsp:
+	case <-timer.C:
+		err = fmt.Errorf("%w: wait %s timeout", ErrMigrationFailed, source.ID)
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err == nil && !rsp.Ok {
+		err = fmt.Errorf("%w: %s", ErrMigrationFailed, rsp.Error)
+	}
+	if err != nil {
+		g.logger.Warn("player migrate failed", append(fields, zap.String("reason", err.Error()))...)
+		// ⭐ 超时 / 取消时源服可能仍在迁出：MigrateIn 让它放弃迁出或清掉墓碑，玩家留在源服；
+		// 源服之后迟到的响应里暂存的消息由 onMigrateRsp 重放
+		g.sendToShard(source.ID, key, migrateInEnvelope(sessionID, playerID))
+		g.finishMigration(playerID, source.ID, rsp.GetPending())
+		gateMigrations.With("error").Inc()
+		return err
+	}
+
+	// 所有权已交出，分配写不进去时只能交回源服（墓碑由 MigrateIn 清理）
+	next := target
+	if err = g.gameSelector.Assign(ctx, playerID, target); err != nil {
+		g.logger.Warn("player migrate assign failed", append(fields, zap.String("reason", err.Error()))...)
+		next = source.ID
+	} else {
+		g.broadcastShardChanged(playerID, target)
+	}
+
+	g.sendToShard(next, key, migrateInEnvelope(sessionID, playerID))
+	replayed := g.finishMigration(playerID, next, rsp.Pending)
+
+	if err != nil {
+		gateMigrations.With("error").Inc()
+		return fmt.Errorf("%w: %v", ErrMigrationFailed, err)
+	}
+	gateMigrations.With("ok").Inc()
+	g.logger.Info("player migrated", append(fields,
+		zap.String("reason", "migrate"),
+		zap.Any("resident", rsp.Resident),
+		zap.Int("replayed", replayed),
+	)...)
+	return nil
+}
+
+// holdForMigration 玩家迁移中时缓存发往 game 的消息，返回 true 表示已接管
+func (g *Gate) holdForMigration(env *internalpb.Envelope) bool {
+	if env.PlayerId == 0 {
+		return false
+	}
+	g.migrations.mu.Lock()
+	defer g.migrations.mu.Unlock()
+
+	m := g.migrations.players[env.PlayerId]
+	if m == nil {
+		return false
+	}
+	if len(m.buffer) >= maxMigrateBuffer {
+		m.dropped++
+		return true
+	}
+	m.buffer = append(m.buffer, env)
+	return true
+}
+
+// onMigrateRsp 源 game 的迁移响应
+func (g *Gate) onMigrateRsp(payload []byte) {
+	var rsp internalpb.PlayerMigrateRsp
+	if err := proto.Unmarshal(payload, &rsp); err != nil {
+		return
+	}
+	g.migrations.mu.Lock()
+	m := g.migrations.players[rsp.PlayerId]
+	g.migrations.mu.Unlock()
+	if m == nil {
+		// 已超时放弃的迁移：源服已收到 MigrateIn 留下玩家，暂存的消息按当前分配重放
+		g.logger.Warn("late migrate rsp",
+			zap.Int("msg_id", protocol.MsgPlayerMigrateRsp),
+			zap.Int64("player", rsp.PlayerId),
+			zap.String("reason", "no_migration"),
+			zap.Int("pending", len(rsp.Pending)),
+		)
+		for _, env := range rsp.Pending {
+			g.sendToGame(env)
+		}
+		return
+	}
+	select {
+	case m.rsp <- &rsp:
+	default:
+	}
+}
+
+// finishMigration 先重放源服暂存的消息，再把 Gate 缓存排空后解除迁移状态；
+// 排空期间新到的消息继续进缓存，保证顺序
+func (g *Gate) finishMigration(playerID int64, shardID string, pending []*internalpb.Envelope) int {
+	replayed := 0
+	batch := pending
+	for {
+		for _, env := range batch {
+			key := env.SessionId
+			if key == 0 {
+				key = playerID
+			}
+			g.sendToShard(shardID, key, env)
+		}
+		replayed += len(batch)
+
+		g.migrations.mu.Lock()
+		m := g.migrations.players[playerID]
+		if m == nil {
+			g.migrations.mu.Unlock()
+			return replayed
+		}
+		if len(m.buffer) == 0 {
+			delete(g.migrations.players, playerID)
+			dropped := m.dropped
+			g.migrations.mu.Unlock()
+			if dropped > 0 {
+				g.logger.Warn("migrate buffer overflow",
+					zap.Int64("player", playerID),
+					zap.String("reason", "buffer_full"),
+					zap.Int("dropped", dropped),
+				)
+			}
+			return replayed
+		}
+		batch = m.buffer
+		m.buffer = nil
+		g.migrations.mu.Unlock()
+	}
+}
+
+func migrateInEnvelope(sessionID, playerID int64) *internalpb.Envelope {
+	payload, _ := proto.Marshal(&internalpb.PlayerMigrateIn{PlayerId: playerID})
+	return &internalpb.Envelope{
+		MsgId:     protocol.MsgPlayerMigrateIn,
+		SessionId: sessionID,
+		PlayerId:  playerID,
+		Payload:   payload,
+	}
+}
+
+func (g *Gate) sendToShard(shardID string, key int64, env *internalpb.Envelope) {
+	pool := g.gamePools[shardID]
+	err := protocol.InternalErrRemoteNotReady
+	if pool != nil {
+		err = pool.Send(key, env)
+	}
+	if err != nil {
+		g.logger.Warn("send to game failed",
+			zap.String("reason", err.Error()),
+			zap.Int("msg_id", int(env.MsgId)),
+			zap.Int64("session", env.SessionId),
+			zap.Int64("player", env.PlayerId),
+			zap.String("shard", shardID),
+		)
+	}
+}
+
+// broadcastShardChanged 所有 service 端点都可能缓存了该玩家的选服结果
+func (g *Gate) broadcastShardChanged(playerID int64, shardID string) {
+	if g.servicePool == nil {
+		return
+	}
+	payload, _ := proto.Marshal(&internalpb.PlayerShardChanged{PlayerId: playerID, Shard: shardID})
+	g.servicePool.broadcast(playerID, &internalpb.Envelope{
+		MsgId:    protocol.MsgPlayerShardChanged,
+		PlayerId: playerID,
+		Payload:  payload,
+	})
+}
@@ -15,6 +15,8 @@ func (g *Gate) notifyPlayerResume(s *Session) {
 		MsgId:     protocol.MsgPlayerResumeReq,
 		SessionId: s.ID,
 		PlayerId:  s.PlayerID,
+		Client:    s.Conn.ClientInfo(),
+		Codec:     s.Conn.payloadCodec(),
 	}
 
 	g.sendToGame(env)
@@ -37,6 +39,9 @@ func (g *Gate) notifyPlayerOffline(s *Session) {
 	}
 
 	g.sendToGame(env)
+	if g.gameSelector != nil {
+		g.gameSelector.Forget(s.PlayerID)
+	}
 
 	fields := append(sessionFields(s), zap.Int("msg_id", protocol.MsgPlayerOfflineNotify))
 	fields = append(fields, zap.String("reason", "player_offline"))
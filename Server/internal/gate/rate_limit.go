@@ -0,0 +1,137 @@
+// internal/gate/rate_limit.go
+package gate
+
+import (
+	"time"
+
+	"game-server/internal/metrics"
+	"game-server/internal/protocol"
+	"game-server/internal/protocol/internalpb"
+	"go.uber.org/zap"
+)
+
+var gateRateLimited = metrics.NewCounterVec("gate_rate_limited_total",
+	"Client envelopes rejected by the per-session rate limiter.", "rule")
+
+// RateLimitRule 一个 msgID 区间 [MsgBegin, MsgEnd) 共享一个令牌桶
+type RateLimitRule struct {
+	Name     string
+	MsgBegin int
+	MsgEnd   int
+	Burst    int     // 桶容量
+	Rate     float64 // 每秒补充的令牌数
+}
+
+func (r RateLimitRule) match(msgID int) bool {
+	return msgID >= r.MsgBegin && msgID < r.MsgEnd
+}
+
+func defaultRateLimitRules() []RateLimitRule {
+	return []RateLimitRule{
+		{Name: "login", MsgBegin: protocol.MsgLoginBegin, MsgEnd: protocol.MsgLoginEnd, Burst: 5, Rate: 1},
+		{Name: "chat", MsgBegin: protocol.MsgChatBegin, MsgEnd: protocol.MsgChatEnd, Burst: 10, Rate: 2},
+		{Name: "game", MsgBegin: protocol.MsgGameBegin, MsgEnd: protocol.MsgGameEnd, Burst: 60, Rate: 30},
+	}
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) allow(now time.Time, burst int, rate float64) bool {
+	if b.last.IsZero() {
+		b.tokens = float64(burst)
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * rate
+		if b.tokens > float64(burst) {
+			b.tokens = float64(burst)
+		}
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// SetRateLimits 替换限流规则；kickCount > 0 时 kickWindow 内违规达到次数即踢下线
+func (g *Gate) SetRateLimits(rules []RateLimitRule, kickCount int, kickWindow time.Duration) {
+	if rules != nil {
+		g.rateLimits = rules
+	}
+	if kickCount > 0 {
+		g.rateLimitKickCount = kickCount
+	}
+	if kickWindow > 0 {
+		g.rateLimitKickWindow = kickWindow
+	}
+}
+
+// allowMessage 在连接读协程里调用；resume 换连接时旧连接的读协程可能还没退出，
+// 两个协程会同时扣同一个 Session 的桶，所以持 rateMu
+func (g *Gate) allowMessage(s *Session, msgID int) (RateLimitRule, bool) {
+	rules := g.rateLimits
+	for i, rule := range rules {
+		if !rule.match(msgID) {
+			continue
+		}
+		if rule.Burst <= 0 || rule.Rate <= 0 {
+			return rule, true
+		}
+		s.rateMu.Lock()
+		defer s.rateMu.Unlock()
+		if len(s.rateBuckets) != len(rules) {
+			s.rateBuckets = make([]tokenBucket, len(rules))
+		}
+		return rule, s.rateBuckets[i].allow(time.Now(), rule.Burst, rule.Rate)
+	}
+	return RateLimitRule{}, true
+}
+
+// countViolation 与 allowLogin 一样按固定窗口计数：偶发超限的长连接不会因为累计到阈值被误踢
+func (g *Gate) countViolation(s *Session, now time.Time) int {
+	s.rateMu.Lock()
+	defer s.rateMu.Unlock()
+	if s.RateLimitWindowStart.IsZero() || now.Sub(s.RateLimitWindowStart) > g.rateLimitKickWindow {
+		s.RateLimitWindowStart = now
+		s.RateLimitViolations = 0
+	}
+	s.RateLimitViolations++
+	return s.RateLimitViolations
+}
+
+func (g *Gate) onRateLimited(s *Session, c *Conn, rule RateLimitRule, msgID int) {
+	violations := g.countViolation(s, time.Now())
+	gateRateLimited.With(rule.Name).Inc()
+
+	g.logger.Warn("msg rate limited",
+		zap.String("reason", "rate_limited"),
+		zap.String("rule", rule.Name),
+		zap.Int("violations", violations),
+		zap.Int("msg_id", msgID),
+		zap.Int64("session", s.ID),
+		zap.Int64("player", s.PlayerID),
+		zap.String("trace_id", c.traceID),
+	)
+	g.sendErrorRsp(c, s, protocol.ErrRateLimited, "rate limited: "+rule.Name)
+
+	if g.rateLimitKickCount > 0 && violations >= g.rateLimitKickCount {
+		g.onSessionOffline(s, "rate limit exceeded")
+	}
+}
+
+func (g *Gate) sendErrorRsp(c *Conn, s *Session, code protocol.ErrorCode, msg string) {
+	data, codecName := c.encodePayload(&internalpb.ErrorRsp{
+		Code:    int32(code),
+		Message: msg,
+	})
+	_ = c.Send(&internalpb.Envelope{
+		MsgId:     protocol.MsgErrorRsp,
+		SessionId: s.ID,
+		PlayerId:  s.PlayerID,
+		Payload:   data,
+		Codec:     codecName,
+	})
+}
@@ -0,0 +1,56 @@
+package gate
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"game-server/internal/protocol"
+	"game-server/internal/protocol/codec"
+	"game-server/internal/protocol/internalpb"
+)
+
+// resume 之后旧连接读协程里还在处理的消息与新连接并发扣同一个会话的桶；用 go test -race 跑
+func TestRateLimitSharedAcrossResumedConns(t *testing.T) {
+	g := newTestGate(t)
+	g.SetRateLimits([]RateLimitRule{
+		{Name: "chat", MsgBegin: protocol.MsgChatBegin, MsgEnd: protocol.MsgChatEnd, Burst: 1, Rate: 1e-9},
+	}, 1<<30, time.Hour)
+
+	c1, _ := startConn(g, newPipeConn())
+	s, err := g.createSessionForConn(c1)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	s.SetState(SessionAuthenticated)
+
+	c2, _ := startConn(g, newPipeConn())
+	t.Cleanup(c2.Close)
+	payload, _ := codec.Encode("", &internalpb.ResumeReq{SessionId: s.ID, Token: s.Token})
+	g.handleResume(c2, &internalpb.Envelope{MsgId: protocol.MsgResumeReq, Payload: payload})
+	if s.currentConn() != c2 {
+		t.Fatal("resume did not bind the new conn")
+	}
+
+	// 每次超限回一条 critical 的 ErrorRsp；测试不读下行，条数留在队列容量以内，免得连接被判忙断开
+	perConn := g.delivery.CriticalQueue / 2
+	var wg sync.WaitGroup
+	for _, c := range []*Conn{c1, c2} {
+		wg.Add(1)
+		go func(c *Conn) {
+			defer wg.Done()
+			for i := 0; i < perConn; i++ {
+				g.OnEnvelope(c, &internalpb.Envelope{MsgId: protocol.MsgChatSendReq, SessionId: s.ID})
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	// 桶里只有一个令牌：其余每条都算一次违规，一次也不能丢
+	s.rateMu.Lock()
+	violations := s.RateLimitViolations
+	s.rateMu.Unlock()
+	if violations != 2*perConn-1 {
+		t.Fatalf("violations = %d, want %d", violations, 2*perConn-1)
+	}
+}
@@ -2,8 +2,8 @@ package gate
 
 import (
 	"context"
-	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"game-server/internal/protocol"
@@ -30,12 +30,28 @@ type remoteClient struct {
 	busyCount        uint64
 	dropCount        uint64
 	lastConnectedAt  time.Time
+
+	// 每次连上后首先发送（GateRegister）
+	hello *internalpb.Envelope
+
+	// ⭐ 健康检查：Ping / Pong 往返
+	pingInterval time.Duration
+	lastPingAt   int64 // unix nano
+	lastPongAt   int64 // unix nano
+	rtt          int64 // 最近一次往返耗时（ns）
 }
 
 type remoteClientPool struct {
 	clients []*remoteClient
 }
 
+// SetHello 设置连接建立后的第一条消息，需在 Start 之前调用
+func (p *remoteClientPool) SetHello(env *internalpb.Envelope) {
+	for _, client := range p.clients {
+		client.hello = env
+	}
+}
+
 func newRemoteClient(name, addr string, logger *zap.Logger, onEnvelope func(env *internalpb.Envelope), options transport.ConnOptions, retryMax int, retryBackoff time.Duration) *remoteClient {
 	return &remoteClient{
 		name:       name,
@@ -48,6 +64,7 @@ func newRemoteClient(name, addr string, logger *zap.Logger, onEnvelope func(env
 		connOptions:      options,
 		sendRetryMax:     retryMax,
 		sendRetryBackoff: retryBackoff,
+		pingInterval:     30 * time.Second,
 	}
 }
 
@@ -62,6 +79,23 @@ func newRemoteClientPool(name, addr string, logger *zap.Logger, onEnvelope func(
 	return &remoteClientPool{clients: clients}
 }
 
+func (p *remoteClientPool) queueDepth() int {
+	depth := 0
+	for _, client := range p.clients {
+		depth += len(client.sendCh)
+	}
+	return depth
+}
+
+// stats 返回累计的队列满次数和断线丢弃次数
+func (p *remoteClientPool) stats() (busy, dropped uint64) {
+	for _, client := range p.clients {
+		busy += atomic.LoadUint64(&client.busyCount)
+		dropped += atomic.LoadUint64(&client.dropCount)
+	}
+	return busy, dropped
+}
+
 func (p *remoteClientPool) Start(ctx context.Context) {
 	for _, client := range p.clients {
 		client.Start(ctx)
@@ -79,6 +113,16 @@ func (p *remoteClientPool) Send(sessionID int64, env *internalpb.Envelope) error
 	return p.clients[index].Send(env)
 }
 
+// SetPingInterval 设置 Ping 间隔，需在 Start 之前调用
+func (p *remoteClientPool) SetPingInterval(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	for _, client := range p.clients {
+		client.pingInterval = interval
+	}
+}
+
 func (c *remoteClient) Start(ctx context.Context) {
 	go c.connectLoop(ctx)
 	go c.writeLoop(ctx)
@@ -92,7 +136,7 @@ func (c *remoteClient) Send(env *internalpb.Envelope) error {
 			return nil
 		default:
 			if attempt == c.sendRetryMax {
-				c.busyCount++
+				atomic.AddUint64(&c.busyCount, 1)
 				c.logger.Warn("remote send queue full",
 					zap.String("remote", c.name),
 					zap.String("addr", c.addr),
@@ -113,34 +157,43 @@ func (c *remoteClient) Send(env *internalpb.Envelope) error {
 // Writer Loop（唯一写 socket 的地方）
 // ========================
 func (c *remoteClient) writeLoop(ctx context.Context) {
+	co := transport.NewCoalescer[*internalpb.Envelope](c.connOptions)
 	for {
 		select {
 		case <-ctx.Done():
 			return
 
 		case env := <-c.sendCh:
+			// ⭐ 已排队的消息一起写，一次 Flush
+			batch := co.Collect(c.sendCh, env, ctx.Done())
+
 			c.mu.RLock()
 			conn := c.conn
 			c.mu.RUnlock()
 
 			if conn == nil {
 				// 远端未连接，直接丢弃 or 记录
-				c.dropCount++
-				c.logger.Warn("remote disconnected, drop message",
-					zap.String("remote", c.name),
-					zap.String("addr", c.addr),
-					zap.Int("msg_id", int(env.MsgId)),
-					zap.Int64("session", env.SessionId),
-					zap.Int64("player", env.PlayerId),
-					zap.String("reason", "remote_disconnected"),
-				)
+				for _, env := range batch {
+					atomic.AddUint64(&c.dropCount, 1)
+					c.logger.Warn("remote disconnected, drop message",
+						zap.String("remote", c.name),
+						zap.String("addr", c.addr),
+						zap.Int("msg_id", int(env.MsgId)),
+						zap.Int64("session", env.SessionId),
+						zap.Int64("player", env.PlayerId),
+						zap.String("reason", "remote_disconnected"),
+					)
+				}
 				continue
 			}
 
-			if err := conn.WriteEnvelope(env); err != nil {
+			if unsent, err := c.writeBatch(conn, batch); err != nil {
+				atomic.AddUint64(&c.dropCount, uint64(unsent))
 				c.logger.Warn("write to remote failed",
 					zap.String("remote", c.name),
 					zap.String("addr", c.addr),
+					zap.Int("batch", len(batch)),
+					zap.Int("dropped", unsent),
 					zap.Err("error", err),
 				)
 			}
@@ -148,6 +201,11 @@ func (c *remoteClient) writeLoop(ctx context.Context) {
 	}
 }
 
+// writeBatch 逐条写入缓冲后统一 Flush；失败时返回未能写出的消息数，计入 dropCount
+func (c *remoteClient) writeBatch(conn *transport.BufferedConn, batch []*internalpb.Envelope) (int, error) {
+	return transport.WriteBatch(conn, batch)
+}
+
 func (c *remoteClient) connectLoop(ctx context.Context) {
 	backoff := time.Second
 	for {
@@ -157,8 +215,7 @@ func (c *remoteClient) connectLoop(ctx context.Context) {
 		default:
 		}
 
-		dialer := net.Dialer{Timeout: 3 * time.Second, KeepAlive: c.connOptions.KeepAlive}
-		conn, err := dialer.Dial("tcp", c.addr)
+		conn, err := transport.Dial(c.addr, 3*time.Second, c.connOptions)
 		if err != nil {
 			c.logger.Warn("remote dial failed",
 				zap.String("reason", err.Error()),
@@ -177,10 +234,21 @@ func (c *remoteClient) connectLoop(ctx context.Context) {
 			continue
 		}
 
+		bc := transport.NewBufferedConnWithOptions(conn, c.connOptions)
+		if c.hello != nil {
+			if err := bc.WriteEnvelope(c.hello); err != nil {
+				_ = bc.Close()
+				time.Sleep(backoff)
+				continue
+			}
+		}
+
 		c.mu.Lock()
-		c.conn = transport.NewBufferedConnWithOptions(conn, c.connOptions)
+		c.conn = bc
 		c.mu.Unlock()
 		c.lastConnectedAt = time.Now()
+		// 连上立即探测一次，不必等第一个 Ping 周期才判定健康
+		c.ping(bc)
 		c.logger.Info("remote connected",
 			zap.String("addr", c.addr),
 			zap.String("remote", c.name),
@@ -194,19 +262,24 @@ func (c *remoteClient) connectLoop(ctx context.Context) {
 		backoff = time.Second
 
 		for {
-			env, err := c.conn.ReadEnvelope()
+			env, err := bc.ReadEnvelope()
 			if err != nil {
 				break
 			}
+			if env.MsgId == protocol.MsgServicePong {
+				c.onPong()
+				continue
+			}
 			if c.onEnvelope != nil {
 				c.onEnvelope(env)
 			}
 		}
 
-		_ = c.conn.Close()
+		_ = bc.Close()
 		c.mu.Lock()
 		c.conn = nil
 		c.mu.Unlock()
+		atomic.StoreInt64(&c.lastPongAt, 0)
 		c.logger.Warn("remote disconnected",
 			zap.String("remote", c.name),
 			zap.Duration("connected_duration", time.Since(c.lastConnectedAt)),
@@ -221,7 +294,7 @@ func (c *remoteClient) connectLoop(ctx context.Context) {
 }
 
 func (c *remoteClient) heartbeatLoop(ctx context.Context) {
-	ticker := time.NewTicker(30 * time.Second)
+	ticker := time.NewTicker(c.pingInterval)
 	defer ticker.Stop()
 
 	for {
@@ -237,9 +310,34 @@ func (c *remoteClient) heartbeatLoop(ctx context.Context) {
 				continue
 			}
 
-			_ = conn.WriteEnvelope(&internalpb.Envelope{
-				MsgId: protocol.MsgServicePing,
-			})
+			c.ping(conn)
 		}
 	}
 }
+
+func (c *remoteClient) ping(conn *transport.BufferedConn) {
+	atomic.StoreInt64(&c.lastPingAt, time.Now().UnixNano())
+	_ = conn.WriteEnvelope(&internalpb.Envelope{
+		MsgId: protocol.MsgServicePing,
+	})
+}
+
+func (c *remoteClient) onPong() {
+	now := time.Now().UnixNano()
+	atomic.StoreInt64(&c.lastPongAt, now)
+	if sent := atomic.LoadInt64(&c.lastPingAt); sent > 0 {
+		atomic.StoreInt64(&c.rtt, now-sent)
+	}
+}
+
+// healthy 已连接且最近 timeout 内收到过 Pong
+func (c *remoteClient) healthy(now time.Time, timeout time.Duration) bool {
+	c.mu.RLock()
+	connected := c.conn != nil
+	c.mu.RUnlock()
+	if !connected {
+		return false
+	}
+	pong := atomic.LoadInt64(&c.lastPongAt)
+	return pong > 0 && now.Sub(time.Unix(0, pong)) <= timeout
+}
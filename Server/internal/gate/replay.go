@@ -0,0 +1,151 @@
+package gate
+
+import (
+	"game-server/internal/protocol"
+	"game-server/internal/protocol/internalpb"
+	"go.uber.org/zap"
+)
+
+const defaultReplayBufferSize = 256
+
+// replayBuffer 会话离线期间的下行补发缓冲（有界环形队列）
+//
+//	baseSeq：进入离线时已经发给客户端的最大序号
+//	客户端 resume 带上 last_seq，只有 last_seq >= baseSeq 且没有溢出时才能无缝补发
+type replayBuffer struct {
+	items    []*internalpb.Envelope
+	start    int
+	size     int
+	baseSeq  uint64
+	overflow bool
+}
+
+func newReplayBuffer(capacity int, baseSeq uint64) *replayBuffer {
+	if capacity <= 0 {
+		capacity = defaultReplayBufferSize
+	}
+	return &replayBuffer{
+		items:   make([]*internalpb.Envelope, capacity),
+		baseSeq: baseSeq,
+	}
+}
+
+func (b *replayBuffer) push(env *internalpb.Envelope) {
+	if b.size == len(b.items) {
+		b.items[b.start] = nil
+		b.start = (b.start + 1) % len(b.items)
+		b.size--
+		b.overflow = true
+	}
+	b.items[(b.start+b.size)%len(b.items)] = env
+	b.size++
+}
+
+// since 返回 seq > lastSeq 的缓冲消息；complete=false 表示中间有缺口，需要全量重载
+func (b *replayBuffer) since(lastSeq uint64) (items []*internalpb.Envelope, complete bool) {
+	if b.overflow || lastSeq < b.baseSeq {
+		return nil, false
+	}
+	for i := 0; i < b.size; i++ {
+		env := b.items[(b.start+i)%len(b.items)]
+		if env.Seq > lastSeq {
+			items = append(items, env)
+		}
+	}
+	return items, true
+}
+
+// isSequenced Gate 控制消息（心跳 / resume / session init）不编号也不补发
+func isSequenced(msgID int) bool {
+	return msgID >= protocol.MsgGateEnd
+}
+
+// SetReplayBufferSize 设置每个离线会话最多缓存的下行消息数
+func (g *Gate) SetReplayBufferSize(size int) {
+	if size > 0 {
+		g.replayBufferSize = size
+	}
+}
+
+// sendSequenced 给下行业务消息编号；会话离线时写入补发缓冲
+//
+//	Droppable 消息允许被覆盖 / 丢弃，不编号也不补发；
+//	编号消息没能入队时断开连接，客户端 resume 时 last_seq 落后于 sendSeq，走全量重载
+func (g *Gate) sendSequenced(s *Session, env *internalpb.Envelope) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	conn := s.Conn
+	if conn == nil && s.State() != SessionOffline {
+		return ErrSessionNotFound
+	}
+
+	if g.deliveryClass(env.MsgId) == DeliveryDroppable {
+		env.Seq = 0
+		if conn == nil {
+			return nil
+		}
+		return conn.Send(env)
+	}
+
+	s.sendSeq++
+	env.Seq = s.sendSeq
+
+	if conn == nil {
+		if s.replay == nil {
+			s.replay = newReplayBuffer(g.replayBufferSize, s.sendSeq-1)
+		}
+		s.replay.push(env)
+		return nil
+	}
+	if err := conn.Send(env); err != nil {
+		g.logger.Warn("sequenced message dropped, closing conn for full reload",
+			zap.String("reason", "seq_gap"),
+			zap.Int("msg_id", int(env.MsgId)),
+			zap.Int64("session", s.ID),
+			zap.Int64("player", s.PlayerID),
+			zap.String("trace_id", conn.traceID),
+		)
+		conn.Close()
+		return err
+	}
+	return nil
+}
+
+// resumeDownstream 绑定新连接、回 ResumeRsp 并补发离线期间的消息，返回被替换下来的旧连接
+//
+//	全程持有 sendMu，保证补发消息先于之后的新消息到达客户端
+func (g *Gate) resumeDownstream(s *Session, c *Conn, lastSeq uint64, adopted bool) (old *Conn, fullReload bool, replayed int) {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	old = s.Conn
+	s.Conn = c
+	c.sessionID = s.ID
+
+	var items []*internalpb.Envelope
+	complete := true
+	switch {
+	case adopted:
+		// 其他 Gate 的缓冲带不过来：序号从客户端位置续上，要求全量重载
+		if lastSeq > s.sendSeq {
+			s.sendSeq = lastSeq
+		}
+		complete = false
+	case s.replay != nil:
+		items, complete = s.replay.since(lastSeq)
+	default:
+		complete = lastSeq >= s.sendSeq
+	}
+	s.replay = nil
+
+	g.sendResumeRsp(c, true, "", !complete)
+	for i, env := range items {
+		if err := c.Send(env); err != nil {
+			// 补发没能入队同样是缺口，断开让客户端下次 resume 全量重载
+			c.Close()
+			return old, !complete, i
+		}
+	}
+	return old, !complete, len(items)
+}
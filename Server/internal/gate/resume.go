@@ -4,10 +4,10 @@ import (
 	"time"
 
 	"game-server/internal/protocol"
+	"game-server/internal/protocol/codec"
 	"game-server/internal/protocol/internalpb"
 
 	"go.uber.org/zap"
-	"google.golang.org/protobuf/proto"
 )
 
 func (g *Gate) handleResume(c *Conn, env *internalpb.Envelope) {
@@ -26,14 +26,20 @@ func (g *Gate) handleResume(c *Conn, env *internalpb.Envelope) {
 	}
 
 	var req internalpb.ResumeReq
-	if err := proto.Unmarshal(env.Payload, &req); err != nil {
+	if err := codec.Decode(env, &req); err != nil {
 		c.Close()
 		return
 	}
 
 	s := g.sessions.Get(req.SessionId)
+	adopted := false
+	if s == nil {
+		// ⭐ 本地没有：尝试从共享目录接管（Gate 重启 / 跨 Gate）
+		s = g.adoptSession(req.SessionId, req.Token)
+		adopted = s != nil
+	}
 	if s == nil || !g.verifyToken(s, req.Token) {
-		g.sendResumeRsp(c, false, "invalid session")
+		g.sendResumeRsp(c, false, "invalid session", false)
 		fields := append(sessionFields(s),
 			zap.Int("msg_id", protocol.MsgResumeReq),
 			zap.String("reason", "invalid_session"),
@@ -45,9 +51,9 @@ func (g *Gate) handleResume(c *Conn, env *internalpb.Envelope) {
 	}
 
 	// ===== 1️⃣ 状态校验 =====
-	switch s.State {
+	switch s.State() {
 	case SessionAuthing:
-		g.sendResumeRsp(c, false, "session authing")
+		g.sendResumeRsp(c, false, "session authing", false)
 		fields := append(sessionFields(s),
 			zap.Int("msg_id", protocol.MsgResumeReq),
 			zap.String("reason", "session_authing"),
@@ -57,7 +63,7 @@ func (g *Gate) handleResume(c *Conn, env *internalpb.Envelope) {
 		c.Close()
 		return
 	case SessionClosed:
-		g.sendResumeRsp(c, false, "session closed")
+		g.sendResumeRsp(c, false, "session closed", false)
 		fields := append(sessionFields(s),
 			zap.Int("msg_id", protocol.MsgResumeReq),
 			zap.String("reason", "session_closed"),
@@ -68,8 +74,21 @@ func (g *Gate) handleResume(c *Conn, env *internalpb.Envelope) {
 		return
 	}
 
-	// ===== 2️⃣ 踢掉旧 Conn（如果存在）=====
-	if old := s.Conn; old != nil && old != c {
+	// ===== 2️⃣ 状态 =====
+	s.setLastSeen(time.Now())
+
+	if s.PlayerID != 0 {
+		s.SetState(SessionAuthenticated)
+	} else {
+		s.SetState(SessionOnline)
+	}
+
+	// ===== 3️⃣ 绑定新 Conn + 回包 + 补发（同一把锁内完成）=====
+	old, fullReload, replayed := g.resumeDownstream(s, c, req.LastSeq, adopted)
+
+	// ===== 4️⃣ 踢掉旧 Conn（如果存在）=====
+	// 新连接已经绑上再关旧连接：旧连接读协程退出时 onConnClose 发现会话不再指向它，不会把会话置为离线
+	if old != nil && old != c {
 		fields := append(sessionFields(s),
 			zap.Int("msg_id", protocol.MsgResumeReq),
 			zap.String("reason", "resume_replace_conn"),
@@ -79,23 +98,9 @@ func (g *Gate) handleResume(c *Conn, env *internalpb.Envelope) {
 		old.Close()
 	}
 
-	// ===== 3️⃣ 绑定新 Conn =====
-	s.Conn = c
-	s.LastSeen = time.Now()
-
-	if s.PlayerID != 0 {
-		s.State = SessionAuthenticated
-	} else {
-		s.State = SessionOnline
-	}
-
-	// ===== 4️⃣ 最后一步才写入 =====
-	c.sessionID = s.ID
-
-	// ===== 5️⃣ 回包 =====
-	g.sendResumeRsp(c, true, "")
+	g.saveSessionRecord(s)
 
-	// ===== 6️⃣ 通知 Game =====
+	// ===== 5️⃣ 通知 Game =====
 	if s.PlayerID != 0 {
 		g.notifyPlayerResume(s)
 	}
@@ -103,25 +108,30 @@ func (g *Gate) handleResume(c *Conn, env *internalpb.Envelope) {
 	fields := append(sessionFields(s),
 		zap.Int("msg_id", protocol.MsgResumeReq),
 		zap.String("reason", "resume_success"),
+		zap.Uint64("last_seq", req.LastSeq),
+		zap.Int("replayed", replayed),
+		zap.Any("full_reload", fullReload),
 	)
 	fields = append(fields, connFields(c)...)
 	g.logger.Info("player resume", fields...)
 
-	g.unknownMsgKickCount = 0
+	s.UnknownMsgCount = 0
 }
 
-func (g *Gate) sendResumeRsp(c *Conn, ok bool, reason string) {
+func (g *Gate) sendResumeRsp(c *Conn, ok bool, reason string, fullReload bool) {
 	rsp := &internalpb.ResumeRsp{
-		Ok:     ok,
-		Reason: reason,
+		Ok:         ok,
+		Reason:     reason,
+		FullReload: fullReload,
 	}
 
-	payload, _ := proto.Marshal(rsp)
+	payload, codecName := c.encodePayload(rsp)
 
 	env := &internalpb.Envelope{
 		MsgId:     protocol.MsgResumeRsp,
 		SessionId: c.sessionID,
 		Payload:   payload,
+		Codec:     codecName,
 	}
 
 	_ = c.Send(env)
@@ -0,0 +1,148 @@
+package gate
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"game-server/internal/protocol"
+	"game-server/internal/protocol/codec"
+	"game-server/internal/protocol/internalpb"
+)
+
+var errPipeClosed = errors.New("pipe closed")
+
+// pipeConn 内存里的 transport.Conn：in 喂给 ReadEnvelope，WriteEnvelope 写到 out
+type pipeConn struct {
+	in   chan *internalpb.Envelope
+	out  chan *internalpb.Envelope
+	done chan struct{}
+	once sync.Once
+}
+
+func newPipeConn() *pipeConn {
+	return &pipeConn{
+		in:   make(chan *internalpb.Envelope, 16),
+		out:  make(chan *internalpb.Envelope, 64),
+		done: make(chan struct{}),
+	}
+}
+
+func (p *pipeConn) ReadEnvelope() (*internalpb.Envelope, error) {
+	select {
+	case env := <-p.in:
+		return env, nil
+	case <-p.done:
+		return nil, errPipeClosed
+	}
+}
+
+func (p *pipeConn) WriteEnvelope(env *internalpb.Envelope) error {
+	select {
+	case p.out <- env:
+		return nil
+	case <-p.done:
+		return errPipeClosed
+	}
+}
+
+func (p *pipeConn) Close() error {
+	p.once.Do(func() { close(p.done) })
+	return nil
+}
+
+// expect 等待下一条 msgID 的下行消息，跳过其他消息
+func (p *pipeConn) expect(t *testing.T, msgID int) *internalpb.Envelope {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case env := <-p.out:
+			if int(env.MsgId) == msgID {
+				return env
+			}
+		case <-timeout:
+			t.Fatalf("msg %d not delivered", msgID)
+			return nil
+		}
+	}
+}
+
+func newTestGate(t *testing.T) *Gate {
+	t.Helper()
+	g := NewGate(nil)
+	if err := g.SetResumeKeys([]ResumeKey{{ID: "k1", Secret: []byte("resume-test-secret")}}, time.Hour); err != nil {
+		t.Fatalf("set resume keys: %v", err)
+	}
+	return g
+}
+
+// startConn 建连接并跑读协程；返回的 channel 在读协程退出（含 onConnClose）后关闭
+func startConn(g *Gate, p *pipeConn) (*Conn, <-chan struct{}) {
+	c := NewConnWithTransport(p, g, ConnTCP)
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		c.ReadLoop()
+	}()
+	return c, exited
+}
+
+func TestResumeKeepsSessionWhenOldConnCloses(t *testing.T) {
+	g := newTestGate(t)
+
+	p1 := newPipeConn()
+	c1, c1Exited := startConn(g, p1)
+	s, err := g.createSessionForConn(c1)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	p1.expect(t, protocol.MsgSessionInit)
+
+	// 已登录的会话：旧连接误把会话置为离线时还会给 Game 发下线通知
+	s.PlayerID = 42
+	s.SetState(SessionAuthenticated)
+
+	p2 := newPipeConn()
+	c2, _ := startConn(g, p2)
+	t.Cleanup(c2.Close)
+
+	payload, err := codec.Encode("", &internalpb.ResumeReq{SessionId: s.ID, Token: s.Token})
+	if err != nil {
+		t.Fatalf("encode resume: %v", err)
+	}
+	g.handleResume(c2, &internalpb.Envelope{MsgId: protocol.MsgResumeReq, Payload: payload})
+
+	var rsp internalpb.ResumeRsp
+	if err := codec.Decode(p2.expect(t, protocol.MsgResumeRsp), &rsp); err != nil || !rsp.Ok {
+		t.Fatalf("resume rsp = %v, err %v", &rsp, err)
+	}
+
+	// handleResume 关掉了旧连接；等它的读协程走完 onConnClose
+	select {
+	case <-c1Exited:
+	case <-time.After(2 * time.Second):
+		t.Fatal("old conn read loop did not exit")
+	}
+
+	if s.Conn != c2 {
+		t.Fatalf("session conn = %p, want new conn %p", s.Conn, c2)
+	}
+	if s.State() != SessionAuthenticated {
+		t.Fatalf("session state = %v, want authenticated", s.State())
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := g.Push(s.ID, protocol.MsgGameBegin+1, []byte{byte(i)}); err != nil {
+			t.Fatalf("push %d: %v", i, err)
+		}
+		env := p2.expect(t, protocol.MsgGameBegin+1)
+		if env.Seq == 0 || env.Payload[0] != byte(i) {
+			t.Fatalf("push %d arrived as seq=%d payload=%v", i, env.Seq, env.Payload)
+		}
+	}
+	if s.replay != nil {
+		t.Fatal("pushes went to the replay buffer instead of the resumed conn")
+	}
+}
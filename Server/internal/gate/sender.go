@@ -2,6 +2,10 @@
 package gate
 
 import (
+	"context"
+	"time"
+
+	"game-server/internal/protocol"
 	"game-server/internal/protocol/internalpb"
 	"go.uber.org/zap"
 )
@@ -29,6 +33,49 @@ func (g *Gate) sendToService(module string, env *internalpb.Envelope) {
 	}
 }
 
+// shardSelectTimeout 选服可能访问 Redis
+const shardSelectTimeout = time.Second
+
+func (g *Gate) forgetServiceSession(sessionID int64) {
+	if g.servicePool != nil {
+		g.servicePool.forget(sessionID)
+	}
+}
+
+// sendToGame 配置了直连时按玩家所在 game 服发送，否则沿用经 service 中转
 func (g *Gate) sendToGame(env *internalpb.Envelope) {
-	g.sendToService("", env)
+	if g.gameSelector == nil {
+		g.sendToService("", env)
+		return
+	}
+	traceID := ""
+	if s := g.sessions.Get(env.GetSessionId()); s != nil {
+		env.PlayerId = s.PlayerID
+		if s.Conn != nil {
+			traceID = s.Conn.TraceID()
+		}
+	}
+	if g.holdForMigration(env) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shardSelectTimeout)
+	shard, err := g.gameSelector.Select(ctx, env.PlayerId)
+	cancel()
+	if err == nil {
+		if pool := g.gamePools[shard.ID]; pool != nil {
+			err = pool.Send(env.GetSessionId(), env)
+		} else {
+			err = protocol.InternalErrRemoteNotReady
+		}
+	}
+	if err != nil {
+		g.logger.Warn("send to game failed",
+			zap.String("reason", err.Error()),
+			zap.Int("msg_id", int(env.MsgId)),
+			zap.Int64("session", env.SessionId),
+			zap.Int64("player", env.PlayerId),
+			zap.String("trace_id", traceID),
+		)
+	}
 }
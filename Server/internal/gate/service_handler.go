@@ -2,9 +2,9 @@ package gate
 
 import (
 	"game-server/internal/protocol"
+	"game-server/internal/protocol/codec"
 	"game-server/internal/protocol/internalpb"
 	"go.uber.org/zap"
-	"google.golang.org/protobuf/proto"
 )
 
 // internal/gate/service_handler.go
@@ -17,23 +17,28 @@ func (g *Gate) OnServiceEnvelope(env *internalpb.Envelope) {
 		return
 	}
 
+	if env.MsgId == protocol.MsgGateControl {
+		g.onGateControl(env.Payload)
+		return
+	}
+
 	switch msgID {
 	case protocol.MsgLoginRsp:
-		g.onLoginRsp(sessionID, env.Payload)
+		g.onLoginRsp(sessionID, env)
 	}
 
 	// 默认：原样转发给客户端
-	_ = g.Reply(sessionID, msgID, env.Payload)
+	_ = g.reply(sessionID, msgID, env.Codec, env.Payload)
 }
 
-func (g *Gate) onLoginRsp(sessionID int64, payload []byte) {
+func (g *Gate) onLoginRsp(sessionID int64, env *internalpb.Envelope) {
 	s := g.sessions.Get(sessionID)
 	if s == nil {
 		return
 	}
 
 	var rsp internalpb.LoginRsp
-	if err := proto.Unmarshal(payload, &rsp); err != nil {
+	if err := codec.Decode(env, &rsp); err != nil {
 		return
 	}
 
@@ -50,7 +55,8 @@ func (g *Gate) onLoginRsp(sessionID int64, payload []byte) {
 	}
 
 	s.PlayerID = rsp.PlayerId
-	s.State = SessionAuthenticated
+	s.SetState(SessionAuthenticated)
+	g.saveSessionRecord(s)
 
 	fields := append(sessionFields(s),
 		zap.Int("msg_id", protocol.MsgLoginRsp),
@@ -59,5 +65,5 @@ func (g *Gate) onLoginRsp(sessionID int64, payload []byte) {
 	fields = append(fields, connFields(s.Conn)...)
 	g.logger.Info("session authenticated", fields...)
 
-	g.unknownMsgKickCount = 0
+	s.UnknownMsgCount = 0
 }
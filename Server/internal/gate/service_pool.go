@@ -0,0 +1,268 @@
+// internal/gate/service_pool.go
+package gate
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"game-server/internal/protocol"
+	"game-server/internal/protocol/internalpb"
+	"game-server/internal/router"
+	"game-server/internal/transport"
+	"go.uber.org/zap"
+)
+
+const (
+	serviceRingReplicas         = 128
+	serviceHealthCheckInterval  = time.Second
+	defaultServicePingInterval  = 5 * time.Second
+	defaultServiceHealthTimeout = 15 * time.Second
+)
+
+// serviceEndpoint 一个 service 进程：内部仍是按 session 取模的连接池
+type serviceEndpoint struct {
+	addr    string
+	pool    *remoteClientPool
+	healthy bool // checkLoop 写，读写都在 servicePool.mu 下
+}
+
+// ServiceEndpointInfo 运维查询用
+type ServiceEndpointInfo struct {
+	Addr     string `json:"addr"`
+	Healthy  bool   `json:"healthy"`
+	RTTMs    int64  `json:"rtt_ms"`
+	Sessions int    `json:"sessions"`
+}
+
+// servicePool 多个 service 端点：
+//   - 健康：任一连接在 healthTimeout 内收到过 Pong
+//   - 新会话：按一致性哈希落到健康端点
+//   - 已分配的会话：端点健康时保持不动，不健康时重新哈希（故障转移）
+type servicePool struct {
+	logger    *zap.Logger
+	endpoints []*serviceEndpoint
+
+	healthTimeout time.Duration
+
+	mu      sync.RWMutex
+	ring    *router.HashRing[*serviceEndpoint] // 只含健康端点
+	allRing *router.HashRing[*serviceEndpoint] // 全部不健康时兜底，保持旧的"排队等重连"行为
+	sticky  map[int64]*serviceEndpoint
+
+	failoverCount uint64
+}
+
+func newServicePool(addrs []string, logger *zap.Logger, onEnvelope func(env *internalpb.Envelope), size int, options transport.ConnOptions, retryMax int, retryBackoff time.Duration) *servicePool {
+	p := &servicePool{
+		logger:        logger,
+		healthTimeout: defaultServiceHealthTimeout,
+		sticky:        make(map[int64]*serviceEndpoint),
+	}
+	for _, addr := range addrs {
+		pool := newRemoteClientPool("service", addr, logger, onEnvelope, size, options, retryMax, retryBackoff)
+		pool.SetPingInterval(defaultServicePingInterval)
+		p.endpoints = append(p.endpoints, &serviceEndpoint{addr: addr, pool: pool})
+	}
+	p.ring = newHashRing(nil)
+	p.allRing = newHashRing(p.endpoints)
+	return p
+}
+
+// SetHello 设置连接建立后的第一条消息，需在 Start 之前调用
+func (p *servicePool) SetHello(env *internalpb.Envelope) {
+	for _, ep := range p.endpoints {
+		ep.pool.SetHello(env)
+	}
+}
+
+// SetHealth 设置 Ping 间隔和判定不健康的超时，需在 Start 之前调用
+func (p *servicePool) SetHealth(pingInterval, timeout time.Duration) {
+	for _, ep := range p.endpoints {
+		ep.pool.SetPingInterval(pingInterval)
+	}
+	if timeout > 0 {
+		p.healthTimeout = timeout
+	}
+}
+
+func (p *servicePool) Start(ctx context.Context) {
+	for _, ep := range p.endpoints {
+		ep.pool.Start(ctx)
+	}
+	go p.checkLoop(ctx)
+}
+
+func (p *servicePool) Send(sessionID int64, env *internalpb.Envelope) error {
+	ep := p.pick(sessionID)
+	if ep == nil {
+		return protocol.InternalErrRemoteNotReady
+	}
+	return ep.pool.Send(sessionID, env)
+}
+
+// broadcast 发给每个端点（按 key 选该端点内的连接），用于清理各 service 的本地状态
+func (p *servicePool) broadcast(key int64, env *internalpb.Envelope) {
+	for _, ep := range p.endpoints {
+		if err := ep.pool.Send(key, env); err != nil {
+			p.logger.Warn("service broadcast failed",
+				zap.String("addr", ep.addr),
+				zap.Int("msg_id", int(env.MsgId)),
+				zap.String("reason", err.Error()),
+			)
+		}
+	}
+}
+
+// pick 会话已绑定且端点健康时沿用，否则按哈希重新选择
+func (p *servicePool) pick(sessionID int64) *serviceEndpoint {
+	p.mu.RLock()
+	ep := p.sticky[sessionID]
+	if ep != nil && ep.healthy {
+		p.mu.RUnlock()
+		return ep
+	}
+	p.mu.RUnlock()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ep = p.sticky[sessionID]
+	if ep != nil && ep.healthy {
+		return ep
+	}
+	key := router.HashID(sessionID)
+	next, ok := p.ring.Get(key)
+	if !ok {
+		// 没有健康端点：不绑定，按全量哈希投递，由连接层排队 / 丢弃
+		ep, _ = p.allRing.Get(key)
+		return ep
+	}
+	if ep != nil && ep != next {
+		atomic.AddUint64(&p.failoverCount, 1)
+		p.logger.Warn("service failover",
+			zap.Int64("session", sessionID),
+			zap.String("from", ep.addr),
+			zap.String("to", next.addr),
+			zap.String("reason", "endpoint_unhealthy"),
+		)
+	}
+	p.sticky[sessionID] = next
+	return next
+}
+
+// forget 会话销毁时解除绑定
+func (p *servicePool) forget(sessionID int64) {
+	p.mu.Lock()
+	delete(p.sticky, sessionID)
+	p.mu.Unlock()
+}
+
+func (p *servicePool) checkLoop(ctx context.Context) {
+	ticker := time.NewTicker(serviceHealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		p.checkHealth(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *servicePool) checkHealth(now time.Time) {
+	states := make([]bool, len(p.endpoints))
+	for i, ep := range p.endpoints {
+		for _, client := range ep.pool.clients {
+			if client.healthy(now, p.healthTimeout) {
+				states[i] = true
+				break
+			}
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changed := false
+	healthy := make([]*serviceEndpoint, 0, len(p.endpoints))
+	for i, ep := range p.endpoints {
+		if ep.healthy != states[i] {
+			changed = true
+			ep.healthy = states[i]
+			if ep.healthy {
+				p.logger.Info("service endpoint healthy", zap.String("addr", ep.addr))
+			} else {
+				p.logger.Warn("service endpoint unhealthy",
+					zap.String("addr", ep.addr),
+					zap.String("reason", "health_check_failed"),
+				)
+			}
+		}
+		if ep.healthy {
+			healthy = append(healthy, ep)
+		}
+	}
+	if changed {
+		p.ring = newHashRing(healthy)
+	}
+}
+
+func (p *servicePool) queueDepth() int {
+	depth := 0
+	for _, ep := range p.endpoints {
+		depth += ep.pool.queueDepth()
+	}
+	return depth
+}
+
+// stats 返回累计的队列满次数和断线丢弃次数
+func (p *servicePool) stats() (busy, dropped uint64) {
+	for _, ep := range p.endpoints {
+		b, d := ep.pool.stats()
+		busy += b
+		dropped += d
+	}
+	return busy, dropped
+}
+
+func (p *servicePool) infos() []ServiceEndpointInfo {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	sessions := make(map[*serviceEndpoint]int, len(p.endpoints))
+	for _, ep := range p.sticky {
+		sessions[ep]++
+	}
+	out := make([]ServiceEndpointInfo, 0, len(p.endpoints))
+	for _, ep := range p.endpoints {
+		var rtt int64
+		for _, client := range ep.pool.clients {
+			if v := atomic.LoadInt64(&client.rtt); v > rtt {
+				rtt = v
+			}
+		}
+		out = append(out, ServiceEndpointInfo{
+			Addr:     ep.addr,
+			Healthy:  ep.healthy,
+			RTTMs:    time.Duration(rtt).Milliseconds(),
+			Sessions: sessions[ep],
+		})
+	}
+	return out
+}
+
+// ServiceEndpoints 当前各 service 端点的健康状态
+func (g *Gate) ServiceEndpoints() []ServiceEndpointInfo {
+	if g.servicePool == nil {
+		return nil
+	}
+	return g.servicePool.infos()
+}
+
+func newHashRing(endpoints []*serviceEndpoint) *router.HashRing[*serviceEndpoint] {
+	return router.NewHashRing(endpoints, func(ep *serviceEndpoint) string { return ep.addr }, serviceRingReplicas)
+}
@@ -4,7 +4,8 @@ package gate
 import (
 	"game-server/internal/protocol"
 	"game-server/internal/protocol/internalpb"
-	"google.golang.org/protobuf/proto"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,53 +25,142 @@ type Session struct {
 	PlayerID int64
 	Token    string
 
-	State SessionState
-	Conn  *Conn
+	state int32 // SessionState，admin 等其他协程也会读，用 State / SetState 原子读写
+	Conn  *Conn // 写在 sendMu 下；读协程以外用 currentConn
 
-	LastSeen time.Time
+	lastSeen atomic.Int64 // UnixNano
 
 	// ⭐ 登录相关
 	AuthStart time.Time
 	LoginWindowStart time.Time
 	LoginAttempts    int
 	UnknownMsgCount  int
+
+	// ⭐ 按 msgID 区间限流；resume 时新旧连接的读协程可能同时在跑，用 rateMu 保护
+	rateMu               sync.Mutex
+	rateBuckets          []tokenBucket
+	RateLimitViolations  int // rateLimitKickWindow 内的违规次数
+	RateLimitWindowStart time.Time
+
+	// ⭐ 下行序号 / 离线补发
+	sendMu  sync.Mutex
+	sendSeq uint64
+	replay  *replayBuffer
 }
 
-func (g *Gate) newSession() *Session {
-	s := &Session{
-		ID:       g.nextSessionID(),
-		State:    SessionInit,
-		LastSeen: time.Now(),
+func (g *Gate) newSession() (*Session, error) {
+	id, err := g.nextSessionID()
+	if err != nil {
+		return nil, err
 	}
-	s.Token = g.signResumeToken(s)
-	return s
+	s := &Session{ID: id}
+	s.SetState(SessionInit)
+	s.MarkSeen()
+	if s.Token, err = g.signResumeToken(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Session) State() SessionState {
+	return SessionState(atomic.LoadInt32(&s.state))
+}
+
+func (s *Session) SetState(st SessionState) {
+	atomic.StoreInt32(&s.state, int32(st))
+}
+
+func (s *Session) LastSeen() time.Time {
+	return time.Unix(0, s.lastSeen.Load())
+}
+
+func (s *Session) setLastSeen(t time.Time) {
+	s.lastSeen.Store(t.UnixNano())
 }
 
 func (s *Session) MarkSeen() {
-	s.LastSeen = time.Now()
+	s.setLastSeen(time.Now())
+}
+
+// currentConn 在 sendMu 下读当前连接，给 admin 等不在连接读协程里的调用方用
+func (s *Session) currentConn() *Conn {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	return s.Conn
 }
 
-func (g *Gate) createSessionForConn(c *Conn) *Session {
-	s := g.newSession()
+// setConn 更换当前连接；和 sendSequenced 一样持 sendMu，下行发送不会读到换了一半的连接
+func (s *Session) setConn(c *Conn) {
+	s.sendMu.Lock()
 	s.Conn = c
-	s.State = SessionOnline
-	s.LastSeen = time.Now()
+	s.sendMu.Unlock()
+}
+
+// detachConn 摘下当前连接并返回它；调用方在锁外 Close，Close 回调里还会再摘一次
+func (s *Session) detachConn() *Conn {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	c := s.Conn
+	s.Conn = nil
+	return c
+}
+
+// detachConnIf 只有 c 仍是当前连接时才摘下；resume 换上新连接后，旧连接的关闭回调不能再动会话
+func (s *Session) detachConnIf(c *Conn) bool {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	if s.Conn != c {
+		return false
+	}
+	s.Conn = nil
+	return true
+}
+
+func (g *Gate) createSessionForConn(c *Conn) (*Session, error) {
+	s, err := g.newSession()
+	if err != nil {
+		return nil, err
+	}
+	s.Conn = c
+	s.SetState(SessionOnline)
+	s.setLastSeen(time.Now())
 
 	c.sessionID = s.ID
 	g.sessions.Add(s)
+	g.saveSessionRecord(s)
 
 	init := &internalpb.SessionInit{
 		SessionId: s.ID,
 		Token:     s.Token,
 	}
-	data, _ := proto.Marshal(init)
+	data, codecName := c.encodePayload(init)
 
 	_ = c.Send(&internalpb.Envelope{
 		MsgId:     protocol.MsgSessionInit,
 		SessionId: s.ID,
 		Payload:   data,
+		Codec:     codecName,
 	})
 
 	g.logger.Info("session init", append(sessionFields(s), connFields(c)...)...)
-	return s
+	return s, nil
+}
+
+func (st SessionState) String() string {
+	switch st {
+	case SessionInit:
+		return "init"
+	case SessionOnline:
+		return "online"
+	case SessionAuthing:
+		return "authing"
+	case SessionAuthenticated:
+		return "authenticated"
+	case SessionOffline:
+		return "offline"
+	case SessionClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
 }
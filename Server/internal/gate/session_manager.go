@@ -53,6 +53,20 @@ func (sm *SessionManager) Remove(sessionID int64) {
 	delete(sm.bySession, sessionID)
 }
 
+func (sm *SessionManager) GetByPlayer(playerID int64) *Session {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	return sm.byPlayer[playerID]
+}
+
+func (sm *SessionManager) Count() int {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	return len(sm.bySession)
+}
+
 func (sm *SessionManager) snapshot() []*Session {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
@@ -72,10 +86,10 @@ func (sm *SessionManager) GC(timeout time.Duration) []*Session {
 	defer sm.mu.Unlock()
 
 	for id, s := range sm.bySession {
-		if s.State == SessionOffline &&
-			now.Sub(s.LastSeen) > timeout {
+		if s.State() == SessionOffline &&
+			now.Sub(s.LastSeen()) > timeout {
 
-			s.State = SessionClosed
+			s.SetState(SessionClosed)
 			removed = append(removed, s)
 
 			if s.PlayerID != 0 {
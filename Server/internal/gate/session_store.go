@@ -0,0 +1,229 @@
+package gate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SessionRecord 跨 Gate 共享的会话目录项
+type SessionRecord struct {
+	SessionID int64
+	PlayerID  int64
+	TokenHash string
+	GateID    string
+	State     SessionState
+	UpdatedAt time.Time
+}
+
+// SessionStore 共享会话目录（可选）
+//
+//	Gate 重启或负载均衡到另一台 Gate 时，ResumeReq 通过它接管原会话
+type SessionStore interface {
+	// NextSessionID 全局唯一的 sessionID
+	NextSessionID(ctx context.Context) (int64, error)
+	Save(ctx context.Context, rec *SessionRecord) error
+	Load(ctx context.Context, sessionID int64) (*SessionRecord, bool, error)
+	// Refresh 续期记录的过期时间；记录只在状态变化时重写，在线会话靠它保持不过期
+	Refresh(ctx context.Context, sessionIDs []int64) error
+	// TTL 记录的过期时间，Gate 按它的 1/3 续期
+	TTL() time.Duration
+	// DeleteIfOwner 只删除仍归属 gateID 的记录，避免误删已被接管的会话
+	DeleteIfOwner(ctx context.Context, sessionID int64, gateID string) error
+
+	// NotifyDrop 通知原 Gate 丢弃本地会话副本
+	NotifyDrop(ctx context.Context, gateID string, sessionID int64) error
+	// WatchDrops 阻塞监听发给 gateID 的丢弃通知，直到 ctx 结束
+	WatchDrops(ctx context.Context, gateID string, onDrop func(sessionID int64))
+}
+
+const sessionStoreTimeout = time.Second
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SetSessionStore 启用共享会话目录，需在 Start 之前调用
+func (g *Gate) SetSessionStore(store SessionStore) {
+	g.sessionStore = store
+}
+
+func (g *Gate) saveSessionRecord(s *Session) {
+	if g.sessionStore == nil || s == nil {
+		return
+	}
+	rec := &SessionRecord{
+		SessionID: s.ID,
+		PlayerID:  s.PlayerID,
+		TokenHash: hashToken(s.Token),
+		GateID:    g.id,
+		State:     s.State(),
+		UpdatedAt: time.Now(),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sessionStoreTimeout)
+	defer cancel()
+	if err := g.sessionStore.Save(ctx, rec); err != nil {
+		fields := append(sessionFields(s), zap.String("reason", err.Error()))
+		g.logger.Warn("save session record failed", fields...)
+	}
+}
+
+// sessionRefreshBatch 单次 Refresh 最多续期的会话数
+const sessionRefreshBatch = 500
+
+// refreshSessionRecords 续期本 Gate 持有的会话记录；Closed 的记录由 GC 删除，不再续期
+func (g *Gate) refreshSessionRecords() {
+	if g.sessionStore == nil {
+		return
+	}
+	ids := make([]int64, 0, sessionRefreshBatch)
+	flush := func() {
+		if len(ids) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), sessionStoreTimeout)
+		defer cancel()
+		if err := g.sessionStore.Refresh(ctx, ids); err != nil {
+			g.logger.Warn("refresh session records failed",
+				zap.String("reason", err.Error()),
+				zap.Int("sessions", len(ids)),
+			)
+		}
+		ids = ids[:0]
+	}
+	for _, s := range g.sessions.snapshot() {
+		if s.State() == SessionClosed {
+			continue
+		}
+		ids = append(ids, s.ID)
+		if len(ids) == sessionRefreshBatch {
+			flush()
+		}
+	}
+	flush()
+}
+
+func (g *Gate) sessionRefreshLoop(ctx context.Context) {
+	if g.sessionStore == nil {
+		return
+	}
+	interval := g.sessionStore.TTL() / 3
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.refreshSessionRecords()
+		}
+	}
+}
+
+func (g *Gate) deleteSessionRecord(s *Session) {
+	if g.sessionStore == nil || s == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sessionStoreTimeout)
+	defer cancel()
+	if err := g.sessionStore.DeleteIfOwner(ctx, s.ID, g.id); err != nil {
+		fields := append(sessionFields(s), zap.String("reason", err.Error()))
+		g.logger.Warn("delete session record failed", fields...)
+	}
+}
+
+// adoptSession 从共享目录接管其他 Gate（或上一个进程）创建的会话
+func (g *Gate) adoptSession(sessionID int64, token string) *Session {
+	if g.sessionStore == nil || sessionID == 0 || token == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sessionStoreTimeout)
+	defer cancel()
+
+	rec, ok, err := g.sessionStore.Load(ctx, sessionID)
+	if err != nil {
+		g.logger.Warn("load session record failed",
+			zap.Int64("session", sessionID),
+			zap.String("reason", err.Error()),
+		)
+		return nil
+	}
+	if !ok || rec.State == SessionClosed {
+		return nil
+	}
+	if rec.TokenHash != hashToken(token) {
+		return nil
+	}
+
+	s := &Session{
+		ID:       rec.SessionID,
+		PlayerID: rec.PlayerID,
+		Token:    token,
+	}
+	s.SetState(SessionOffline)
+	s.MarkSeen()
+	if !g.verifyToken(s, token) {
+		return nil
+	}
+
+	g.sessions.Add(s)
+	if s.PlayerID != 0 {
+		g.sessions.BindPlayer(s, s.PlayerID)
+	}
+
+	if rec.GateID != "" && rec.GateID != g.id {
+		if err := g.sessionStore.NotifyDrop(ctx, rec.GateID, s.ID); err != nil {
+			g.logger.Warn("notify old gate drop session failed",
+				zap.Int64("session", s.ID),
+				zap.Int64("player", s.PlayerID),
+				zap.String("gate", rec.GateID),
+				zap.String("reason", err.Error()),
+			)
+		}
+	}
+
+	g.logger.Info("session adopted from store",
+		zap.Int64("session", s.ID),
+		zap.Int64("player", s.PlayerID),
+		zap.String("from_gate", rec.GateID),
+		zap.String("reason", "session_adopted"),
+	)
+	return s
+}
+
+// dropSession 会话已被其他 Gate 接管：只清理本地副本，不通知 Game 下线
+func (g *Gate) dropSession(sessionID int64) {
+	s := g.sessions.Get(sessionID)
+	if s == nil {
+		return
+	}
+	g.sessions.Remove(sessionID)
+	g.groups.removeSession(sessionID)
+	g.forgetServiceSession(sessionID)
+
+	conn := s.detachConn()
+	s.SetState(SessionClosed)
+	if conn != nil {
+		conn.Close()
+	}
+
+	fields := append(sessionFields(s), zap.String("reason", "session_taken_over"))
+	fields = append(fields, connFields(conn)...)
+	g.logger.Info("session dropped", fields...)
+}
+
+func (g *Gate) watchSessionDrops(ctx context.Context) {
+	if g.sessionStore == nil {
+		return
+	}
+	g.sessionStore.WatchDrops(ctx, g.id, g.dropSession)
+}
@@ -0,0 +1,125 @@
+package gate
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"game-server/internal/db/redis_tools"
+	"go.uber.org/zap"
+)
+
+const deleteIfOwnerScript = `
+if redis.call('HGET', KEYS[1], 'gate_id') == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`
+
+type RedisSessionStore struct {
+	dao    *redis_tools.RedisDao
+	ttl    time.Duration
+	logger *zap.Logger
+}
+
+func NewRedisSessionStore(dao *redis_tools.RedisDao, ttl time.Duration, logger *zap.Logger) *RedisSessionStore {
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &RedisSessionStore{dao: dao, ttl: ttl, logger: logger}
+}
+
+func (s *RedisSessionStore) NextSessionID(ctx context.Context) (int64, error) {
+	return s.dao.Incr(ctx, redis_tools.KeyGateSessionNext)
+}
+
+func (s *RedisSessionStore) Save(ctx context.Context, rec *SessionRecord) error {
+	key := redis_tools.GateSessionKey(rec.SessionID)
+	pipe := s.dao.Pipe()
+	pipe.HSet(ctx, key,
+		"player_id", rec.PlayerID,
+		"token_hash", rec.TokenHash,
+		"gate_id", rec.GateID,
+		"state", int(rec.State),
+		"updated_at", rec.UpdatedAt.UnixMilli(),
+	)
+	pipe.Expire(ctx, key, s.ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisSessionStore) Refresh(ctx context.Context, sessionIDs []int64) error {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+	pipe := s.dao.Pipe()
+	for _, id := range sessionIDs {
+		pipe.Expire(ctx, redis_tools.GateSessionKey(id), s.ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisSessionStore) TTL() time.Duration {
+	return s.ttl
+}
+
+func (s *RedisSessionStore) Load(ctx context.Context, sessionID int64) (*SessionRecord, bool, error) {
+	fields, err := s.dao.HGetAll(ctx, redis_tools.GateSessionKey(sessionID))
+	if err != nil {
+		return nil, false, err
+	}
+	if len(fields) == 0 {
+		return nil, false, nil
+	}
+
+	playerID, _ := strconv.ParseInt(fields["player_id"], 10, 64)
+	state, _ := strconv.Atoi(fields["state"])
+	updatedAt, _ := strconv.ParseInt(fields["updated_at"], 10, 64)
+	return &SessionRecord{
+		SessionID: sessionID,
+		PlayerID:  playerID,
+		TokenHash: fields["token_hash"],
+		GateID:    fields["gate_id"],
+		State:     SessionState(state),
+		UpdatedAt: time.UnixMilli(updatedAt),
+	}, true, nil
+}
+
+func (s *RedisSessionStore) DeleteIfOwner(ctx context.Context, sessionID int64, gateID string) error {
+	_, err := s.dao.Eval(ctx, deleteIfOwnerScript, []string{redis_tools.GateSessionKey(sessionID)}, gateID)
+	return err
+}
+
+func (s *RedisSessionStore) NotifyDrop(ctx context.Context, gateID string, sessionID int64) error {
+	return s.dao.Publish(ctx, redis_tools.GateControlChannel(gateID), strconv.FormatInt(sessionID, 10))
+}
+
+func (s *RedisSessionStore) WatchDrops(ctx context.Context, gateID string, onDrop func(sessionID int64)) {
+	sub := s.dao.Subscribe(ctx, redis_tools.GateControlChannel(gateID))
+	defer sub.Close()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			sessionID, err := strconv.ParseInt(msg.Payload, 10, 64)
+			if err != nil {
+				s.logger.Warn("invalid session drop message",
+					zap.String("payload", msg.Payload),
+					zap.String("reason", err.Error()),
+				)
+				continue
+			}
+			onDrop(sessionID)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package gate
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+)
+
+// memSessionStore 只记录 Refresh 调用的内存实现
+type memSessionStore struct {
+	refreshed [][]int64
+}
+
+func (m *memSessionStore) NextSessionID(context.Context) (int64, error) { return 0, nil }
+func (m *memSessionStore) Save(context.Context, *SessionRecord) error   { return nil }
+func (m *memSessionStore) Load(context.Context, int64) (*SessionRecord, bool, error) {
+	return nil, false, nil
+}
+func (m *memSessionStore) DeleteIfOwner(context.Context, int64, string) error { return nil }
+func (m *memSessionStore) NotifyDrop(context.Context, string, int64) error    { return nil }
+func (m *memSessionStore) WatchDrops(context.Context, string, func(int64))    {}
+func (m *memSessionStore) TTL() time.Duration                                 { return time.Minute }
+
+func (m *memSessionStore) Refresh(_ context.Context, ids []int64) error {
+	m.refreshed = append(m.refreshed, append([]int64(nil), ids...))
+	return nil
+}
+
+func TestRefreshSessionRecords(t *testing.T) {
+	g := NewGate(nil)
+	store := &memSessionStore{}
+	g.SetSessionStore(store)
+
+	states := map[int64]SessionState{
+		1: SessionOnline,
+		2: SessionAuthenticated,
+		3: SessionOffline,
+		4: SessionClosed,
+	}
+	for id, st := range states {
+		s := &Session{ID: id}
+		s.SetState(st)
+		g.sessions.Add(s)
+	}
+	for id := int64(100); id < 100+sessionRefreshBatch; id++ {
+		s := &Session{ID: id}
+		s.SetState(SessionAuthenticated)
+		g.sessions.Add(s)
+	}
+
+	g.refreshSessionRecords()
+
+	if len(store.refreshed) != 2 {
+		t.Fatalf("refresh calls = %d, want 2 batches", len(store.refreshed))
+	}
+	var got []int64
+	for _, batch := range store.refreshed {
+		if len(batch) > sessionRefreshBatch {
+			t.Fatalf("batch of %d exceeds %d", len(batch), sessionRefreshBatch)
+		}
+		got = append(got, batch...)
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+
+	if len(got) != 3+sessionRefreshBatch || got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Fatalf("refreshed %d sessions starting %v, want online/offline ones and not the closed one", len(got), got[:3])
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 )
 
@@ -35,3 +36,16 @@ func (r *Registry[T]) Get(msgID int) (T, bool) {
 	h, ok := r.handlers[msgID]
 	return h, ok
 }
+
+// IDs returns the registered msgIDs in ascending order.
+func (r *Registry[T]) IDs() []int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]int, 0, len(r.handlers))
+	for id := range r.handlers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
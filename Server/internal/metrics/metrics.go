@@ -0,0 +1,273 @@
+// internal/metrics/metrics.go
+package metrics
+
+import (
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefBuckets 默认延迟分桶（秒）
+var DefBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+// ========================
+// Counter
+// ========================
+
+type Counter struct {
+	v uint64
+}
+
+func (c *Counter) Inc() {
+	atomic.AddUint64(&c.v, 1)
+}
+
+func (c *Counter) Add(n uint64) {
+	atomic.AddUint64(&c.v, n)
+}
+
+func (c *Counter) Value() uint64 {
+	return atomic.LoadUint64(&c.v)
+}
+
+// ========================
+// Gauge
+// ========================
+
+type Gauge struct {
+	bits uint64 // math.Float64bits
+}
+
+func (g *Gauge) Set(v float64) {
+	atomic.StoreUint64(&g.bits, math.Float64bits(v))
+}
+
+func (g *Gauge) Add(delta float64) {
+	for {
+		old := atomic.LoadUint64(&g.bits)
+		next := math.Float64bits(math.Float64frombits(old) + delta)
+		if atomic.CompareAndSwapUint64(&g.bits, old, next) {
+			return
+		}
+	}
+}
+
+func (g *Gauge) Inc() { g.Add(1) }
+func (g *Gauge) Dec() { g.Add(-1) }
+
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&g.bits))
+}
+
+// ========================
+// Histogram
+// ========================
+
+type Histogram struct {
+	upper  []float64
+	counts []uint64 // 与 upper 一一对应，非累计
+	count  uint64
+	sum    Gauge
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{
+		upper:  buckets,
+		counts: make([]uint64, len(buckets)),
+	}
+}
+
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.upper, v)
+	if i < len(h.counts) {
+		atomic.AddUint64(&h.counts[i], 1)
+	}
+	atomic.AddUint64(&h.count, 1)
+	h.sum.Add(v)
+}
+
+// ========================
+// Vec（按 label 值分组）
+// ========================
+
+type vec[T any] struct {
+	name   string
+	help   string
+	labels []string
+	newFn  func() T
+
+	mu    sync.RWMutex
+	items map[string]T
+	keys  map[string][]string
+}
+
+func newVec[T any](name, help string, labels []string, newFn func() T) *vec[T] {
+	return &vec[T]{
+		name:   name,
+		help:   help,
+		labels: labels,
+		newFn:  newFn,
+		items:  make(map[string]T),
+		keys:   make(map[string][]string),
+	}
+}
+
+func (v *vec[T]) with(values []string) T {
+	if len(values) != len(v.labels) {
+		panic("metrics: label count mismatch for " + v.name)
+	}
+	key := strings.Join(values, "\xff")
+
+	v.mu.RLock()
+	item, ok := v.items[key]
+	v.mu.RUnlock()
+	if ok {
+		return item
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if item, ok = v.items[key]; ok {
+		return item
+	}
+	item = v.newFn()
+	v.items[key] = item
+	v.keys[key] = append([]string(nil), values...)
+	return item
+}
+
+func (v *vec[T]) metricName() string {
+	return v.name
+}
+
+// each 按 label 值排序遍历，保证输出稳定
+func (v *vec[T]) each(fn func(values []string, item T)) {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.items))
+	for k := range v.items {
+		keys = append(keys, k)
+	}
+	v.mu.RUnlock()
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v.mu.RLock()
+		item, values := v.items[k], v.keys[k]
+		v.mu.RUnlock()
+		fn(values, item)
+	}
+}
+
+type CounterVec struct {
+	*vec[*Counter]
+}
+
+// NewCounterVec 只创建不注册；进程真正用到时再 Register，避免被 import 的包把指标带进别的进程
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{newVec(name, help, labels, func() *Counter { return &Counter{} })}
+}
+
+func (c *CounterVec) With(values ...string) *Counter {
+	return c.with(values)
+}
+
+func (c *CounterVec) writeTo(w io.Writer) {
+	writeHeader(w, c.name, c.help, "counter")
+	c.each(func(values []string, item *Counter) {
+		writeSample(w, c.name, c.labels, values, "", "", float64(item.Value()))
+	})
+}
+
+type GaugeVec struct {
+	*vec[*Gauge]
+}
+
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{newVec(name, help, labels, func() *Gauge { return &Gauge{} })}
+}
+
+func (g *GaugeVec) With(values ...string) *Gauge {
+	return g.with(values)
+}
+
+func (g *GaugeVec) writeTo(w io.Writer) {
+	writeHeader(w, g.name, g.help, "gauge")
+	g.each(func(values []string, item *Gauge) {
+		writeSample(w, g.name, g.labels, values, "", "", item.Value())
+	})
+}
+
+type HistogramVec struct {
+	*vec[*Histogram]
+}
+
+// NewHistogramVec buckets 为空时使用 DefBuckets
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &HistogramVec{newVec(name, help, labels, func() *Histogram { return newHistogram(buckets) })}
+}
+
+func (h *HistogramVec) With(values ...string) *Histogram {
+	return h.with(values)
+}
+
+func (h *HistogramVec) writeTo(w io.Writer) {
+	writeHeader(w, h.name, h.help, "histogram")
+	h.each(func(values []string, item *Histogram) {
+		var cumulative uint64
+		for i, upper := range item.upper {
+			cumulative += atomic.LoadUint64(&item.counts[i])
+			writeSample(w, h.name+"_bucket", h.labels, values, "le", formatFloat(upper), float64(cumulative))
+		}
+		total := atomic.LoadUint64(&item.count)
+		writeSample(w, h.name+"_bucket", h.labels, values, "le", "+Inf", float64(total))
+		writeSample(w, h.name+"_sum", h.labels, values, "", "", item.sum.Value())
+		writeSample(w, h.name+"_count", h.labels, values, "", "", float64(total))
+	})
+}
+
+// ========================
+// Func（抓取时采集）
+// ========================
+
+// EmitFunc 采集回调中上报一个样本，label 值与声明顺序一致
+type EmitFunc func(value float64, values ...string)
+
+type funcMetric struct {
+	name    string
+	help    string
+	typ     string
+	labels  []string
+	collect func(emit EmitFunc)
+}
+
+// NewGaugeFunc 每次抓取时调用 collect，适合队列深度、状态分布等现算指标
+func NewGaugeFunc(name, help string, labels []string, collect func(emit EmitFunc)) Collector {
+	return &funcMetric{name: name, help: help, typ: "gauge", labels: labels, collect: collect}
+}
+
+// NewCounterFunc 导出已有的单调计数器（例如结构体里的 atomic 字段）
+func NewCounterFunc(name, help string, labels []string, collect func(emit EmitFunc)) Collector {
+	return &funcMetric{name: name, help: help, typ: "counter", labels: labels, collect: collect}
+}
+
+func (f *funcMetric) metricName() string {
+	return f.name
+}
+
+func (f *funcMetric) writeTo(w io.Writer) {
+	writeHeader(w, f.name, f.help, f.typ)
+	f.collect(func(value float64, values ...string) {
+		if len(values) != len(f.labels) {
+			return
+		}
+		writeSample(w, f.name, f.labels, values, "", "", value)
+	})
+}
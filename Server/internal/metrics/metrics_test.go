@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func writeText(t *testing.T, r *Registry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf); err != nil {
+		t.Fatalf("write text: %v", err)
+	}
+	return buf.String()
+}
+
+func TestWriteTextFormat(t *testing.T) {
+	requests := NewCounterVec("test_requests_total", "Requests by route.", "route", "code")
+	requests.With("login", "200").Add(3)
+	requests.With("chat", "500").Inc()
+
+	conns := NewGaugeVec("test_connections", "Open connections.")
+	conns.With().Set(2)
+	conns.With().Dec()
+
+	queue := NewGaugeFunc("test_queue_depth", "Queue depth.", []string{"queue"}, func(emit EmitFunc) {
+		emit(7, "send")
+		emit(1, "send", "extra") // label 个数不符的样本被丢弃
+	})
+
+	r := NewRegistry()
+	r.Register(requests, conns, queue)
+
+	want := `# HELP test_requests_total Requests by route.
+# TYPE test_requests_total counter
+test_requests_total{route="chat",code="500"} 1
+test_requests_total{route="login",code="200"} 3
+# HELP test_connections Open connections.
+# TYPE test_connections gauge
+test_connections 1
+# HELP test_queue_depth Queue depth.
+# TYPE test_queue_depth gauge
+test_queue_depth{queue="send"} 7
+`
+	if got := writeText(t, r); got != want {
+		t.Fatalf("exposition mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestEscaping(t *testing.T) {
+	c := NewCounterVec("test_escape_total", "Help with \\ backslash\nand newline \"quotes\".", "value")
+	c.With("a\"b\\c\nd").Inc()
+
+	r := NewRegistry()
+	r.Register(c)
+
+	want := `# HELP test_escape_total Help with \\ backslash\nand newline "quotes".
+# TYPE test_escape_total counter
+test_escape_total{value="a\"b\\c\nd"} 1
+`
+	if got := writeText(t, r); got != want {
+		t.Fatalf("escaping mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	// 乱序传入的分桶会被排序；le 为闭区间，正好落在上界的样本计入该桶
+	h := NewHistogramVec("test_latency_seconds", "Latency.", []float64{1, 0.1, 0.5}, "op")
+	for _, v := range []float64{0.1, 0.3, 2} {
+		h.With("save").Observe(v)
+	}
+
+	r := NewRegistry()
+	r.Register(h)
+
+	want := `# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{op="save",le="0.1"} 1
+test_latency_seconds_bucket{op="save",le="0.5"} 2
+test_latency_seconds_bucket{op="save",le="1"} 2
+test_latency_seconds_bucket{op="save",le="+Inf"} 3
+test_latency_seconds_sum{op="save"} 2.4
+test_latency_seconds_count{op="save"} 3
+`
+	if got := writeText(t, r); got != want {
+		t.Fatalf("histogram mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHistogramDefaultBuckets(t *testing.T) {
+	h := NewHistogramVec("test_default_seconds", "Default buckets.", nil)
+	h.With().Observe(0.003)
+
+	r := NewRegistry()
+	r.Register(h)
+	got := writeText(t, r)
+
+	if n := strings.Count(got, "test_default_seconds_bucket{"); n != len(DefBuckets)+1 {
+		t.Fatalf("bucket lines = %d, want %d\n%s", n, len(DefBuckets)+1, got)
+	}
+	if !strings.Contains(got, `test_default_seconds_bucket{le="0.0025"} 0`) ||
+		!strings.Contains(got, `test_default_seconds_bucket{le="0.005"} 1`) {
+		t.Fatalf("sample not in the expected bucket:\n%s", got)
+	}
+}
+
+func TestRegisterReplacesSameName(t *testing.T) {
+	first := NewCounterVec("test_dup_total", "First.")
+	second := NewCounterVec("test_dup_total", "Second.")
+	other := NewCounterVec("test_other_total", "Other.")
+	second.With().Add(5)
+
+	r := NewRegistry()
+	r.Register(first, other)
+	r.Register(second)
+
+	got := writeText(t, r)
+	if strings.Contains(got, "First.") || !strings.Contains(got, "test_dup_total 5") {
+		t.Fatalf("later registration should replace the earlier one:\n%s", got)
+	}
+	if strings.Index(got, "test_dup_total") > strings.Index(got, "test_other_total") {
+		t.Fatalf("replaced metric should keep its original position:\n%s", got)
+	}
+}
+
+func TestLabelCountMismatchPanics(t *testing.T) {
+	c := NewCounterVec("test_labels_total", "Labels.", "a", "b")
+	defer func() {
+		if recover() == nil {
+			t.Fatal("With with wrong label count did not panic")
+		}
+	}()
+	c.With("only-one")
+}
+
+func TestFormatFloat(t *testing.T) {
+	cases := map[float64]string{
+		0:            "0",
+		1.5:          "1.5",
+		1e21:         "1e+21",
+		math.Inf(1):  "+Inf",
+		math.Inf(-1): "-Inf",
+		math.NaN():   "NaN",
+	}
+	for v, want := range cases {
+		if got := formatFloat(v); got != want {
+			t.Fatalf("formatFloat(%v) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+func TestHandlerContentType(t *testing.T) {
+	r := NewRegistry()
+	r.Register(NewCounterVec("test_handler_total", "Handler."))
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Fatalf("content type = %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "# TYPE test_handler_total counter") {
+		t.Fatalf("unexpected body:\n%s", rec.Body.String())
+	}
+}
@@ -0,0 +1,165 @@
+// internal/metrics/registry.go
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default 进程内唯一的注册表，由各服务构造时注册
+var Default = NewRegistry()
+
+// Collector 本包内的各类指标（CounterVec / GaugeVec / HistogramVec / Func）
+type Collector interface {
+	metricName() string
+	writeTo(w io.Writer)
+}
+
+type Registry struct {
+	mu    sync.RWMutex
+	order []string
+	items map[string]Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{items: make(map[string]Collector)}
+}
+
+// Register 同名指标后注册的覆盖先注册的（例如重复构造 Gate）
+func (r *Registry) Register(cs ...Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range cs {
+		name := c.metricName()
+		if _, ok := r.items[name]; !ok {
+			r.order = append(r.order, name)
+		}
+		r.items[name] = c
+	}
+}
+
+func Register(cs ...Collector) {
+	Default.Register(cs...)
+}
+
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.RLock()
+	items := make([]Collector, 0, len(r.order))
+	for _, name := range r.order {
+		items = append(items, r.items[name])
+	}
+	r.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	for _, c := range items {
+		c.writeTo(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler Prometheus 文本格式（0.0.4）
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = r.WriteText(w)
+	})
+}
+
+func Handler() http.Handler {
+	return Default.Handler()
+}
+
+// ListenAndServe 在 addr 上暴露 /metrics，ctx 结束时关闭
+func ListenAndServe(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = srv.Shutdown(context.Background())
+	}()
+
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// ========================
+// Text format
+// ========================
+
+func writeHeader(w io.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, escapeHelp(help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+}
+
+func writeSample(w io.Writer, name string, labels, values []string, extraName, extraValue string, v float64) {
+	var sb strings.Builder
+	sb.WriteString(name)
+	if len(labels) > 0 || extraName != "" {
+		sb.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(l)
+			sb.WriteString(`="`)
+			sb.WriteString(escapeLabel(values[i]))
+			sb.WriteByte('"')
+		}
+		if extraName != "" {
+			if len(labels) > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(extraName)
+			sb.WriteString(`="`)
+			sb.WriteString(extraValue)
+			sb.WriteByte('"')
+		}
+		sb.WriteByte('}')
+	}
+	sb.WriteByte(' ')
+	sb.WriteString(formatFloat(v))
+	sb.WriteByte('\n')
+	_, _ = io.WriteString(w, sb.String())
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelReplacer.Replace(s)
+}
@@ -0,0 +1,309 @@
+package player_db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"game-server/internal/protocol"
+	"game-server/internal/protocol/internalpb"
+	"game-server/internal/transport"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrDBUnavailable = errors.New("db proxy unavailable")
+	ErrDBTimeout     = errors.New("db proxy request timeout")
+)
+
+// RemoteStore 通过 DB 代理进程访问存储，实现 Store 和 UID 分配；
+// 单连接多路复用，按 req_id 匹配响应，断线后下次调用时重连
+type RemoteStore struct {
+	addr        string
+	connOptions transport.ConnOptions
+	timeout     time.Duration
+	logger      *zap.Logger
+
+	mu      sync.Mutex
+	conn    *transport.BufferedConn
+	pending map[uint64]chan *internalpb.Envelope
+
+	nextReq uint64
+}
+
+func NewRemoteStore(addr string, options transport.ConnOptions, timeout time.Duration, logger *zap.Logger) *RemoteStore {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+	return &RemoteStore{
+		addr:        addr,
+		connOptions: options,
+		timeout:     timeout,
+		logger:      logger,
+		pending:     make(map[uint64]chan *internalpb.Envelope),
+	}
+}
+
+// =======================
+// Account ↔ Role
+// =======================
+func (s *RemoteStore) LoadRoleID(ctx context.Context, accountID string) (int64, bool, error) {
+	if accountID == "" {
+		return 0, false, nil
+	}
+	reqID := s.newReqID()
+	var rsp internalpb.DBLoadRoleRsp
+	if err := s.call(ctx, reqID, protocol.MsgDBLoadRoleReq, &internalpb.DBLoadRoleReq{
+		ReqId:     reqID,
+		AccountId: accountID,
+	}, &rsp); err != nil {
+		return 0, false, err
+	}
+	if rsp.Error != "" {
+		return 0, false, remoteError(rsp.Error)
+	}
+	return rsp.RoleId, rsp.Found, nil
+}
+
+func (s *RemoteStore) SaveRoleID(ctx context.Context, accountID string, roleID int64) error {
+	if accountID == "" || roleID == 0 {
+		return nil
+	}
+	reqID := s.newReqID()
+	var rsp internalpb.DBSaveRoleRsp
+	if err := s.call(ctx, reqID, protocol.MsgDBSaveRoleReq, &internalpb.DBSaveRoleReq{
+		ReqId:     reqID,
+		AccountId: accountID,
+		RoleId:    roleID,
+	}, &rsp); err != nil {
+		return err
+	}
+	if rsp.Error != "" {
+		return remoteError(rsp.Error)
+	}
+	return nil
+}
+
+// =======================
+// Player Profile
+// =======================
+func (s *RemoteStore) LoadProfile(ctx context.Context, roleID int64) (*PlayerProfile, bool, error) {
+	if roleID == 0 {
+		return nil, false, nil
+	}
+	reqID := s.newReqID()
+	var rsp internalpb.DBLoadProfileRsp
+	if err := s.call(ctx, reqID, protocol.MsgDBLoadProfileReq, &internalpb.DBLoadProfileReq{
+		ReqId:  reqID,
+		RoleId: roleID,
+	}, &rsp); err != nil {
+		return nil, false, err
+	}
+	if rsp.Error != "" {
+		return nil, false, remoteError(rsp.Error)
+	}
+	if !rsp.Found || rsp.Profile == nil {
+		return nil, false, nil
+	}
+	return FromDBProfile(rsp.Profile), true, nil
+}
+
+func (s *RemoteStore) SaveProfile(ctx context.Context, profile *PlayerProfile) error {
+	if profile == nil || profile.RoleID == 0 {
+		return nil
+	}
+	reqID := s.newReqID()
+	var rsp internalpb.DBSaveProfileRsp
+	if err := s.call(ctx, reqID, protocol.MsgDBSaveProfileReq, &internalpb.DBSaveProfileReq{
+		ReqId:   reqID,
+		Profile: ToDBProfile(profile),
+	}, &rsp); err != nil {
+		return err
+	}
+	if rsp.Error != "" {
+		return remoteError(rsp.Error)
+	}
+	return nil
+}
+
+// =======================
+// UID
+// =======================
+func (s *RemoteStore) NextUID(ctx context.Context) (int64, error) {
+	reqID := s.newReqID()
+	var rsp internalpb.DBNextUIDRsp
+	if err := s.call(ctx, reqID, protocol.MsgDBNextUIDReq, &internalpb.DBNextUIDReq{
+		ReqId: reqID,
+	}, &rsp); err != nil {
+		return 0, err
+	}
+	if rsp.Error != "" {
+		return 0, remoteError(rsp.Error)
+	}
+	return rsp.Uid, nil
+}
+
+// =======================
+// 连接与请求
+// =======================
+
+func (s *RemoteStore) newReqID() uint64 {
+	return atomic.AddUint64(&s.nextReq, 1)
+}
+
+func (s *RemoteStore) call(ctx context.Context, reqID uint64, msgID int, req proto.Message, rsp proto.Message) error {
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	conn, err := s.getConn()
+	if err != nil {
+		return err
+	}
+
+	ch := make(chan *internalpb.Envelope, 1)
+	s.mu.Lock()
+	s.pending[reqID] = ch
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, reqID)
+		s.mu.Unlock()
+	}()
+
+	if err := conn.WriteEnvelope(&internalpb.Envelope{
+		MsgId:   int32(msgID),
+		Payload: data,
+	}); err != nil {
+		s.dropConn(conn, err)
+		return fmt.Errorf("%w: %v", ErrDBUnavailable, err)
+	}
+
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+
+	select {
+	case env, ok := <-ch:
+		if !ok {
+			return ErrDBUnavailable
+		}
+		if env.MsgId == protocol.MsgDBErrorRsp {
+			var e internalpb.DBErrorRsp
+			if err := proto.Unmarshal(env.Payload, &e); err != nil {
+				return err
+			}
+			return remoteError(e.Error)
+		}
+		return proto.Unmarshal(env.Payload, rsp)
+	case <-timer.C:
+		return ErrDBTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *RemoteStore) getConn() (*transport.BufferedConn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		return s.conn, nil
+	}
+
+	nc, err := transport.Dial(s.addr, time.Second, s.connOptions)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBUnavailable, err)
+	}
+	conn := transport.NewBufferedConnWithOptions(nc, s.connOptions)
+	s.conn = conn
+	go s.readLoop(conn)
+
+	s.logger.Info("db proxy connected", zap.String("addr", s.addr))
+	return conn, nil
+}
+
+func (s *RemoteStore) readLoop(conn *transport.BufferedConn) {
+	for {
+		env, err := conn.ReadEnvelope()
+		if err != nil {
+			s.dropConn(conn, err)
+			return
+		}
+
+		var header internalpb.DBRspHeader
+		if err := proto.Unmarshal(env.Payload, &header); err != nil {
+			continue
+		}
+		// 持锁投递：dropConn 只关闭已从 pending 摘下的 channel，不会与这里并发
+		s.mu.Lock()
+		if ch := s.pending[header.ReqId]; ch != nil {
+			select {
+			case ch <- env:
+			default:
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// dropConn 关闭连接并让所有等待中的请求立即失败
+func (s *RemoteStore) dropConn(conn *transport.BufferedConn, reason error) {
+	s.mu.Lock()
+	if s.conn != conn {
+		s.mu.Unlock()
+		return
+	}
+	s.conn = nil
+	pending := s.pending
+	s.pending = make(map[uint64]chan *internalpb.Envelope)
+	s.mu.Unlock()
+
+	_ = conn.Close()
+	for _, ch := range pending {
+		close(ch)
+	}
+	s.logger.Warn("db proxy disconnected",
+		zap.String("addr", s.addr),
+		zap.String("reason", reason.Error()),
+	)
+}
+
+func remoteError(msg string) error {
+	return fmt.Errorf("db proxy: %s", msg)
+}
+
+// =======================
+// Profile <-> proto
+// =======================
+
+func ToDBProfile(p *PlayerProfile) *internalpb.DBProfile {
+	return &internalpb.DBProfile{
+		RoleId:    p.RoleID,
+		AccountId: p.AccountID,
+		Nickname:  p.NickName,
+		Level:     p.Level,
+		Exp:       p.Exp,
+		Gold:      p.Gold,
+		Stamina:   p.Stamina,
+	}
+}
+
+func FromDBProfile(p *internalpb.DBProfile) *PlayerProfile {
+	return &PlayerProfile{
+		RoleID:    p.RoleId,
+		AccountID: p.AccountId,
+		NickName:  p.Nickname,
+		Level:     p.Level,
+		Exp:       p.Exp,
+		Gold:      p.Gold,
+		Stamina:   p.Stamina,
+	}
+}
@@ -0,0 +1,30 @@
+// internal/protocol/codec/json.go
+package codec
+
+import (
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// jsonCodec protojson 映射：字段名 lowerCamelCase，64 位整数和 bytes 为字符串；
+// 上行忽略未知字段，网页工具可以比服务端协议新
+type jsonCodec struct{}
+
+var (
+	jsonMarshal   = protojson.MarshalOptions{}
+	jsonUnmarshal = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+func (jsonCodec) Name() string { return NameJSON }
+
+func (jsonCodec) Marshal(m proto.Message) ([]byte, error) {
+	return jsonMarshal.Marshal(m)
+}
+
+func (jsonCodec) Unmarshal(data []byte, m proto.Message) error {
+	if len(data) == 0 {
+		proto.Reset(m)
+		return nil
+	}
+	return jsonUnmarshal.Unmarshal(data, m)
+}
@@ -0,0 +1,68 @@
+// internal/protocol/codec/msgpack.go
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// msgpackCodec 结构与 protojson 一致（字段名、枚举名、64 位整数为字符串），只是换成 msgpack 承载；
+// 经 JSON 中转，性能不如 protobuf，面向脚本 / 工具客户端。上行的 bytes 字段可以直接用 bin 类型
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return NameMsgPack }
+
+func (msgpackCodec) Marshal(m proto.Message) ([]byte, error) {
+	data, err := jsonMarshal.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return msgpack.Marshal(msgpackValue(v))
+}
+
+func (msgpackCodec) Unmarshal(data []byte, m proto.Message) error {
+	if len(data) == 0 {
+		proto.Reset(m)
+		return nil
+	}
+	var v any
+	if err := msgpack.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	// []byte 经 encoding/json 变为 base64 字符串，正好是 protojson 的 bytes 格式
+	js, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return jsonUnmarshal.Unmarshal(js, m)
+}
+
+// msgpackValue 把 json.Number 还原成整数 / 浮点，否则 msgpack 会把它当字符串编码
+func msgpackValue(v any) any {
+	switch x := v.(type) {
+	case json.Number:
+		if n, err := x.Int64(); err == nil {
+			return n
+		}
+		f, _ := x.Float64()
+		return f
+	case map[string]any:
+		for k, item := range x {
+			x[k] = msgpackValue(item)
+		}
+	case []any:
+		for i, item := range x {
+			x[i] = msgpackValue(item)
+		}
+	}
+	return v
+}
@@ -1 +1,87 @@
+// internal/protocol/codec/packet.go
 package codec
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"game-server/internal/protocol/internalpb"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrUnknownCodec = errors.New("unknown codec")
+
+// 编码名，握手时协商，写在 Envelope.Codec 上
+const (
+	NameProtobuf = "protobuf"
+	NameJSON     = "json"
+	NameMsgPack  = "msgpack"
+)
+
+// Codec payload 的编解码；Envelope 本身的帧格式由 transport 决定，与这里无关
+type Codec interface {
+	Name() string
+	Marshal(m proto.Message) ([]byte, error)
+	Unmarshal(data []byte, m proto.Message) error
+}
+
+var (
+	Protobuf Codec = protobufCodec{}
+	JSON     Codec = jsonCodec{}
+	MsgPack  Codec = msgpackCodec{}
+)
+
+// codecs 握手时按这个顺序列给客户端，只在 init 阶段修改
+var codecs = []Codec{Protobuf, JSON, MsgPack}
+
+// Register 注册自定义编码，需在启动监听之前调用；同名覆盖
+func Register(c Codec) {
+	for i, have := range codecs {
+		if have.Name() == c.Name() {
+			codecs[i] = c
+			return
+		}
+	}
+	codecs = append(codecs, c)
+}
+
+// Get 空名字视为 protobuf（老客户端和内部消息不带编码）
+func Get(name string) (Codec, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return Protobuf, nil
+	}
+	for _, c := range codecs {
+		if c.Name() == name {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownCodec, name)
+}
+
+func Names() []string {
+	names := make([]string, len(codecs))
+	for i, c := range codecs {
+		names[i] = c.Name()
+	}
+	return names
+}
+
+// Decode 按 env.Codec 解出 payload；业务 handler 不关心客户端用的哪种编码
+func Decode(env *internalpb.Envelope, m proto.Message) error {
+	c, err := Get(env.GetCodec())
+	if err != nil {
+		return err
+	}
+	return c.Unmarshal(env.GetPayload(), m)
+}
+
+// Encode 按 name 编码回包；name 一般取请求的 Envelope.Codec，保证客户端收到自己能解的格式
+func Encode(name string, m proto.Message) ([]byte, error) {
+	c, err := Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.Marshal(m)
+}
@@ -1 +1,16 @@
+// internal/protocol/codec/protobuf.go
 package codec
+
+import "google.golang.org/protobuf/proto"
+
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string { return NameProtobuf }
+
+func (protobufCodec) Marshal(m proto.Message) ([]byte, error) {
+	return proto.Marshal(m)
+}
+
+func (protobufCodec) Unmarshal(data []byte, m proto.Message) error {
+	return proto.Unmarshal(data, m)
+}
@@ -14,6 +14,7 @@ const (
 	ErrInvalidToken    ErrorCode = 1003
 	ErrSessionExpired  ErrorCode = 1004
 	ErrUnknownPlatform ErrorCode = 10005
+	ErrRateLimited     ErrorCode = 1006
 
 	// ---- Login ----
 	ErrLoginFailed ErrorCode = 1100
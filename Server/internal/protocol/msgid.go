@@ -1,61 +1,104 @@
-// internal/protocol/msgid.go
+// Code generated by protoc-gen-msgid. DO NOT EDIT.
+// source: chat.proto, db.proto, error.proto, game.proto, gate.proto, login.proto
+
 package protocol
 
+// 号段 [Begin, End)，见 options.proto 的 MsgRange
+const (
+	MsgGateBegin  = 0 // Gate / Framework
+	MsgGateEnd    = 1000
+	MsgLoginBegin = 1000 // Login / Account（Service）
+	MsgLoginEnd   = 2000
+	MsgChatBegin  = 2000 // Chat / Social（Service）
+	MsgChatEnd    = 3000
+	MsgGameBegin  = 3000 // Game Logic（Game）
+	MsgGameEnd    = 4000
+	MsgDBBegin    = 4000 // DB Proxy（仅服务间使用，客户端不可直达）
+	MsgDBEnd      = 5000
+)
+
 // =======================
 // Gate / Framework
 // =======================
 const (
-	// Gate / Framework
-	MsgGateBegin   = 0
-	MsgResumeReq   = 1
-	MsgResumeRsp   = 2
-	MsgSessionInit = 3
-
-	MsgHeartbeatReq = 10
-	MsgHeartbeatRsp = 11
-
-	MsgErrorRsp = 21
-
-	MsgServicePing = 31
-	MsgServicePong = 32
-
-	MsgGamePing = 33
-	MsgGamePong = 34
+	MsgResumeReq          = 1  // ResumeReq
+	MsgResumeRsp          = 2  // ResumeRsp
+	MsgSessionInit        = 3  // SessionInit
+	MsgHandshakeReq       = 5  // HandshakeReq 客户端连上后的第一条消息，在 Login / Resume 之前发送（gate 配置 required 时必须发送）
+	MsgHandshakeRsp       = 6  // HandshakeRsp
+	MsgHeartbeatReq       = 10 // HeartbeatReq 心跳：payload 为空
+	MsgHeartbeatRsp       = 11 // HeartbeatRsp
+	MsgErrorRsp           = 21 // ErrorRsp
+	MsgServicePing        = 31 // ServicePing（仅服务间） 后端健康检查：payload 为空，直接在收到 Ping 的连接上回 Pong
+	MsgServicePong        = 32 // ServicePong（仅服务间）
+	MsgGamePing           = 33 // GamePing（仅服务间）
+	MsgGamePong           = 34 // GamePong（仅服务间）
+	MsgGateRegister       = 35 // GateRegister（仅服务间） Gate 连上后端后发送的第一条消息，后端据此识别同一个 Gate 的多条连接
+	MsgGateControl        = 40 // GateControl（仅服务间） 后端（service / game）发给 Gate 的控制消息，msg_id = MsgGateControl
+	MsgPlayerMigrateReq   = 41 // PlayerMigrateReq（仅服务间） Gate → 源 Game：冻结玩家、落盘并释放所有权
+	MsgPlayerMigrateRsp   = 42 // PlayerMigrateRsp（仅服务间） 源 Game → Gate：pending 为冻结后到达源服的消息，由 Gate 重放到目标服
+	MsgPlayerMigrateIn    = 43 // PlayerMigrateIn（仅服务间） Gate → 目标 Game：接管玩家（清理本服的迁出标记并预加载）
+	MsgPlayerShardChanged = 44 // PlayerShardChanged（仅服务间） Gate → Service：玩家所在 game 服变化，清理本地选服缓存
+)
 
-	MsgGateEnd = 1000
+// =======================
+// Login / Account（Service）
+// =======================
+const (
+	MsgLoginReq = 1001 // LoginReq
+	MsgLoginRsp = 1002 // LoginRsp
 )
 
 // =======================
-// Login / Account (Service)
+// Chat / Social（Service）
 // =======================
 const (
-	// Login / Account
-	MsgLoginBegin = 1000
-	MsgLoginReq   = 1001
-	MsgLoginRsp   = 1002
-	MsgLoginEnd   = 2000
+	MsgChatSendReq = 2001 // ChatSendReq 聊天协议还没定，先占住消息号
+	MsgChatSendRsp = 2002 // ChatSendRsp
 )
 
+// =======================
+// Game Logic（Game）
+// =======================
 const (
-	// Chat / Social
-	MsgChatBegin   = 2000
-	MsgChatSendReq = 2001
-	MsgChatSendRsp = 2002
-	MsgChatEnd     = 3000
+	MsgPlayerEnterGameReq  = 3001 // PlayerEnterGameReq 登录成功后 Service 转发给 Game，payload 为空
+	MsgPlayerEnterGameRsp  = 3002 // PlayerInitRsp
+	MsgLoadPlayerDataReq   = 3003 // LoadPlayerDataReq
+	MsgLoadPlayerDataRsp   = 3004 // LoadPlayerDataRsp
+	MsgPlayerResumeReq     = 3005 // PlayerResumeReq（仅服务间） Gate → Game：会话恢复后通知 Game，payload 为空
+	MsgPlayerOfflineNotify = 3006 // PlayerOfflineNotify（仅服务间） Gate → Game：会话下线，payload 为空
 )
 
 // =======================
-// Game Logic (Game)
+// DB Proxy（仅服务间使用，客户端不可直达）
 // =======================
 const (
-	// Game Logic
-	MsgGameBegin           = 3000
-	MsgPlayerEnterGameReq  = 3001
-	MsgPlayerEnterGameRsp  = 3002
-	MsgLoadPlayerDataReq   = 3003
-	MsgLoadPlayerDataRsp   = 3004
-	MsgPlayerResumeReq     = 3005
-	MsgPlayerOfflineNotify = 3006
-	//MsgPlayerReEnterGameReq = 3007
-	MsgGameEnd = 4000
+	MsgDBLoadRoleReq    = 4001 // DBLoadRoleReq（仅服务间）
+	MsgDBLoadRoleRsp    = 4002 // DBLoadRoleRsp（仅服务间）
+	MsgDBSaveRoleReq    = 4003 // DBSaveRoleReq（仅服务间）
+	MsgDBSaveRoleRsp    = 4004 // DBSaveRoleRsp（仅服务间）
+	MsgDBLoadProfileReq = 4005 // DBLoadProfileReq（仅服务间）
+	MsgDBLoadProfileRsp = 4006 // DBLoadProfileRsp（仅服务间）
+	MsgDBSaveProfileReq = 4007 // DBSaveProfileReq（仅服务间）
+	MsgDBSaveProfileRsp = 4008 // DBSaveProfileRsp（仅服务间）
+	MsgDBNextUIDReq     = 4009 // DBNextUIDReq（仅服务间）
+	MsgDBNextUIDRsp     = 4010 // DBNextUIDRsp（仅服务间）
+	MsgDBErrorRsp       = 4011 // DBErrorRsp（仅服务间） 未知 msgID / 请求解析失败时的通用回包，调用方据 req_id 立即失败而不是等到超时
 )
+
+// MsgScope 返回 msgID 所在号段（gate / login / chat / game / db），不在任何号段时返回空
+func MsgScope(msgID int) string {
+	switch {
+	case msgID >= MsgGateBegin && msgID < MsgGateEnd:
+		return "gate"
+	case msgID >= MsgLoginBegin && msgID < MsgLoginEnd:
+		return "login"
+	case msgID >= MsgChatBegin && msgID < MsgChatEnd:
+		return "chat"
+	case msgID >= MsgGameBegin && msgID < MsgGameEnd:
+		return "game"
+	case msgID >= MsgDBBegin && msgID < MsgDBEnd:
+		return "db"
+	}
+	return ""
+}
@@ -0,0 +1,200 @@
+// internal/protocol/msgtype/registry.go
+package msgtype
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"game-server/internal/protocol"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var (
+	ErrDuplicateMsgID = errors.New("duplicate msgID")
+	ErrUnboundMsgID   = errors.New("msgID has no message type bound")
+	ErrTypeMismatch   = errors.New("message type mismatch")
+	ErrNoReply        = errors.New("msgID has no reply bound")
+)
+
+// Message msgID 与 payload 类型的绑定；没有 payload 的消息在 .proto 里也定义一个空消息
+type Message struct {
+	ID       int
+	Name     string // 常量名，如 MsgLoginReq
+	Type     protoreflect.MessageType
+	Reply    int  // 请求对应的回包 msgID，0 表示没有回包（推送 / 通知）
+	Internal bool // 只在服务间使用，客户端不会收到也不能发送
+}
+
+// Of 取生成类型的 MessageType，用于填表：msgtype.Of[*internalpb.LoginReq]()
+func Of[T proto.Message]() protoreflect.MessageType {
+	var zero T
+	return zero.ProtoReflect().Type()
+}
+
+// Registry msgID → Message；启动时由 init 填充，之后只读
+type Registry struct {
+	mu   sync.RWMutex
+	byID map[int]Message
+	errs []error // Bind 时发现的重复，留到 Validate 统一报
+}
+
+func NewRegistry() *Registry {
+	return &Registry{byID: make(map[int]Message)}
+}
+
+// Bind 绑定一批消息；重复的 msgID 记下来由 Validate 报错，先绑定的生效
+func (r *Registry) Bind(msgs ...Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range msgs {
+		if old, ok := r.byID[m.ID]; ok {
+			r.errs = append(r.errs, fmt.Errorf("%w: %d bound to %s and %s", ErrDuplicateMsgID, m.ID, old.Name, m.Name))
+			continue
+		}
+		r.byID[m.ID] = m
+	}
+}
+
+func (r *Registry) Lookup(msgID int) (Message, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m, ok := r.byID[msgID]
+	return m, ok
+}
+
+// New 按 msgID 创建一个空的 payload 消息
+func (r *Registry) New(msgID int) (proto.Message, error) {
+	m, ok := r.Lookup(msgID)
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnboundMsgID, msgID)
+	}
+	return m.Type.New().Interface(), nil
+}
+
+// Messages 按 msgID 升序
+func (r *Registry) Messages() []Message {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Message, 0, len(r.byID))
+	for _, m := range r.byID {
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Validate 启动时调用：重复绑定、缺类型、回包指向未绑定的 msgID 都在这里报出来
+func (r *Registry) Validate() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	errs := append([]error(nil), r.errs...)
+	for _, m := range r.byID {
+		if m.Type == nil {
+			errs = append(errs, fmt.Errorf("%w: %d (%s)", ErrUnboundMsgID, m.ID, m.Name))
+		}
+		if m.Reply == 0 {
+			continue
+		}
+		if _, ok := r.byID[m.Reply]; !ok {
+			errs = append(errs, fmt.Errorf("%w: reply %d of %s", ErrUnboundMsgID, m.Reply, m.Name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Require 校验 msgID 已绑定且类型为 T；handler 注册时调用，把缺失在启动阶段暴露出来
+func Require[T proto.Message](r *Registry, msgID int) (Message, error) {
+	m, ok := r.Lookup(msgID)
+	if !ok || m.Type == nil {
+		return m, fmt.Errorf("%w: %d", ErrUnboundMsgID, msgID)
+	}
+	if want := Of[T]().Descriptor().FullName(); m.Type.Descriptor().FullName() != want {
+		return m, fmt.Errorf("%w: %s is %s, handler takes %s", ErrTypeMismatch, m.Name, m.Type.Descriptor().FullName(), want)
+	}
+	return m, nil
+}
+
+// RequireReply 校验 msgID 的请求类型为 Req、回包类型为 Rsp，返回回包的 msgID
+func RequireReply[Req, Rsp proto.Message](r *Registry, msgID int) (int, error) {
+	m, err := Require[Req](r, msgID)
+	if err != nil {
+		return 0, err
+	}
+	if m.Reply == 0 {
+		return 0, fmt.Errorf("%w: %s", ErrNoReply, m.Name)
+	}
+	if _, err := Require[Rsp](r, m.Reply); err != nil {
+		return 0, err
+	}
+	return m.Reply, nil
+}
+
+// entry Dump 输出的一行，字段名给客户端工具用，改名需同步
+type entry struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Reply    int    `json:"reply,omitempty"`
+	Scope    string `json:"scope"`
+	Internal bool   `json:"internal,omitempty"`
+}
+
+// Dump 以 JSON 输出完整的 msgID 表，给客户端 / 工具团队对照
+func (r *Registry) Dump(w io.Writer) error {
+	msgs := r.Messages()
+	out := make([]entry, 0, len(msgs))
+	for _, m := range msgs {
+		e := entry{ID: m.ID, Name: m.Name, Reply: m.Reply, Scope: scope(m.ID), Internal: m.Internal}
+		if m.Type != nil {
+			e.Type = string(m.Type.Descriptor().FullName())
+		}
+		out = append(out, e)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// scope 按 msgid.go 的号段划分
+func scope(id int) string {
+	if s := protocol.MsgScope(id); s != "" {
+		return s
+	}
+	return "unknown"
+}
+
+// Default 内置协议表，见 table.go（由 protoc-gen-msgid 生成）；业务模块可在 init 里 Bind 自己的消息
+var Default = NewRegistry()
+
+func Bind(msgs ...Message)                 { Default.Bind(msgs...) }
+func Lookup(msgID int) (Message, bool)     { return Default.Lookup(msgID) }
+func New(msgID int) (proto.Message, error) { return Default.New(msgID) }
+func Messages() []Message                  { return Default.Messages() }
+func Validate() error                      { return Default.Validate() }
+func Dump(w io.Writer) error               { return Default.Dump(w) }
+
+// MustValidate 进程启动时调用：内置表或 checks（如 game 的模块注册检查）有任何错误即记录并退出，拒绝带着错表启动
+func MustValidate(logger *zap.Logger, checks ...func() error) {
+	errs := []error{Validate()}
+	for _, check := range checks {
+		errs = append(errs, check())
+	}
+	if err := errors.Join(errs...); err != nil {
+		logger.Error("invalid msgID table",
+			zap.String("reason", err.Error()),
+			zap.Int("msg_id", 0),
+			zap.Int64("session", 0),
+			zap.Int64("player", 0),
+			zap.Int64("conn_id", 0),
+			zap.String("trace_id", ""),
+		)
+		os.Exit(1)
+	}
+}
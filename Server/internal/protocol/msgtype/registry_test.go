@@ -0,0 +1,165 @@
+package msgtype_test
+
+import (
+	"errors"
+	"testing"
+
+	"game-server/internal/handler"
+	"game-server/internal/protocol"
+	"game-server/internal/protocol/codec"
+	"game-server/internal/protocol/internalpb"
+	"game-server/internal/protocol/msgtype"
+	"game-server/internal/service"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDefaultTableValid(t *testing.T) {
+	if err := msgtype.Validate(); err != nil {
+		t.Fatalf("built-in table invalid: %v", err)
+	}
+}
+
+func TestBindDuplicate(t *testing.T) {
+	r := msgtype.NewRegistry()
+	r.Bind(
+		msgtype.Message{ID: 5001, Name: "MsgFirst", Type: msgtype.Of[*internalpb.LoginReq]()},
+		msgtype.Message{ID: 5001, Name: "MsgSecond", Type: msgtype.Of[*internalpb.ChatSendReq]()},
+	)
+	if err := r.Validate(); !errors.Is(err, msgtype.ErrDuplicateMsgID) {
+		t.Fatalf("Validate = %v, want ErrDuplicateMsgID", err)
+	}
+	// 先绑定的生效
+	if m, ok := r.Lookup(5001); !ok || m.Name != "MsgFirst" {
+		t.Fatalf("Lookup = %+v, %v; want MsgFirst", m, ok)
+	}
+}
+
+func TestValidateUnbound(t *testing.T) {
+	cases := map[string]msgtype.Message{
+		"missing type":  {ID: 5002, Name: "MsgNoType"},
+		"missing reply": {ID: 5003, Name: "MsgReq", Type: msgtype.Of[*internalpb.LoginReq](), Reply: 5004},
+	}
+	for name, m := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := msgtype.NewRegistry()
+			r.Bind(m)
+			if err := r.Validate(); !errors.Is(err, msgtype.ErrUnboundMsgID) {
+				t.Fatalf("Validate = %v, want ErrUnboundMsgID", err)
+			}
+		})
+	}
+
+	r := msgtype.NewRegistry()
+	if _, err := r.New(5005); !errors.Is(err, msgtype.ErrUnboundMsgID) {
+		t.Fatalf("New on unbound id = %v, want ErrUnboundMsgID", err)
+	}
+	if _, err := msgtype.Require[*internalpb.LoginReq](r, 5005); !errors.Is(err, msgtype.ErrUnboundMsgID) {
+		t.Fatalf("Require on unbound id = %v, want ErrUnboundMsgID", err)
+	}
+}
+
+func TestRequireReplyMismatch(t *testing.T) {
+	r := msgtype.NewRegistry()
+	r.Bind(
+		msgtype.Message{ID: 5010, Name: "MsgReq", Type: msgtype.Of[*internalpb.LoginReq](), Reply: 5011},
+		msgtype.Message{ID: 5011, Name: "MsgRsp", Type: msgtype.Of[*internalpb.LoginRsp]()},
+		msgtype.Message{ID: 5012, Name: "MsgNotify", Type: msgtype.Of[*internalpb.PlayerOfflineNotify]()},
+	)
+	if err := r.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	if id, err := msgtype.RequireReply[*internalpb.LoginReq, *internalpb.LoginRsp](r, 5010); err != nil || id != 5011 {
+		t.Fatalf("RequireReply = %d, %v; want 5011", id, err)
+	}
+	if _, err := msgtype.RequireReply[*internalpb.ChatSendReq, *internalpb.LoginRsp](r, 5010); !errors.Is(err, msgtype.ErrTypeMismatch) {
+		t.Fatalf("request type mismatch = %v, want ErrTypeMismatch", err)
+	}
+	if _, err := msgtype.RequireReply[*internalpb.LoginReq, *internalpb.ChatSendRsp](r, 5010); !errors.Is(err, msgtype.ErrTypeMismatch) {
+		t.Fatalf("reply type mismatch = %v, want ErrTypeMismatch", err)
+	}
+	if _, err := msgtype.RequireReply[*internalpb.PlayerOfflineNotify, *internalpb.LoginRsp](r, 5012); !errors.Is(err, msgtype.ErrNoReply) {
+		t.Fatalf("no reply = %v, want ErrNoReply", err)
+	}
+
+	if m, err := r.New(5011); err != nil || proto.MessageName(m) != proto.MessageName(&internalpb.LoginRsp{}) {
+		t.Fatalf("New(5011) = %T, %v", m, err)
+	}
+}
+
+// service.Register 按表解出请求、按表里的回包 msgID 回包
+func TestServiceRegisterRoundTrip(t *testing.T) {
+	reg := handler.NewRegistry[service.HandlerFunc]()
+	err := service.Register(reg, protocol.MsgLoginReq, func(ctx *service.Context, req *internalpb.LoginReq) (*internalpb.LoginRsp, error) {
+		if req.Token == "" {
+			return nil, nil // 已自行回包
+		}
+		return &internalpb.LoginRsp{PlayerId: int64(len(req.Token))}, nil
+	})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	h, ok := reg.Get(protocol.MsgLoginReq)
+	if !ok {
+		t.Fatal("handler not registered")
+	}
+
+	for _, codecName := range []string{"", codec.NameJSON} {
+		t.Run("codec="+codecName, func(t *testing.T) {
+			payload, err := codec.Encode(codecName, &internalpb.LoginReq{Platform: 1, Token: "abcd"})
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			var replyID int
+			var replyData []byte
+			ctx := &service.Context{
+				MsgID:   protocol.MsgLoginReq,
+				Payload: payload,
+				Codec:   codecName,
+				Reply: func(msgID int, data []byte) error {
+					replyID, replyData = msgID, data
+					return nil
+				},
+			}
+			if err := h(ctx); err != nil {
+				t.Fatalf("handler: %v", err)
+			}
+			if replyID != protocol.MsgLoginRsp {
+				t.Fatalf("reply msg %d, want MsgLoginRsp", replyID)
+			}
+			cc, err := codec.Get(codecName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var rsp internalpb.LoginRsp
+			if err := cc.Unmarshal(replyData, &rsp); err != nil || rsp.PlayerId != 4 {
+				t.Fatalf("reply = %v, %v; want player_id 4", &rsp, err)
+			}
+		})
+	}
+
+	// nil 回包不再回复；请求解不开时返回错误
+	replied := false
+	ctx := &service.Context{Reply: func(int, []byte) error { replied = true; return nil }}
+	ctx.Payload, _ = proto.Marshal(&internalpb.LoginReq{})
+	if err := h(ctx); err != nil || replied {
+		t.Fatalf("nil rsp: err %v, replied %v", err, replied)
+	}
+	ctx.Payload = []byte{0xff}
+	if err := h(ctx); err == nil {
+		t.Fatal("undecodable request accepted")
+	}
+}
+
+func TestServiceRegisterRejectsMismatch(t *testing.T) {
+	reg := handler.NewRegistry[service.HandlerFunc]()
+	err := service.Register(reg, protocol.MsgLoginReq, func(*service.Context, *internalpb.ChatSendReq) (*internalpb.LoginRsp, error) {
+		return nil, nil
+	})
+	if !errors.Is(err, msgtype.ErrTypeMismatch) {
+		t.Fatalf("Register with wrong request type = %v, want ErrTypeMismatch", err)
+	}
+	if _, ok := reg.Get(protocol.MsgLoginReq); ok {
+		t.Fatal("mismatched handler was registered")
+	}
+}
@@ -0,0 +1,5 @@
+// internal/protocol/version.go
+package protocol
+
+// ProtocolVersion 当前客户端协议版本；不兼容的改动需要加一（gate 配置可接受一个区间）
+const ProtocolVersion = 1
@@ -0,0 +1,70 @@
+// internal/router/config.go
+package router
+
+import (
+	"fmt"
+
+	"game-server/internal/config"
+	"game-server/internal/db/redis_tools"
+)
+
+// LoadFile 读取路由配置并原子替换路由表；path 为空时保留内置路由
+func LoadFile(path string) (*Table, error) {
+	if path == "" {
+		return Current(), nil
+	}
+	var cfg config.RouteTableConfig
+	if err := config.Load(path, &cfg); err != nil {
+		return nil, err
+	}
+	entries := make([]RouteEntry, 0, len(cfg.Routes))
+	for _, item := range cfg.Routes {
+		target, err := ParseTarget(item.Target)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, RouteEntry{
+			MsgID:    item.MsgID,
+			MsgBegin: item.MsgBegin,
+			MsgEnd:   item.MsgEnd,
+			Target:   target,
+			Module:   item.Module,
+		})
+	}
+	return Load(entries)
+}
+
+// SelectorFromConfig games 为空时退化为 game_addr 单服；都没配置时返回 nil。
+// assign=redis 时按 game_shards.redis 新建独立的 client，不复用进程里的全局 Redis
+func SelectorFromConfig(gameAddr string, cfg config.GameShardsConfig) (*ShardSelector, error) {
+	shards := make([]GameShard, 0, len(cfg.Games))
+	for _, item := range cfg.Games {
+		shards = append(shards, GameShard{ID: item.ID, Addr: item.Addr})
+	}
+	if len(shards) == 0 {
+		if gameAddr == "" {
+			return nil, nil
+		}
+		shards = append(shards, GameShard{ID: "game-1", Addr: gameAddr})
+	}
+
+	var store ShardAssignStore
+	switch cfg.Assign {
+	case "", "hash":
+	case "redis":
+		client, err := redis_tools.NewClient(redis_tools.RedisConfig{
+			Addr:         cfg.Redis.Addr,
+			Password:     cfg.Redis.Password,
+			DB:           cfg.Redis.DB,
+			PoolSize:     cfg.Redis.PoolSize,
+			MinIdleConns: cfg.Redis.MinIdleConns,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("init shard redis: %w", err)
+		}
+		store = NewRedisShardStore(redis_tools.NewRedisDaoWithClient(client))
+	default:
+		return nil, fmt.Errorf("unknown shard assign mode: %s", cfg.Assign)
+	}
+	return NewShardSelector(shards, store)
+}
@@ -0,0 +1,76 @@
+// internal/router/hash_ring.go
+package router
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// HashRing 一致性哈希环：每个节点按 name#i 放 replicas 个虚拟节点。
+// 建好之后只读，节点变化时整体重建；game 分服和 Gate 的 service 端点共用
+type HashRing[T any] struct {
+	hashes []uint64
+	nodes  []T
+}
+
+// NewHashRing name 返回节点在环上的标识，只有它参与哈希
+func NewHashRing[T any](nodes []T, name func(T) string, replicas int) *HashRing[T] {
+	type point struct {
+		hash uint64
+		node T
+	}
+	points := make([]point, 0, len(nodes)*replicas)
+	for _, node := range nodes {
+		id := name(node)
+		for i := 0; i < replicas; i++ {
+			points = append(points, point{hash: HashString(id + "#" + strconv.Itoa(i)), node: node})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].hash < points[j].hash })
+
+	r := &HashRing[T]{
+		hashes: make([]uint64, 0, len(points)),
+		nodes:  make([]T, 0, len(points)),
+	}
+	for _, pt := range points {
+		r.hashes = append(r.hashes, pt.hash)
+		r.nodes = append(r.nodes, pt.node)
+	}
+	return r
+}
+
+// Get 顺时针找到第一个不小于 key 的虚拟节点；环为空时返回 false
+func (r *HashRing[T]) Get(key uint64) (T, bool) {
+	if len(r.hashes) == 0 {
+		var zero T
+		return zero, false
+	}
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= key })
+	if i == len(r.hashes) {
+		i = 0
+	}
+	return r.nodes[i], true
+}
+
+// HashString 节点标识的哈希
+func HashString(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	return mix64(h.Sum64())
+}
+
+// HashID playerID / sessionID 的哈希
+func HashID(id int64) uint64 {
+	return mix64(uint64(id))
+}
+
+// mix64 murmur3 finalizer：FNV 对连续的小整数 / 相近字符串高位几乎不变，需要再打散
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
@@ -0,0 +1,75 @@
+package router
+
+import "testing"
+
+func TestHashRingEmpty(t *testing.T) {
+	r := NewHashRing[string](nil, func(s string) string { return s }, 16)
+	if node, ok := r.Get(HashID(1)); ok || node != "" {
+		t.Fatalf("Get on empty ring = %q, %v", node, ok)
+	}
+}
+
+func TestHashRingStableAndBalanced(t *testing.T) {
+	nodes := []string{"a", "b", "c", "d"}
+	name := func(s string) string { return s }
+	r := NewHashRing(nodes, name, shardRingReplicas)
+	// 节点顺序不影响结果
+	reversed := NewHashRing([]string{"d", "c", "b", "a"}, name, shardRingReplicas)
+
+	const keys = 40000
+	counts := make(map[string]int)
+	for id := int64(1); id <= keys; id++ {
+		node, ok := r.Get(HashID(id))
+		if !ok {
+			t.Fatalf("no node for %d", id)
+		}
+		if again, _ := reversed.Get(HashID(id)); again != node {
+			t.Fatalf("key %d: %s vs %s depending on node order", id, node, again)
+		}
+		counts[node]++
+	}
+	for _, n := range nodes {
+		if share := float64(counts[n]) / keys; share < 0.15 || share > 0.35 {
+			t.Fatalf("node %s got %.2f of keys: %v", n, share, counts)
+		}
+	}
+}
+
+func TestHashRingRemoveMovesOnlyThatNode(t *testing.T) {
+	name := func(s string) string { return s }
+	before := NewHashRing([]string{"a", "b", "c"}, name, shardRingReplicas)
+	after := NewHashRing([]string{"a", "c"}, name, shardRingReplicas)
+
+	for id := int64(1); id <= 10000; id++ {
+		was, _ := before.Get(HashID(id))
+		now, _ := after.Get(HashID(id))
+		if was != "b" && was != now {
+			t.Fatalf("key %d moved from %s to %s though %s stayed", id, was, now, was)
+		}
+	}
+}
+
+// 环上的位置和合并前 ShardSelector / servicePool 各自的实现一致，改动哈希会让升级后的玩家和会话换节点
+func TestHashRingPointsUnchanged(t *testing.T) {
+	if h := HashString("game-1#0"); h != 0x930d863ad8c6c326 {
+		t.Fatalf("HashString = %#x", h)
+	}
+	if h := HashString("127.0.0.1:9100#7"); h != 0x7d700ebae2d5cab2 {
+		t.Fatalf("HashString = %#x", h)
+	}
+	if h := HashID(42); h != 0x810879608e4259cc {
+		t.Fatalf("HashID = %#x", h)
+	}
+
+	shards := []GameShard{{ID: "game-1", Addr: "a"}, {ID: "game-2", Addr: "b"}, {ID: "game-3", Addr: "c"}}
+	s, err := NewShardSelector(shards, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"game-3", "game-1", "game-3", "game-3", "game-1", "game-1", "game-3", "game-1"}
+	for i, id := range want {
+		if got := s.hashShard(int64(i + 1)); got.ID != id {
+			t.Fatalf("player %d on %s, want %s", i+1, got.ID, id)
+		}
+	}
+}
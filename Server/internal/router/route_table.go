@@ -1,6 +1,15 @@
 // internal/router/route_table.go
 package router
 
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"game-server/internal/protocol"
+)
+
 type TargetType int
 
 const (
@@ -9,13 +18,126 @@ const (
 	TargetDB
 )
 
+var ErrInvalidRoute = errors.New("invalid route")
+
+func (t TargetType) String() string {
+	switch t {
+	case TargetService:
+		return "service"
+	case TargetGame:
+		return "game"
+	case TargetDB:
+		return "db"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseTarget 配置里的 target 字符串 → TargetType
+func ParseTarget(s string) (TargetType, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "service":
+		return TargetService, nil
+	case "game":
+		return TargetGame, nil
+	case "db":
+		return TargetDB, nil
+	default:
+		return 0, fmt.Errorf("%w: unknown target %q", ErrInvalidRoute, s)
+	}
+}
+
 type RouteRule struct {
 	Target TargetType
 	Module string // service 模块名
 }
 
-var routeTable = []RouteRule{
-	{Target: TargetService, Module: "login"},
-	{Target: TargetService, Module: "chat"},
-	{Target: TargetGame, Module: ""},
+// RouteEntry 一条路由配置：MsgID 非 0 时为单个 id，否则为区间 [MsgBegin, MsgEnd)
+type RouteEntry struct {
+	MsgID    int
+	MsgBegin int
+	MsgEnd   int
+	Target   TargetType
+	Module   string
+}
+
+type rangeRule struct {
+	begin int
+	end   int
+	rule  RouteRule
+}
+
+// Table 只读路由表；更新时整体替换，不在原表上修改
+type Table struct {
+	ids    map[int]RouteRule
+	ranges []rangeRule // 按 begin 升序，互不重叠
+}
+
+// NewTable 校验并构建路由表：单个 id 优先于区间；区间之间不允许重叠；
+// Gate 内部消息区间 [0, MsgGateEnd) 不走路由；空表视为配置错误（例如 routes 写错了键名），
+// 否则热加载会把所有上行消息变成无路由
+func NewTable(entries []RouteEntry) (*Table, error) {
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: no routes", ErrInvalidRoute)
+	}
+	t := &Table{ids: make(map[int]RouteRule)}
+	for _, e := range entries {
+		rule := RouteRule{Target: e.Target, Module: e.Module}
+		if e.Target == TargetService && e.Module == "" {
+			return nil, fmt.Errorf("%w: service route requires module", ErrInvalidRoute)
+		}
+		if e.MsgID != 0 {
+			if e.MsgID < protocol.MsgGateEnd {
+				return nil, fmt.Errorf("%w: msg_id %d in gate range", ErrInvalidRoute, e.MsgID)
+			}
+			if _, ok := t.ids[e.MsgID]; ok {
+				return nil, fmt.Errorf("%w: duplicate msg_id %d", ErrInvalidRoute, e.MsgID)
+			}
+			t.ids[e.MsgID] = rule
+			continue
+		}
+		if e.MsgBegin >= e.MsgEnd {
+			return nil, fmt.Errorf("%w: empty range [%d, %d)", ErrInvalidRoute, e.MsgBegin, e.MsgEnd)
+		}
+		if e.MsgBegin < protocol.MsgGateEnd {
+			return nil, fmt.Errorf("%w: range [%d, %d) overlaps gate range", ErrInvalidRoute, e.MsgBegin, e.MsgEnd)
+		}
+		t.ranges = append(t.ranges, rangeRule{begin: e.MsgBegin, end: e.MsgEnd, rule: rule})
+	}
+
+	sort.Slice(t.ranges, func(i, j int) bool { return t.ranges[i].begin < t.ranges[j].begin })
+	for i := 1; i < len(t.ranges); i++ {
+		prev, cur := t.ranges[i-1], t.ranges[i]
+		if cur.begin < prev.end {
+			return nil, fmt.Errorf("%w: range [%d, %d) overlaps [%d, %d)",
+				ErrInvalidRoute, cur.begin, cur.end, prev.begin, prev.end)
+		}
+	}
+	return t, nil
+}
+
+func (t *Table) Lookup(msgID int) (RouteRule, bool) {
+	if rule, ok := t.ids[msgID]; ok {
+		return rule, true
+	}
+	i := sort.Search(len(t.ranges), func(i int) bool { return t.ranges[i].end > msgID })
+	if i < len(t.ranges) && t.ranges[i].begin <= msgID {
+		return t.ranges[i].rule, true
+	}
+	return RouteRule{}, false
+}
+
+// Len 单个 id 与区间的条目总数
+func (t *Table) Len() int {
+	return len(t.ids) + len(t.ranges)
+}
+
+// DefaultEntries 未提供路由配置时的内置路由（与 msgid.go 的分段一致）
+func DefaultEntries() []RouteEntry {
+	return []RouteEntry{
+		{MsgBegin: protocol.MsgLoginBegin, MsgEnd: protocol.MsgLoginEnd, Target: TargetService, Module: "login"},
+		{MsgBegin: protocol.MsgChatBegin, MsgEnd: protocol.MsgChatEnd, Target: TargetService, Module: "chat"},
+		{MsgBegin: protocol.MsgGameBegin, MsgEnd: protocol.MsgGameEnd, Target: TargetGame},
+		{MsgBegin: protocol.MsgDBBegin, MsgEnd: protocol.MsgDBEnd, Target: TargetDB},
+	}
 }
@@ -0,0 +1,93 @@
+package router
+
+import (
+	"errors"
+	"testing"
+
+	"game-server/internal/protocol"
+)
+
+func TestNewTableRejectsEmpty(t *testing.T) {
+	for _, entries := range [][]RouteEntry{nil, {}} {
+		if _, err := NewTable(entries); !errors.Is(err, ErrInvalidRoute) {
+			t.Fatalf("NewTable(%v) = %v, want ErrInvalidRoute", entries, err)
+		}
+	}
+}
+
+func TestLoadKeepsOldTableOnError(t *testing.T) {
+	old := Current()
+	t.Cleanup(func() { current.Store(old) })
+
+	valid := []RouteEntry{
+		{MsgBegin: protocol.MsgLoginBegin, MsgEnd: protocol.MsgLoginEnd, Target: TargetService, Module: "login"},
+	}
+	loaded, err := Load(valid)
+	if err != nil {
+		t.Fatalf("load valid table: %v", err)
+	}
+
+	bad := [][]RouteEntry{
+		nil,
+		{{MsgBegin: protocol.MsgLoginBegin, MsgEnd: protocol.MsgLoginEnd, Target: TargetService}},
+		{{MsgID: protocol.MsgGateEnd - 1, Target: TargetGame}},
+		{
+			{MsgBegin: protocol.MsgChatBegin, MsgEnd: protocol.MsgChatEnd, Target: TargetGame},
+			{MsgBegin: protocol.MsgChatBegin + 1, MsgEnd: protocol.MsgChatEnd + 1, Target: TargetGame},
+		},
+	}
+	for _, entries := range bad {
+		if _, err := Load(entries); !errors.Is(err, ErrInvalidRoute) {
+			t.Fatalf("Load(%v) = %v, want ErrInvalidRoute", entries, err)
+		}
+		if Current() != loaded {
+			t.Fatalf("Load(%v) replaced the table despite the error", entries)
+		}
+	}
+
+	rule, ok := GetRoute(protocol.MsgLoginBegin)
+	if !ok || rule.Module != "login" {
+		t.Fatalf("GetRoute after failed reloads = %+v, %v", rule, ok)
+	}
+}
+
+func TestTableLookup(t *testing.T) {
+	table, err := NewTable([]RouteEntry{
+		{MsgBegin: 2000, MsgEnd: 3000, Target: TargetService, Module: "chat"},
+		{MsgBegin: 4000, MsgEnd: 5000, Target: TargetGame},
+		{MsgID: 2500, Target: TargetGame},                          // 覆盖所在区间
+		{MsgID: 3500, Target: TargetService, Module: "standalone"}, // 区间之间的单个 id
+	})
+	if err != nil {
+		t.Fatalf("new table: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		msgID  int
+		ok     bool
+		target TargetType
+		module string
+	}{
+		{"range begin inclusive", 2000, true, TargetService, "chat"},
+		{"inside range", 2001, true, TargetService, "chat"},
+		{"id overrides range", 2500, true, TargetGame, ""},
+		{"range after override", 2501, true, TargetService, "chat"},
+		{"range end - 1", 2999, true, TargetService, "chat"},
+		{"range end exclusive", 3000, false, 0, ""},
+		{"gap between ranges", 3499, false, 0, ""},
+		{"single id in gap", 3500, true, TargetService, "standalone"},
+		{"next range begin", 4000, true, TargetGame, ""},
+		{"last range end exclusive", 5000, false, 0, ""},
+		{"below all ranges", 1999, false, 0, ""},
+	}
+	for _, tc := range cases {
+		rule, ok := table.Lookup(tc.msgID)
+		if ok != tc.ok || rule.Target != tc.target || rule.Module != tc.module {
+			t.Fatalf("%s: Lookup(%d) = %+v, %v; want %v/%q, %v", tc.name, tc.msgID, rule, ok, tc.target, tc.module, tc.ok)
+		}
+	}
+	if table.Len() != 4 {
+		t.Fatalf("Len = %d, want 4", table.Len())
+	}
+}
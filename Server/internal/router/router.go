@@ -1,28 +1,33 @@
 // internal/router/router.go
 package router
 
-import "game-server/internal/protocol"
+import "sync/atomic"
 
-var routes = make(map[int]RouteRule)
+// current 进程内共享的路由表（Gate 与 NetServer 都从这里查）
+var current atomic.Pointer[Table]
 
-//func Init() {
-//	for _, r := range routeTable {
-//		routes[r.MsgID] = r
-//	}
-//}
-
-func GetRoute(msgID int) (RouteRule, bool) {
-	switch {
-	case msgID >= protocol.MsgLoginBegin && msgID < protocol.MsgLoginEnd:
-		return RouteRule{Target: TargetService, Module: "login"}, true
+func init() {
+	t, err := NewTable(DefaultEntries())
+	if err != nil {
+		panic(err)
+	}
+	current.Store(t)
+}
 
-	case msgID >= protocol.MsgChatBegin && msgID < protocol.MsgChatEnd:
-		return RouteRule{Target: TargetService, Module: "chat"}, true
+// Load 校验后原子替换路由表；校验失败时保留旧表
+func Load(entries []RouteEntry) (*Table, error) {
+	t, err := NewTable(entries)
+	if err != nil {
+		return nil, err
+	}
+	current.Store(t)
+	return t, nil
+}
 
-	case msgID >= protocol.MsgGameBegin && msgID < protocol.MsgGameEnd:
-		return RouteRule{Target: TargetGame}, true
+func Current() *Table {
+	return current.Load()
+}
 
-	default:
-		return RouteRule{}, false
-	}
+func GetRoute(msgID int) (RouteRule, bool) {
+	return current.Load().Lookup(msgID)
 }
@@ -1 +1,206 @@
 package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNoGameShard      = errors.New("no game shard configured")
+	ErrUnknownGameShard = errors.New("unknown game shard")
+	ErrNoAssignStore    = errors.New("shard assignment store not configured")
+)
+
+const (
+	shardRingReplicas = 128
+	shardCacheTTL     = time.Minute
+	shardCacheSweep   = 1024
+)
+
+// 分配来源（admin 查询用）
+const (
+	ShardSourceHash     = "hash"
+	ShardSourceAssigned = "assigned"
+)
+
+// GameShard 一个 game 服；ID 参与哈希，Addr 变更不会导致玩家迁移
+type GameShard struct {
+	ID   string
+	Addr string
+}
+
+// ShardAssignStore 玩家 -> game 服 的显式分配（Redis）
+type ShardAssignStore interface {
+	Get(ctx context.Context, playerID int64) (string, bool, error)
+	// SetIfAbsent 原子占位；已存在时返回已有的分配
+	SetIfAbsent(ctx context.Context, playerID int64, shardID string) (string, error)
+	Set(ctx context.Context, playerID int64, shardID string) error
+}
+
+type shardCacheEntry struct {
+	shard  GameShard
+	expire time.Time
+}
+
+// ShardSelector 为玩家选择 game 服：
+//   - 无 store：对 game 列表做一致性哈希，列表不变时天然粘滞
+//   - 有 store：优先用 Redis 里的显式分配；没有时按哈希选出并写回，
+//     之后增减 game 服也不会让老玩家换服
+type ShardSelector struct {
+	mu     sync.Mutex
+	shards map[string]GameShard
+	order  []GameShard
+	ring   *HashRing[GameShard]
+	store  ShardAssignStore
+
+	cache   map[int64]shardCacheEntry
+	inserts int
+}
+
+func NewShardSelector(shards []GameShard, store ShardAssignStore) (*ShardSelector, error) {
+	if len(shards) == 0 {
+		return nil, ErrNoGameShard
+	}
+	s := &ShardSelector{
+		shards: make(map[string]GameShard, len(shards)),
+		store:  store,
+		cache:  make(map[int64]shardCacheEntry),
+	}
+	for _, shard := range shards {
+		if shard.ID == "" || shard.Addr == "" {
+			return nil, fmt.Errorf("%w: id=%q addr=%q", ErrUnknownGameShard, shard.ID, shard.Addr)
+		}
+		if _, dup := s.shards[shard.ID]; dup {
+			return nil, fmt.Errorf("%w: duplicate id %s", ErrUnknownGameShard, shard.ID)
+		}
+		s.shards[shard.ID] = shard
+		s.order = append(s.order, shard)
+	}
+	s.ring = NewHashRing(s.order, func(shard GameShard) string { return shard.ID }, shardRingReplicas)
+	return s, nil
+}
+
+// Shards 配置顺序的 game 列表；第一个作为没有 playerID 时的默认服
+func (s *ShardSelector) Shards() []GameShard {
+	out := make([]GameShard, len(s.order))
+	copy(out, s.order)
+	return out
+}
+
+// Persistent 是否配置了分配存储；没有时玩家所在服只由哈希决定，无法迁移
+func (s *ShardSelector) Persistent() bool {
+	return s.store != nil
+}
+
+func (s *ShardSelector) Shard(id string) (GameShard, bool) {
+	shard, ok := s.shards[id]
+	return shard, ok
+}
+
+// Select 返回玩家所在的 game 服；有 store 时首次选择会写回，读写存储失败时返回错误，调用方让请求失败
+func (s *ShardSelector) Select(ctx context.Context, playerID int64) (GameShard, error) {
+	if playerID == 0 {
+		return s.order[0], nil
+	}
+	if s.store == nil {
+		return s.hashShard(playerID), nil
+	}
+	if shard, ok := s.cached(playerID); ok {
+		return shard, nil
+	}
+
+	// ⭐ 存储异常时直接报错，不退化为哈希：玩家可能已迁到别的服，退化会让两个服同时持有玩家
+	if id, ok, err := s.store.Get(ctx, playerID); err != nil {
+		return GameShard{}, err
+	} else if ok {
+		if shard, known := s.shards[id]; known {
+			s.remember(playerID, shard)
+			return shard, nil
+		}
+	}
+
+	shard := s.hashShard(playerID)
+	id, err := s.store.SetIfAbsent(ctx, playerID, shard.ID)
+	if err != nil {
+		return GameShard{}, err
+	}
+	if assigned, known := s.shards[id]; known {
+		shard = assigned
+	}
+	s.remember(playerID, shard)
+	return shard, nil
+}
+
+// Lookup 只读查询，不写回分配；source 为 hash / assigned
+func (s *ShardSelector) Lookup(ctx context.Context, playerID int64) (GameShard, string, error) {
+	if s.store != nil {
+		id, ok, err := s.store.Get(ctx, playerID)
+		if err != nil {
+			return GameShard{}, "", err
+		}
+		if ok {
+			shard, known := s.shards[id]
+			if !known {
+				return GameShard{ID: id}, ShardSourceAssigned, fmt.Errorf("%w: %s", ErrUnknownGameShard, id)
+			}
+			return shard, ShardSourceAssigned, nil
+		}
+	}
+	return s.hashShard(playerID), ShardSourceHash, nil
+}
+
+// Assign 显式指定玩家的 game 服（对在线玩家不做迁移，下次选择时生效）
+func (s *ShardSelector) Assign(ctx context.Context, playerID int64, shardID string) error {
+	if s.store == nil {
+		return ErrNoAssignStore
+	}
+	shard, ok := s.shards[shardID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownGameShard, shardID)
+	}
+	if err := s.store.Set(ctx, playerID, shardID); err != nil {
+		return err
+	}
+	s.remember(playerID, shard)
+	return nil
+}
+
+// Forget 清掉本地缓存（玩家下线）
+func (s *ShardSelector) Forget(playerID int64) {
+	s.mu.Lock()
+	delete(s.cache, playerID)
+	s.mu.Unlock()
+}
+
+func (s *ShardSelector) hashShard(playerID int64) GameShard {
+	shard, _ := s.ring.Get(HashID(playerID))
+	return shard
+}
+
+func (s *ShardSelector) cached(playerID int64) (GameShard, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.cache[playerID]
+	if !ok || time.Now().After(entry.expire) {
+		return GameShard{}, false
+	}
+	return entry.shard, true
+}
+
+func (s *ShardSelector) remember(playerID int64, shard GameShard) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache[playerID] = shardCacheEntry{shard: shard, expire: now.Add(shardCacheTTL)}
+	s.inserts++
+	if s.inserts%shardCacheSweep == 0 {
+		for id, entry := range s.cache {
+			if now.After(entry.expire) {
+				delete(s.cache, id)
+			}
+		}
+	}
+}
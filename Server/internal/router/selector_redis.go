@@ -0,0 +1,46 @@
+package router
+
+import (
+	"context"
+	"errors"
+
+	"game-server/internal/db/redis_tools"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisShardStore 分配永久保存（不设 TTL），保证跨重连、跨进程重启粘滞
+type RedisShardStore struct {
+	dao *redis_tools.RedisDao
+}
+
+func NewRedisShardStore(dao *redis_tools.RedisDao) *RedisShardStore {
+	return &RedisShardStore{dao: dao}
+}
+
+func (s *RedisShardStore) Get(ctx context.Context, playerID int64) (string, bool, error) {
+	val, err := s.dao.Get(ctx, redis_tools.PlayerShardKey(playerID))
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return val, true, nil
+}
+
+func (s *RedisShardStore) SetIfAbsent(ctx context.Context, playerID int64, shardID string) (string, error) {
+	key := redis_tools.PlayerShardKey(playerID)
+	ok, err := s.dao.SetWhenNotExist(ctx, key, shardID, 0)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return shardID, nil
+	}
+	// 并发下其他进程先写入：以已有分配为准
+	return s.dao.Get(ctx, key)
+}
+
+func (s *RedisShardStore) Set(ctx context.Context, playerID int64, shardID string) error {
+	return s.dao.Set(ctx, redis_tools.PlayerShardKey(playerID), shardID, 0)
+}
@@ -4,6 +4,10 @@ package service
 import (
 	"context"
 	"game-server/internal/protocol"
+	"game-server/internal/protocol/codec"
+	"game-server/internal/protocol/internalpb"
+
+	"google.golang.org/protobuf/proto"
 )
 
 type Context struct {
@@ -14,13 +18,73 @@ type Context struct {
 	MsgID     int
 	Payload   []byte
 	TraceID   string
+	Client    *internalpb.ClientInfo // Gate 转发的握手信息，只在登录请求上携带
+	Codec     string                 // Payload 的编码（json / msgpack），空为 protobuf；handler 用 Decode / ReplyMessage 即可
 
-	// 回包 / 推送
+	// 回包 / 推送：data 需按 Codec 编码，一般用 ReplyMessage / PushMessage
 	Reply      func(msgID int, data []byte) error
 	Push       func(msgID int, data []byte) error
 	ReplyError func(code protocol.ErrorCode, msg string) error
 	// 更新 Gate 会话信息
 	SetPlayerID func(playerID int64)
-	// 转发到 Game
+	// 转发到 Game（带上 Codec，Game 按同一编码回包给客户端）
 	SendToGame func(msgID int, data []byte) error
+
+	// 广播 / 组播 / 分组（经 MsgGateControl 下发给 Gate）：data 按 Codec 编码，
+	// Gate 再按每个接收者协商的编码转码；一般用 BroadcastMessage 等
+	Broadcast    func(msgID int, data []byte) error
+	Multicast    func(playerIDs []int64, msgID int, data []byte) error
+	JoinGroup    func(group string) error
+	LeaveGroup   func(group string) error
+	PublishGroup func(group string, msgID int, data []byte) error
+}
+
+// Decode 按客户端使用的编码解出请求
+func (c *Context) Decode(m proto.Message) error {
+	cc, err := codec.Get(c.Codec)
+	if err != nil {
+		return err
+	}
+	return cc.Unmarshal(c.Payload, m)
+}
+
+// ReplyMessage 按请求的编码序列化后回包
+func (c *Context) ReplyMessage(msgID int, m proto.Message) error {
+	data, err := codec.Encode(c.Codec, m)
+	if err != nil {
+		return err
+	}
+	return c.Reply(msgID, data)
+}
+
+func (c *Context) PushMessage(msgID int, m proto.Message) error {
+	data, err := codec.Encode(c.Codec, m)
+	if err != nil {
+		return err
+	}
+	return c.Push(msgID, data)
+}
+
+func (c *Context) BroadcastMessage(msgID int, m proto.Message) error {
+	data, err := codec.Encode(c.Codec, m)
+	if err != nil {
+		return err
+	}
+	return c.Broadcast(msgID, data)
+}
+
+func (c *Context) MulticastMessage(playerIDs []int64, msgID int, m proto.Message) error {
+	data, err := codec.Encode(c.Codec, m)
+	if err != nil {
+		return err
+	}
+	return c.Multicast(playerIDs, msgID, data)
+}
+
+func (c *Context) PublishGroupMessage(group string, msgID int, m proto.Message) error {
+	data, err := codec.Encode(c.Codec, m)
+	if err != nil {
+		return err
+	}
+	return c.PublishGroup(group, msgID, data)
 }
@@ -6,6 +6,7 @@ import (
 	"game-server/internal/protocol"
 	"go.uber.org/zap"
 	"runtime/debug"
+	"time"
 )
 
 type Dispatcher struct {
@@ -26,8 +27,11 @@ func (d *Dispatcher) Dispatch(ctx *Context) {
 		return
 	}
 
+	start := time.Now()
+	result := resultOK
 	defer func() {
 		if r := recover(); r != nil {
+			result = resultPanic
 			d.logger.Error("handler panic",
 				zap.Any("panic", r),
 				zap.String("panic_type", fmt.Sprintf("%T", r)),
@@ -39,10 +43,12 @@ func (d *Dispatcher) Dispatch(ctx *Context) {
 				zap.String("trace_id", ctx.TraceID),
 			)
 		}
+		observeRequest(ctx.MsgID, result, time.Since(start))
 	}()
 
 	handler, ok := d.registry.GetHandler(ctx.MsgID)
 	if !ok {
+		result = resultNoHandler
 		d.logger.Warn("no handler for msgID",
 			zap.Int("msg_id", ctx.MsgID),
 			zap.Int64("session", ctx.SessionID),
@@ -55,6 +61,7 @@ func (d *Dispatcher) Dispatch(ctx *Context) {
 	}
 
 	if err := handler(ctx); err != nil {
+		result = resultError
 		d.logger.Warn("handler error",
 			zap.Int("msg_id", ctx.MsgID),
 			zap.Int64("session", ctx.SessionID),
@@ -2,8 +2,8 @@ package service
 
 import (
 	"context"
-	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"game-server/internal/protocol"
@@ -57,7 +57,7 @@ func (r *GameRouter) Send(env *internalpb.Envelope) error {
 			return nil
 		default:
 			if attempt == r.sendRetryMax {
-				r.busyCount++
+				atomic.AddUint64(&r.busyCount, 1)
 				r.logger.Warn("game router send queue full",
 					zap.String("addr", r.addr),
 					zap.Int("msg_id", int(env.MsgId)),
@@ -74,26 +74,38 @@ func (r *GameRouter) Send(env *internalpb.Envelope) error {
 }
 
 func (r *GameRouter) writeLoop() {
+	co := transport.NewCoalescer[*internalpb.Envelope](r.connOptions)
 	for {
 		select {
 		case env := <-r.sendCh:
+			batch := co.Collect(r.sendCh, env, r.closed)
+
 			r.mu.RLock()
 			conn := r.conn
 			r.mu.RUnlock()
 
 			if conn == nil {
-				r.dropCount++
-				r.logger.Warn("game router disconnected, drop message",
-					zap.String("addr", r.addr),
-					zap.Int("msg_id", int(env.MsgId)),
-					zap.Int64("session", env.SessionId),
-					zap.Int64("player", env.PlayerId),
-					zap.String("reason", "router_disconnected"),
-				)
+				for _, env := range batch {
+					atomic.AddUint64(&r.dropCount, 1)
+					r.logger.Warn("game router disconnected, drop message",
+						zap.String("addr", r.addr),
+						zap.Int("msg_id", int(env.MsgId)),
+						zap.Int64("session", env.SessionId),
+						zap.Int64("player", env.PlayerId),
+						zap.String("reason", "router_disconnected"),
+					)
+				}
 				continue
 			}
 
-			if err := conn.WriteEnvelope(env); err != nil {
+			if unsent, err := r.writeBatch(conn, batch); err != nil {
+				atomic.AddUint64(&r.dropCount, uint64(unsent))
+				r.logger.Warn("write to game failed",
+					zap.String("addr", r.addr),
+					zap.Int("batch", len(batch)),
+					zap.Int("dropped", unsent),
+					zap.String("reason", err.Error()),
+				)
 				// 写失败，等待重连
 				time.Sleep(10 * time.Millisecond)
 			}
@@ -104,6 +116,11 @@ func (r *GameRouter) writeLoop() {
 	}
 }
 
+// writeBatch 逐条写入缓冲后统一 Flush；失败时返回未能写出的消息数，计入 dropCount
+func (r *GameRouter) writeBatch(conn *transport.BufferedConn, batch []*internalpb.Envelope) (int, error) {
+	return transport.WriteBatch(conn, batch)
+}
+
 func (r *GameRouter) connectLoop(ctx context.Context, onEnvelope func(env *internalpb.Envelope)) {
 	backoff := time.Second
 	for {
@@ -113,8 +130,7 @@ func (r *GameRouter) connectLoop(ctx context.Context, onEnvelope func(env *inter
 		default:
 		}
 
-		dialer := net.Dialer{Timeout: 3 * time.Second, KeepAlive: r.connOptions.KeepAlive}
-		conn, err := dialer.Dial("tcp", r.addr)
+		conn, err := transport.Dial(r.addr, 3*time.Second, r.connOptions)
 		if err != nil {
 			time.Sleep(backoff)
 			if backoff < 5*time.Second {
@@ -1,4 +1,41 @@
 // internal/service/handler.go
 package service
 
+import (
+	"game-server/internal/handler"
+	"game-server/internal/protocol/msgtype"
+
+	"google.golang.org/protobuf/proto"
+)
+
 type HandlerFunc func(ctx *Context) error
+
+// Register 按 msgtype 表注册强类型 handler：请求按客户端的编码解出，返回的 Rsp 按表里的回包 msgID 回给客户端；
+// Rsp 为 nil 表示 handler 已自行回包（如 ReplyError）。请求 / 回包类型与表不一致时返回错误，模块注册失败
+//
+//	service.Register(reg, protocol.MsgLoginReq, m.onLogin) // onLogin(*Context, *internalpb.LoginReq) (*internalpb.LoginRsp, error)
+//
+// .proto 里标注了 (msg_id) / (reply) 的请求由 protoc-gen-msgid 生成 RegisterXxx 包装，见 msg_handlers.go
+func Register[Req, Rsp any, PReq interface {
+	*Req
+	proto.Message
+}, PRsp interface {
+	*Rsp
+	proto.Message
+}](reg *handler.Registry[HandlerFunc], msgID int, fn func(*Context, PReq) (PRsp, error)) error {
+	rspID, err := msgtype.RequireReply[PReq, PRsp](msgtype.Default, msgID)
+	if err != nil {
+		return err
+	}
+	return reg.Register(msgID, func(ctx *Context) error {
+		req := PReq(new(Req))
+		if err := ctx.Decode(req); err != nil {
+			return err
+		}
+		rsp, err := fn(ctx, req)
+		if err != nil || rsp == nil {
+			return err
+		}
+		return ctx.ReplyMessage(rspID, rsp)
+	})
+}
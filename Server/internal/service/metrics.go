@@ -0,0 +1,63 @@
+// internal/service/metrics.go
+package service
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"game-server/internal/metrics"
+)
+
+var (
+	serviceRequests = metrics.NewCounterVec("service_requests_total",
+		"Envelopes dispatched to service modules by msg_id and result.", "msg_id", "result")
+	serviceRequestLatency = metrics.NewHistogramVec("service_request_duration_seconds",
+		"Time spent in service handlers.", nil, "msg_id")
+)
+
+const (
+	resultOK        = "ok"
+	resultError     = "error"
+	resultPanic     = "panic"
+	resultNoHandler = "no_handler"
+)
+
+func observeRequest(msgID int, result string, cost time.Duration) {
+	label := "unknown"
+	if result != resultNoHandler {
+		label = strconv.Itoa(msgID)
+	}
+	serviceRequests.With(label, result).Inc()
+	serviceRequestLatency.With(label).Observe(cost.Seconds())
+}
+
+func (n *NetServer) registerMetrics() {
+	metrics.Register(serviceRequests, serviceRequestLatency)
+	metrics.Register(metrics.NewGaugeFunc("service_gate_connections", "Connected gate links.", nil, func(emit metrics.EmitFunc) {
+		n.mu.RLock()
+		count := len(n.gateConns)
+		n.mu.RUnlock()
+		emit(float64(count))
+	}))
+	metrics.Register(metrics.NewGaugeFunc("service_sessions", "Sessions with a known gate route.", nil, func(emit metrics.EmitFunc) {
+		n.mu.RLock()
+		count := len(n.sessionGate)
+		n.mu.RUnlock()
+		emit(float64(count))
+	}))
+	if len(n.gameRouters) == 0 {
+		return
+	}
+	metrics.Register(metrics.NewGaugeFunc("service_game_queue_depth", "Queued envelopes towards each game server.", []string{"shard"}, func(emit metrics.EmitFunc) {
+		for id, r := range n.gameRouters {
+			emit(float64(len(r.sendCh)), id)
+		}
+	}))
+	metrics.Register(metrics.NewCounterFunc("service_game_drops_total", "Envelopes towards each game server dropped by reason.", []string{"shard", "reason"}, func(emit metrics.EmitFunc) {
+		for id, r := range n.gameRouters {
+			emit(float64(atomic.LoadUint64(&r.busyCount)), id, "queue_full")
+			emit(float64(atomic.LoadUint64(&r.dropCount)), id, "disconnected")
+		}
+	}))
+}
@@ -3,23 +3,20 @@ package chat
 
 import (
 	"game-server/internal/handler"
+	"game-server/internal/protocol/internalpb"
 	"game-server/internal/service"
 )
 
-const (
-	MsgChatSend = 2001
-)
-
 type Module struct{}
 
 func (m *Module) Name() string { return "chat" }
 func (m *Module) Init() error  { return nil }
 
 func (m *Module) RegisterHandlers(reg *handler.Registry[service.HandlerFunc]) error {
-	return reg.Register(MsgChatSend, m.onChat)
+	return service.RegisterChatSendReq(reg, m.onChat)
 }
 
-func (m *Module) onChat(ctx *service.Context) error {
+func (m *Module) onChat(ctx *service.Context, req *internalpb.ChatSendReq) (*internalpb.ChatSendRsp, error) {
 	// 广播 / 跨服推送
-	return nil
+	return nil, nil
 }
@@ -1,81 +1,97 @@
 package login
 
 import (
+	"context"
+	"errors"
+
 	"game-server/internal/handler"
 	"game-server/internal/protocol"
 	"game-server/internal/protocol/internalpb"
 	"game-server/internal/service"
-
-	"google.golang.org/protobuf/proto"
 )
 
 func (m *Module) RegisterHandlers(reg *handler.Registry[service.HandlerFunc]) error {
-	return reg.Register(protocol.MsgLoginReq, m.onLogin)
+	return service.RegisterLoginReq(reg, m.onLogin)
 }
 
-func (m *Module) verifyToken(platform int32, token string) error {
-	if token == "" {
-		return protocol.InternalErrInvalidToken
+func (m *Module) verifyToken(ctx context.Context, req *internalpb.LoginReq) (string, error) {
+	v, ok := m.verifier(req.Platform)
+	if !ok {
+		return "", protocol.InternalErrUnknownPlatForm
 	}
+	return v.Verify(ctx, req)
+}
 
-	switch platform {
-
-	case protocol.PlatformTest:
-		// 测试平台，永远放行
-		return nil
-
-	//case protocol.PlatformAndroid:
-	//	return m.verifyAndroidToken(token)
-	//
-	//case protocol.PlatformIOS:
-	//	return m.verifyIOSToken(token)
-	//
-	//case protocol.PlatformPC:
-	//	return m.verifyPCToken(token)
-
+// onLogin 平台校验是一次 HTTP 请求，放到校验协程池里做，不占 Gate 链路的读协程；
+// 校验完成后由 worker 通过 ctx 回包，这里返回 nil 表示已自行回包
+func (m *Module) onLogin(ctx *service.Context, req *internalpb.LoginReq) (*internalpb.LoginRsp, error) {
+	select {
+	case m.jobs <- loginJob{ctx: ctx, req: req}:
+		return nil, nil
 	default:
-		return protocol.InternalErrUnknownPlatForm
+		return nil, ctx.ReplyError(protocol.ErrRateLimited, "login busy")
 	}
 }
-func (m *Module) onLogin(ctx *service.Context) error {
-	var req internalpb.LoginReq
-	if err := proto.Unmarshal(ctx.Payload, &req); err != nil {
-		return err
+
+func (m *Module) startWorkers() {
+	m.startOnce.Do(func() {
+		if m.jobs == nil {
+			m.jobs = make(chan loginJob, loginQueueSize)
+		}
+		for i := 0; i < loginWorkers; i++ {
+			go m.loginWorker()
+		}
+	})
+}
+
+func (m *Module) loginWorker() {
+	for job := range m.jobs {
+		m.handleLogin(job.ctx, job.req)
 	}
+}
+
+func (m *Module) handleLogin(ctx *service.Context, req *internalpb.LoginReq) {
+	defer func() {
+		// worker 不在 Dispatcher 的 recover 范围内，panic 不能带走整个协程池
+		if r := recover(); r != nil {
+			_ = ctx.ReplyError(protocol.ErrLoginFailed, "login failed")
+		}
+	}()
 
-	switch req.Platform {
-	case protocol.PlatformTest:
-		// 不校验
-	case protocol.PlatformAndroid,
-		protocol.PlatformIOS,
-		protocol.PlatformPC:
+	rsp, err := m.login(ctx, req)
+	if err != nil {
+		_ = ctx.ReplyError(protocol.ErrLoginFailed, err.Error())
+		return
+	}
+	if rsp != nil {
+		_ = ctx.ReplyMessage(protocol.MsgLoginRsp, rsp)
+	}
+}
 
-		if err := m.verifyToken(req.Platform, req.Token); err != nil {
-			return ctx.ReplyError(
-				protocol.ErrInvalidToken,
+func (m *Module) login(ctx *service.Context, req *internalpb.LoginReq) (*internalpb.LoginRsp, error) {
+	// ⭐ accountID 以平台校验结果为准，不信任 req.AccountId
+	accountID, err := m.verifyToken(ctx, req)
+	if err != nil {
+		if errors.Is(err, protocol.InternalErrUnknownPlatForm) {
+			return nil, ctx.ReplyError(
+				protocol.ErrUnknownPlatform,
 				err.Error(),
 			)
 		}
-	default:
-		return ctx.ReplyError(
-			protocol.ErrUnknownPlatform,
-			protocol.InternalErrUnknownPlatForm.Error(),
+		return nil, ctx.ReplyError(
+			protocol.ErrInvalidToken,
+			err.Error(),
 		)
 	}
 
-	accountID := req.AccountId
-	if accountID == "" {
-		accountID = req.Token
-	}
 	playerID, _, err := m.svc.ResolveRoleID(ctx, accountID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	rsp := &internalpb.LoginRsp{
 		PlayerId: playerID,
 	}
-	data, _ := proto.Marshal(rsp)
 
 	ctx.SetPlayerID(playerID)
 
@@ -84,5 +100,5 @@ func (m *Module) onLogin(ctx *service.Context) error {
 	}
 
 	// 回包给 Gate → Client
-	return ctx.Reply(protocol.MsgLoginRsp, data)
+	return rsp, nil
 }
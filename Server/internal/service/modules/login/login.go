@@ -1,13 +1,31 @@
 // internal/service/modules/login/login.go
 package login
 
+import (
+	"sync"
+
+	"game-server/internal/protocol/internalpb"
+	"game-server/internal/service"
+)
+
 const (
-	MsgLoginReq  = 1001
-	MsgLoginResp = 1002
+	loginWorkers   = 64   // 同时进行的平台校验数
+	loginQueueSize = 1024 // 等待校验的登录请求，满了直接回 ErrRateLimited
 )
 
+type loginJob struct {
+	ctx *service.Context
+	req *internalpb.LoginReq
+}
+
 type Module struct {
 	svc *LoginService
+
+	mu        sync.RWMutex
+	verifiers map[int32]TokenVerifier
+
+	jobs      chan loginJob
+	startOnce sync.Once
 }
 
 func (m *Module) Name() string { return "login" }
@@ -15,9 +33,36 @@ func (m *Module) Init() error {
 	if m.svc == nil {
 		m.svc = NewLoginService(nil, nil)
 	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.verifiers == nil {
+		m.verifiers = make(map[int32]TokenVerifier)
+	}
+	m.startWorkers()
 	return nil
 }
 
 func NewModule(svc *LoginService) *Module {
-	return &Module{svc: svc}
+	return &Module{
+		svc:       svc,
+		verifiers: make(map[int32]TokenVerifier),
+		jobs:      make(chan loginJob, loginQueueSize),
+	}
+}
+
+// RegisterVerifier 为平台注册 token 校验器，重复注册会覆盖
+func (m *Module) RegisterVerifier(platform int32, v TokenVerifier) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.verifiers == nil {
+		m.verifiers = make(map[int32]TokenVerifier)
+	}
+	m.verifiers[platform] = v
+}
+
+func (m *Module) verifier(platform int32) (TokenVerifier, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := m.verifiers[platform]
+	return v, ok
 }
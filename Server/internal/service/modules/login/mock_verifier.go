@@ -0,0 +1,76 @@
+package login
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// MockVerifierServer 本地模拟平台校验服务，供联调 / 测试时把 HTTPVerifier 指过来
+//
+//	token 先查显式注册的映射；未注册时以 "invalid" 开头的 token 被拒绝，
+//	其余 token 直接作为 accountID 返回
+type MockVerifierServer struct {
+	secret string
+
+	mu       sync.RWMutex
+	accounts map[string]string
+}
+
+func NewMockVerifierServer(secret string) *MockVerifierServer {
+	return &MockVerifierServer{
+		secret:   secret,
+		accounts: make(map[string]string),
+	}
+}
+
+func (s *MockVerifierServer) SetAccount(token, accountID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts[token] = accountID
+}
+
+func (s *MockVerifierServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 64*1024))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if s.secret != "" {
+		expected := signVerifyBody(s.secret, body)
+		if !hmac.Equal([]byte(r.Header.Get(SignatureHeader)), []byte(expected)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+
+	var req verifyRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	rsp := s.verify(req.Token)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(rsp)
+}
+
+func (s *MockVerifierServer) verify(token string) verifyResponse {
+	s.mu.RLock()
+	accountID, ok := s.accounts[token]
+	s.mu.RUnlock()
+	if ok {
+		return verifyResponse{OK: true, AccountID: accountID}
+	}
+	if token == "" || strings.HasPrefix(token, "invalid") {
+		return verifyResponse{OK: false, Reason: "invalid token"}
+	}
+	return verifyResponse{OK: true, AccountID: token}
+}
@@ -0,0 +1,200 @@
+package login
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"game-server/internal/protocol"
+	"game-server/internal/protocol/internalpb"
+)
+
+// TokenVerifier 校验平台 token，返回平台确认过的 accountID
+type TokenVerifier interface {
+	Verify(ctx context.Context, req *internalpb.LoginReq) (string, error)
+}
+
+// SignatureHeader HTTP 校验请求的签名头：hex(hmac-sha256(secret, body))
+const SignatureHeader = "X-Verify-Signature"
+
+var ErrVerifierRejected = errors.New("token rejected by verifier")
+
+// =======================
+// Test 平台
+// =======================
+
+// TestVerifier 测试平台，永远放行；account_id 为空时用 token 充当
+//
+//	⭐ 任何人都能以任意账号登录，只在配置 allow_test_platform 时注册
+type TestVerifier struct{}
+
+func (TestVerifier) Verify(_ context.Context, req *internalpb.LoginReq) (string, error) {
+	if req.AccountId != "" {
+		return req.AccountId, nil
+	}
+	if req.Token == "" {
+		return "", protocol.InternalErrInvalidToken
+	}
+	return req.Token, nil
+}
+
+// =======================
+// HTTP 平台校验
+// =======================
+
+type HTTPVerifierConfig struct {
+	Endpoint string
+	Timeout  time.Duration
+	Secret   string
+}
+
+type verifyRequest struct {
+	Platform  int32  `json:"platform"`
+	Token     string `json:"token"`
+	AccountID string `json:"account_id,omitempty"`
+}
+
+type verifyResponse struct {
+	OK        bool   `json:"ok"`
+	AccountID string `json:"account_id"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+type HTTPVerifier struct {
+	cfg    HTTPVerifierConfig
+	client *http.Client
+}
+
+func NewHTTPVerifier(cfg HTTPVerifierConfig) *HTTPVerifier {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 3 * time.Second
+	}
+	return &HTTPVerifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+func (v *HTTPVerifier) Verify(ctx context.Context, req *internalpb.LoginReq) (string, error) {
+	if req.Token == "" {
+		return "", protocol.InternalErrInvalidToken
+	}
+	body, err := json.Marshal(verifyRequest{
+		Platform:  req.Platform,
+		Token:     req.Token,
+		AccountID: req.AccountId,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, v.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if v.cfg.Secret != "" {
+		httpReq.Header.Set(SignatureHeader, signVerifyBody(v.cfg.Secret, body))
+	}
+
+	httpRsp, err := v.client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("verify request: %w", err)
+	}
+	defer httpRsp.Body.Close()
+
+	if httpRsp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("verify request: unexpected status %d", httpRsp.StatusCode)
+	}
+
+	var rsp verifyResponse
+	if err := json.NewDecoder(httpRsp.Body).Decode(&rsp); err != nil {
+		return "", fmt.Errorf("decode verify response: %w", err)
+	}
+	if !rsp.OK || rsp.AccountID == "" {
+		if rsp.Reason != "" {
+			return "", fmt.Errorf("%w: %s", ErrVerifierRejected, rsp.Reason)
+		}
+		return "", ErrVerifierRejected
+	}
+	return rsp.AccountID, nil
+}
+
+func signVerifyBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// =======================
+// 结果缓存
+// =======================
+
+const maxCachedTokens = 100000
+
+type cachedAccount struct {
+	accountID string
+	expireAt  time.Time
+}
+
+// CachedVerifier 缓存校验成功的结果，避免重连时重复请求平台
+type CachedVerifier struct {
+	next TokenVerifier
+	ttl  time.Duration
+
+	mu    sync.Mutex
+	items map[string]cachedAccount
+}
+
+func NewCachedVerifier(next TokenVerifier, ttl time.Duration) *CachedVerifier {
+	return &CachedVerifier{
+		next:  next,
+		ttl:   ttl,
+		items: make(map[string]cachedAccount),
+	}
+}
+
+func (v *CachedVerifier) Verify(ctx context.Context, req *internalpb.LoginReq) (string, error) {
+	key := fmt.Sprintf("%d:%s", req.Platform, req.Token)
+	now := time.Now()
+
+	v.mu.Lock()
+	item, ok := v.items[key]
+	v.mu.Unlock()
+	if ok && now.Before(item.expireAt) {
+		return item.accountID, nil
+	}
+
+	accountID, err := v.next.Verify(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	v.mu.Lock()
+	if len(v.items) >= maxCachedTokens {
+		v.pruneLocked(now)
+	}
+	v.items[key] = cachedAccount{accountID: accountID, expireAt: now.Add(v.ttl)}
+	v.mu.Unlock()
+	return accountID, nil
+}
+
+func (v *CachedVerifier) pruneLocked(now time.Time) {
+	for key, item := range v.items {
+		if !now.Before(item.expireAt) {
+			delete(v.items, key)
+		}
+	}
+	// 全部有效仍然超限：直接清空，宁可多请求一次平台
+	if len(v.items) >= maxCachedTokens {
+		v.items = make(map[string]cachedAccount)
+	}
+}
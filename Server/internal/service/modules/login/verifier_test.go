@@ -0,0 +1,180 @@
+package login
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"game-server/internal/protocol"
+	"game-server/internal/protocol/internalpb"
+	"game-server/internal/service"
+)
+
+const testVerifySecret = "verify-test-secret"
+
+// countingHandler 记录请求数，delay 大于 0 时先等待再交给 next
+type countingHandler struct {
+	next  http.Handler
+	delay time.Duration
+	hits  atomic.Int32
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.hits.Add(1)
+	if h.delay > 0 {
+		select {
+		case <-time.After(h.delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+func newMockPlatform(t *testing.T, delay time.Duration) (*httptest.Server, *MockVerifierServer, *countingHandler) {
+	t.Helper()
+	mock := NewMockVerifierServer(testVerifySecret)
+	h := &countingHandler{next: mock, delay: delay}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv, mock, h
+}
+
+func TestHTTPVerifier(t *testing.T) {
+	srv, mock, _ := newMockPlatform(t, 0)
+	mock.SetAccount("tok-alice", "alice")
+	v := NewHTTPVerifier(HTTPVerifierConfig{Endpoint: srv.URL, Secret: testVerifySecret, Timeout: time.Second})
+	ctx := context.Background()
+
+	t.Run("valid", func(t *testing.T) {
+		for token, want := range map[string]string{"tok-alice": "alice", "tok-bob": "tok-bob"} {
+			got, err := v.Verify(ctx, &internalpb.LoginReq{Platform: 1, Token: token})
+			if err != nil || got != want {
+				t.Fatalf("Verify(%q) = %q, %v; want %q", token, got, err, want)
+			}
+		}
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		_, err := v.Verify(ctx, &internalpb.LoginReq{Platform: 1, Token: "invalid-123"})
+		if !errors.Is(err, ErrVerifierRejected) {
+			t.Fatalf("err = %v, want ErrVerifierRejected", err)
+		}
+		if _, err := v.Verify(ctx, &internalpb.LoginReq{Platform: 1}); !errors.Is(err, protocol.InternalErrInvalidToken) {
+			t.Fatalf("empty token err = %v, want InternalErrInvalidToken", err)
+		}
+	})
+
+	t.Run("bad signature", func(t *testing.T) {
+		bad := NewHTTPVerifier(HTTPVerifierConfig{Endpoint: srv.URL, Secret: "wrong-secret", Timeout: time.Second})
+		if _, err := bad.Verify(ctx, &internalpb.LoginReq{Platform: 1, Token: "tok-alice"}); err == nil {
+			t.Fatal("verify with wrong secret succeeded")
+		}
+		unsigned := NewHTTPVerifier(HTTPVerifierConfig{Endpoint: srv.URL, Timeout: time.Second})
+		if _, err := unsigned.Verify(ctx, &internalpb.LoginReq{Platform: 1, Token: "tok-alice"}); err == nil {
+			t.Fatal("unsigned verify succeeded")
+		}
+	})
+}
+
+func TestHTTPVerifierTimeout(t *testing.T) {
+	srv, _, _ := newMockPlatform(t, 300*time.Millisecond)
+	v := NewHTTPVerifier(HTTPVerifierConfig{Endpoint: srv.URL, Secret: testVerifySecret, Timeout: 50 * time.Millisecond})
+
+	start := time.Now()
+	if _, err := v.Verify(context.Background(), &internalpb.LoginReq{Platform: 1, Token: "tok-slow"}); err == nil {
+		t.Fatal("verify against slow platform succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Fatalf("verify took %v, timeout not applied", elapsed)
+	}
+}
+
+func TestCachedVerifier(t *testing.T) {
+	srv, _, hits := newMockPlatform(t, 0)
+	v := NewCachedVerifier(
+		NewHTTPVerifier(HTTPVerifierConfig{Endpoint: srv.URL, Secret: testVerifySecret, Timeout: time.Second}),
+		time.Minute,
+	)
+	ctx := context.Background()
+	req := &internalpb.LoginReq{Platform: 1, Token: "tok-cached"}
+
+	for i := 0; i < 3; i++ {
+		got, err := v.Verify(ctx, req)
+		if err != nil || got != "tok-cached" {
+			t.Fatalf("verify %d = %q, %v", i, got, err)
+		}
+	}
+	if n := hits.hits.Load(); n != 1 {
+		t.Fatalf("platform hit %d times, want 1 (cache hits after the first)", n)
+	}
+
+	// 同一 token 换平台不命中缓存；失败结果不缓存
+	if _, err := v.Verify(ctx, &internalpb.LoginReq{Platform: 2, Token: "tok-cached"}); err != nil {
+		t.Fatalf("verify other platform: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := v.Verify(ctx, &internalpb.LoginReq{Platform: 1, Token: "invalid-x"}); err == nil {
+			t.Fatal("invalid token accepted")
+		}
+	}
+	if n := hits.hits.Load(); n != 4 {
+		t.Fatalf("platform hit %d times, want 4", n)
+	}
+}
+
+// blockingVerifier 放行前等待 release，用来确认校验不在调用方协程里执行
+type blockingVerifier struct {
+	release chan struct{}
+}
+
+func (v blockingVerifier) Verify(_ context.Context, req *internalpb.LoginReq) (string, error) {
+	<-v.release
+	return req.Token, nil
+}
+
+func TestLoginVerifiesOffCallerGoroutine(t *testing.T) {
+	m := NewModule(NewLoginService(nil, nil))
+	if err := m.Init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	release := make(chan struct{})
+	m.RegisterVerifier(1, blockingVerifier{release: release})
+
+	replies := make(chan int, 1)
+	ctx := &service.Context{
+		Context:     context.Background(),
+		SessionID:   1,
+		MsgID:       protocol.MsgLoginReq,
+		Reply:       func(msgID int, _ []byte) error { replies <- msgID; return nil },
+		ReplyError:  func(code protocol.ErrorCode, msg string) error { t.Errorf("reply error %d: %s", code, msg); return nil },
+		SetPlayerID: func(int64) {},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := m.onLogin(ctx, &internalpb.LoginReq{Platform: 1, Token: "tok-async"}); err != nil {
+			t.Errorf("onLogin: %v", err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("onLogin blocked on the verifier")
+	}
+
+	close(release)
+	select {
+	case msgID := <-replies:
+		if msgID != protocol.MsgLoginRsp {
+			t.Fatalf("reply msg %d, want MsgLoginRsp", msgID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("login reply not sent after verification")
+	}
+}
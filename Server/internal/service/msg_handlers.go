@@ -0,0 +1,20 @@
+// Code generated by protoc-gen-msgid. DO NOT EDIT.
+// source: chat.proto, db.proto, error.proto, game.proto, gate.proto, login.proto
+
+package service
+
+import (
+	handler "game-server/internal/handler"
+	protocol "game-server/internal/protocol"
+	internalpb "game-server/internal/protocol/internalpb"
+)
+
+// RegisterLoginReq MsgLoginReq → MsgLoginRsp
+func RegisterLoginReq(reg *handler.Registry[HandlerFunc], fn func(*Context, *internalpb.LoginReq) (*internalpb.LoginRsp, error)) error {
+	return Register(reg, protocol.MsgLoginReq, fn)
+}
+
+// RegisterChatSendReq MsgChatSendReq → MsgChatSendRsp
+func RegisterChatSendReq(reg *handler.Registry[HandlerFunc], fn func(*Context, *internalpb.ChatSendReq) (*internalpb.ChatSendRsp, error)) error {
+	return Register(reg, protocol.MsgChatSendReq, fn)
+}
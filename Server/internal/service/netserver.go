@@ -3,10 +3,10 @@ package service
 import (
 	"context"
 	"fmt"
-	"net"
 	"runtime"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"game-server/internal/protocol"
 	"game-server/internal/protocol/internalpb"
@@ -16,41 +16,48 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+// shardSelectTimeout 选服可能访问 Redis
+const shardSelectTimeout = time.Second
+
 type NetServer struct {
-	svc        *Server
-	gameRouter *GameRouter
+	svc *Server
+
+	// ⭐ 每个 game 服一个 GameRouter，玩家所在服由 selector 决定
+	selector    *router.ShardSelector
+	gameRouters map[string]*GameRouter // shard id -> router
 
 	mu         sync.RWMutex
 	gateConns  map[int64]*transport.BufferedConn // gateID -> conn
+	gateNames  map[int64]string                  // gateID -> GateRegister.gate_id
 	nextGateID int64
 	// session -> gateID
 	sessionGate map[int64]int64
 
 	connOptions transport.ConnOptions
 
-	routeMu     sync.RWMutex
-	playerRoute map[int64]*GameRouter
-
 	dispatchQueues []chan *internalpb.Envelope
 	dispatchOnce   sync.Once
 
 	closing atomic.Bool
 }
 
-func NewNetServer(svc *Server, gameRouter *GameRouter, connOptions transport.ConnOptions) *NetServer {
-	return &NetServer{
+func NewNetServer(svc *Server, selector *router.ShardSelector, gameRouters map[string]*GameRouter, connOptions transport.ConnOptions) *NetServer {
+	n := &NetServer{
 		svc:         svc,
-		gameRouter:  gameRouter,
+		selector:    selector,
+		gameRouters: gameRouters,
 		gateConns:   make(map[int64]*transport.BufferedConn),
+		gateNames:   make(map[int64]string),
 		sessionGate: make(map[int64]int64),
-		playerRoute: make(map[int64]*GameRouter),
 		connOptions: connOptions,
 	}
+	n.registerMetrics()
+	return n
 }
 
 func (n *NetServer) ListenAndServe(ctx context.Context, addr string) error {
 	//n.startDispatchers(ctx)
-	ln, err := net.Listen("tcp", addr)
+	ln, err := transport.Listen(addr, n.connOptions)
 	if err != nil {
 		return err
 	}
@@ -95,6 +102,7 @@ func (n *NetServer) handleGateConn(ctx context.Context, gateID int64, conn *tran
 
 		n.mu.Lock()
 		delete(n.gateConns, gateID)
+		delete(n.gateNames, gateID)
 		for sid, gid := range n.sessionGate {
 			if gid == gateID {
 				delete(n.sessionGate, sid)
@@ -113,6 +121,21 @@ func (n *NetServer) handleGateConn(ctx context.Context, gateID int64, conn *tran
 			return
 		}
 
+		if env.MsgId == protocol.MsgGateRegister {
+			n.onGateRegister(gateID, env.Payload)
+			continue
+		}
+		// ⭐ 健康检查直接在本连接回 Pong（Ping 不带 session，走不了 replyToGate）
+		if env.MsgId == protocol.MsgServicePing {
+			_ = conn.WriteEnvelope(&internalpb.Envelope{MsgId: protocol.MsgServicePong})
+			continue
+		}
+		// 玩家迁移到其他 game 服：丢掉本地选服缓存，下次从 Redis 读取新分配
+		if env.MsgId == protocol.MsgPlayerShardChanged {
+			n.onPlayerShardChanged(env.Payload)
+			continue
+		}
+
 		// 记录 session -> gate 映射
 		if env.SessionId != 0 {
 			n.mu.Lock()
@@ -125,6 +148,22 @@ func (n *NetServer) handleGateConn(ctx context.Context, gateID int64, conn *tran
 	}
 }
 
+func (n *NetServer) onPlayerShardChanged(payload []byte) {
+	var msg internalpb.PlayerShardChanged
+	if err := proto.Unmarshal(payload, &msg); err != nil || msg.PlayerId == 0 {
+		return
+	}
+	if n.selector != nil {
+		n.selector.Forget(msg.PlayerId)
+	}
+	n.svc.logger.Info("player shard changed",
+		zap.Int("msg_id", protocol.MsgPlayerShardChanged),
+		zap.Int64("player", msg.PlayerId),
+		zap.String("reason", "migrate"),
+		zap.String("shard", msg.Shard),
+	)
+}
+
 func (n *NetServer) dispatchEnvelope(ctx context.Context, env *internalpb.Envelope) {
 	msgID := int(env.MsgId)
 	if rule, ok := router.GetRoute(msgID); ok && rule.Target == router.TargetGame {
@@ -139,11 +178,13 @@ func (n *NetServer) dispatchEnvelope(ctx context.Context, env *internalpb.Envelo
 		MsgID:     msgID,
 		Payload:   env.Payload,
 		TraceID:   fmt.Sprintf("session-%d", env.SessionId),
+		Client:    env.Client,
+		Codec:     env.Codec,
 		Reply: func(replyMsgID int, data []byte) error {
-			return n.replyToGate(env.SessionId, replyMsgID, data)
+			return n.replyToGate(env.SessionId, replyMsgID, env.Codec, data)
 		},
 		Push: func(pushMsgID int, data []byte) error {
-			return n.replyToGate(env.SessionId, pushMsgID, data)
+			return n.replyToGate(env.SessionId, pushMsgID, env.Codec, data)
 		},
 		// ⭐ 关键修正点
 		ReplyError: nil, // 先占位
@@ -157,11 +198,54 @@ func (n *NetServer) dispatchEnvelope(ctx context.Context, env *internalpb.Envelo
 				SessionId: env.SessionId,
 				PlayerId:  env.PlayerId,
 				Payload:   data,
+				Client:    env.Client, // 登录后进入游戏时带给 game
+				Codec:     env.Codec,
 			}
 			return n.routeToGame(gameEnv)
 		},
 	}
 
+	serviceCtx.Broadcast = func(msgID int, data []byte) error {
+		return n.BroadcastGateControl(&internalpb.GateControl{
+			Op:      internalpb.GateControl_BROADCAST,
+			MsgId:   int32(msgID),
+			Payload: data,
+			Codec:   env.Codec,
+		})
+	}
+	serviceCtx.Multicast = func(playerIDs []int64, msgID int, data []byte) error {
+		return n.BroadcastGateControl(&internalpb.GateControl{
+			Op:        internalpb.GateControl_MULTICAST,
+			MsgId:     int32(msgID),
+			Payload:   data,
+			PlayerIds: playerIDs,
+			Codec:     env.Codec,
+		})
+	}
+	serviceCtx.JoinGroup = func(group string) error {
+		return n.sessionGateControl(env.SessionId, &internalpb.GateControl{
+			Op:         internalpb.GateControl_GROUP_JOIN,
+			Group:      group,
+			SessionIds: []int64{env.SessionId},
+		})
+	}
+	serviceCtx.LeaveGroup = func(group string) error {
+		return n.sessionGateControl(env.SessionId, &internalpb.GateControl{
+			Op:         internalpb.GateControl_GROUP_LEAVE,
+			Group:      group,
+			SessionIds: []int64{env.SessionId},
+		})
+	}
+	serviceCtx.PublishGroup = func(group string, msgID int, data []byte) error {
+		return n.BroadcastGateControl(&internalpb.GateControl{
+			Op:      internalpb.GateControl_GROUP_CAST,
+			MsgId:   int32(msgID),
+			Payload: data,
+			Group:   group,
+			Codec:   env.Codec,
+		})
+	}
+
 	// ⭐ 在 Context 构造完成后，再绑定 ReplyError
 	serviceCtx.ReplyError = makeReplyError(serviceCtx)
 
@@ -220,12 +304,11 @@ func makeReplyError(ctx *Context) func(protocol.ErrorCode, string) error {
 			Code:    int32(code),
 			Message: msg,
 		}
-		data, _ := proto.Marshal(rsp)
-		return ctx.Reply(protocol.MsgErrorRsp, data)
+		return ctx.ReplyMessage(protocol.MsgErrorRsp, rsp)
 	}
 }
 
-func (n *NetServer) replyToGate(sessionID int64, msgID int, data []byte) error {
+func (n *NetServer) replyToGate(sessionID int64, msgID int, codecName string, data []byte) error {
 	n.mu.RLock()
 	gateID, ok := n.sessionGate[sessionID]
 	if !ok {
@@ -243,37 +326,110 @@ func (n *NetServer) replyToGate(sessionID int64, msgID int, data []byte) error {
 		MsgId:     int32(msgID),
 		SessionId: sessionID,
 		Payload:   data,
+		Codec:     codecName,
 	}
 	return conn.WriteEnvelope(env)
 }
 
 func (n *NetServer) ForwardToGate(env *internalpb.Envelope) error {
-	return n.replyToGate(env.SessionId, int(env.MsgId), env.Payload)
+	if env.MsgId == protocol.MsgGateControl {
+		return n.forwardGateControl(env)
+	}
+	return n.replyToGate(env.SessionId, int(env.MsgId), env.Codec, env.Payload)
+}
+
+// BroadcastGateControl 控制消息发给所有 Gate（广播 / 组播 / 分组推送）
+func (n *NetServer) BroadcastGateControl(ctrl *internalpb.GateControl) error {
+	data, err := proto.Marshal(ctrl)
+	if err != nil {
+		return err
+	}
+	return n.writeAllGates(&internalpb.Envelope{
+		MsgId:   protocol.MsgGateControl,
+		Payload: data,
+	})
+}
+
+// sessionGateControl 控制消息只发给会话所在 Gate（加入 / 离开分组）
+func (n *NetServer) sessionGateControl(sessionID int64, ctrl *internalpb.GateControl) error {
+	data, err := proto.Marshal(ctrl)
+	if err != nil {
+		return err
+	}
+	return n.replyToGate(sessionID, protocol.MsgGateControl, "", data)
+}
+
+// forwardGateControl Game 发来的控制消息：带 session 的发给对应 Gate，否则发给所有 Gate
+func (n *NetServer) forwardGateControl(env *internalpb.Envelope) error {
+	if env.SessionId != 0 {
+		return n.replyToGate(env.SessionId, protocol.MsgGateControl, "", env.Payload)
+	}
+	return n.writeAllGates(&internalpb.Envelope{
+		MsgId:   protocol.MsgGateControl,
+		Payload: env.Payload,
+	})
+}
+
+func (n *NetServer) onGateRegister(gateID int64, payload []byte) {
+	var reg internalpb.GateRegister
+	if err := proto.Unmarshal(payload, &reg); err != nil || reg.GateId == "" {
+		return
+	}
+	n.mu.Lock()
+	n.gateNames[gateID] = reg.GateId
+	n.mu.Unlock()
+
+	n.svc.logger.Info("gate registered",
+		zap.Int64("gate_id", gateID),
+		zap.String("gate", reg.GateId),
+	)
+}
+
+// writeAllGates 每个 Gate 只写一次：同一 Gate 的连接池按 gate_id 去重
+func (n *NetServer) writeAllGates(env *internalpb.Envelope) error {
+	n.mu.RLock()
+	conns := make([]*transport.BufferedConn, 0, len(n.gateConns))
+	seen := make(map[string]struct{}, len(n.gateConns))
+	for gateID, conn := range n.gateConns {
+		if name, ok := n.gateNames[gateID]; ok {
+			if _, dup := seen[name]; dup {
+				continue
+			}
+			seen[name] = struct{}{}
+		}
+		conns = append(conns, conn)
+	}
+	n.mu.RUnlock()
+
+	if len(conns) == 0 {
+		return protocol.InternalErrNoGateConnection
+	}
+	var firstErr error
+	for _, conn := range conns {
+		if err := conn.WriteEnvelope(env); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
 }
 
 func (n *NetServer) routeToGame(env *internalpb.Envelope) error {
-	if n.gameRouter == nil {
+	if n.selector == nil || len(n.gameRouters) == 0 {
 		return protocol.InternalErrRemoteNotReady
 	}
-	router := n.gameRouter
-	msgID := int(env.MsgId)
-	if env.PlayerId != 0 {
-		n.routeMu.RLock()
-		r, ok := n.playerRoute[env.PlayerId]
-		n.routeMu.RUnlock()
-		if ok {
-			router = r
-		} else {
-			n.routeMu.Lock()
-			n.playerRoute[env.PlayerId] = n.gameRouter
-			n.routeMu.Unlock()
-		}
+	ctx, cancel := context.WithTimeout(context.Background(), shardSelectTimeout)
+	shard, err := n.selector.Select(ctx, env.PlayerId)
+	cancel()
+	if err != nil {
+		return err
+	}
+	gameRouter := n.gameRouters[shard.ID]
+	if gameRouter == nil {
+		return protocol.InternalErrGameRouterNotReady
 	}
-	err := router.Send(env)
-	if env.PlayerId != 0 && msgID == protocol.MsgPlayerOfflineNotify {
-		n.routeMu.Lock()
-		delete(n.playerRoute, env.PlayerId)
-		n.routeMu.Unlock()
+	err = gameRouter.Send(env)
+	if env.PlayerId != 0 && int(env.MsgId) == protocol.MsgPlayerOfflineNotify {
+		n.selector.Forget(env.PlayerId)
 	}
 	return err
 }
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"game-server/internal/handler"
+	"game-server/internal/protocol"
+	"game-server/internal/protocol/internalpb"
+	"game-server/internal/router"
+	"game-server/internal/transport"
+)
+
+// chatProbe 记录 chat 请求是否交给了 service 本地处理
+type chatProbe struct {
+	handled int
+}
+
+func (m *chatProbe) Name() string { return "chat" }
+func (m *chatProbe) Init() error  { return nil }
+
+func (m *chatProbe) RegisterHandlers(reg *handler.Registry[HandlerFunc]) error {
+	return RegisterChatSendReq(reg, func(*Context, *internalpb.ChatSendReq) (*internalpb.ChatSendRsp, error) {
+		m.handled++
+		return nil, nil
+	})
+}
+
+// 路由表热加载后，dispatchEnvelope 立即按新表决定本地处理还是转发 game
+func TestDispatchEnvelopeSeesReloadedRoutes(t *testing.T) {
+	t.Cleanup(func() {
+		if _, err := router.Load(router.DefaultEntries()); err != nil {
+			t.Fatalf("restore routes: %v", err)
+		}
+	})
+
+	svc := NewServer(nil)
+	probe := &chatProbe{}
+	if err := svc.RegisterModule(probe); err != nil {
+		t.Fatalf("register module: %v", err)
+	}
+	n := NewNetServer(svc, nil, nil, transport.ConnOptions{})
+	env := &internalpb.Envelope{MsgId: protocol.MsgChatSendReq, SessionId: 1}
+
+	if _, err := router.Load([]router.RouteEntry{
+		{MsgBegin: protocol.MsgChatBegin, MsgEnd: protocol.MsgChatEnd, Target: router.TargetService, Module: "chat"},
+	}); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	n.dispatchEnvelope(context.Background(), env)
+	if probe.handled != 1 {
+		t.Fatalf("handled = %d, want 1 with a service route", probe.handled)
+	}
+
+	// 改成单个 id 转发 game：没有 game 连接时丢弃，本地 handler 不再被调用
+	if _, err := router.Load([]router.RouteEntry{
+		{MsgBegin: protocol.MsgChatBegin, MsgEnd: protocol.MsgChatEnd, Target: router.TargetService, Module: "chat"},
+		{MsgID: protocol.MsgChatSendReq, Target: router.TargetGame},
+	}); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	n.dispatchEnvelope(context.Background(), env)
+	if probe.handled != 1 {
+		t.Fatalf("handled = %d after rerouting to game, want still 1", probe.handled)
+	}
+}
@@ -4,6 +4,7 @@ package service
 import (
 	"fmt"
 	"game-server/internal/handler"
+	"game-server/internal/protocol/msgtype"
 	"sync"
 )
 
@@ -36,6 +37,12 @@ func (r *Registry) Register(m Module) error {
 	if err := m.RegisterHandlers(r.handlers); err != nil {
 		return fmt.Errorf("register handlers for module %s failed: %w", name, err)
 	}
+	// ⭐ 每个 handler 的 msgID 都要在 msgtype 表里有类型，缺了启动即失败
+	for _, msgID := range r.handlers.IDs() {
+		if _, ok := msgtype.Lookup(msgID); !ok {
+			return fmt.Errorf("register handlers for module %s failed: %w: %d", name, msgtype.ErrUnboundMsgID, msgID)
+		}
+	}
 
 	r.modules[name] = m
 	return nil
@@ -2,14 +2,18 @@ package transport
 
 import (
 	"bufio"
+	"crypto/tls"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"game-server/internal/protocol/internalpb"
 )
 
-const defaultBufferSize = 4 * 1024 * 1024
+// defaultBufferSize bufio 只用于合并小包：超过缓冲的帧直接读写底层连接，帧本身用池化缓冲，
+// 因此按连接数线性增长的常驻内存只有这两块小缓冲
+const defaultBufferSize = 4 * 1024
 
 type BufferedConn struct {
 	conn    net.Conn
@@ -19,12 +23,19 @@ type BufferedConn struct {
 
 	readTimeout  time.Duration
 	writeTimeout time.Duration
+
+	compression atomic.Int32 // internalpb.Compression，下行超过阈值的 payload 按此压缩
 }
 
 type ConnOptions struct {
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	KeepAlive    time.Duration
+
+	TLS *TLSProvider // 内部链路 mTLS；nil 为明文
+
+	WriteBatch int           // 写协程一次合并的最大消息数，0 取默认 64
+	WriteDelay time.Duration // 凑批最多等待的时间，0 表示只合并已排队的消息
 }
 
 func NewBufferedConn(conn net.Conn) *BufferedConn {
@@ -32,7 +43,11 @@ func NewBufferedConn(conn net.Conn) *BufferedConn {
 }
 
 func NewBufferedConnWithOptions(conn net.Conn, opts ConnOptions) *BufferedConn {
-	if tcpConn, ok := conn.(*net.TCPConn); ok && opts.KeepAlive > 0 {
+	raw := conn
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		raw = tlsConn.NetConn()
+	}
+	if tcpConn, ok := raw.(*net.TCPConn); ok && opts.KeepAlive > 0 {
 		_ = tcpConn.SetKeepAlive(true)
 		_ = tcpConn.SetKeepAlivePeriod(opts.KeepAlive)
 	}
@@ -52,7 +67,14 @@ func (c *BufferedConn) ReadEnvelope() (*internalpb.Envelope, error) {
 	return readEnvelope(c.reader)
 }
 
+// SetCompression 设置下行压缩算法；上行按 Envelope 自带的标记解压，不受影响
+func (c *BufferedConn) SetCompression(algo internalpb.Compression) {
+	c.compression.Store(int32(algo))
+}
+
 func (c *BufferedConn) WriteEnvelope(env *internalpb.Envelope) error {
+	env, release := compressForWrite(env, internalpb.Compression(c.compression.Load()))
+	defer release()
 	c.writeMu.Lock()
 	defer c.writeMu.Unlock()
 	if c.writeTimeout > 0 {
@@ -64,6 +86,63 @@ func (c *BufferedConn) WriteEnvelope(env *internalpb.Envelope) error {
 	return c.writer.Flush()
 }
 
+func (c *BufferedConn) WritePrepared(p *PreparedEnvelope) error {
+	p = p.compressed(internalpb.Compression(c.compression.Load()))
+	data, err := p.Binary()
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.writeTimeout > 0 {
+		_ = c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+	if err := writeFrame(c.writer, data); err != nil {
+		return err
+	}
+	return c.writer.Flush()
+}
+
+// BufferEnvelope 只写入缓冲，缓冲满时 bufio 会自行写出；需调用 Flush 结束一批
+func (c *BufferedConn) BufferEnvelope(env *internalpb.Envelope) error {
+	env, release := compressForWrite(env, internalpb.Compression(c.compression.Load()))
+	defer release()
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.writeTimeout > 0 {
+		_ = c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+	return writeEnvelope(c.writer, env)
+}
+
+func (c *BufferedConn) BufferPrepared(p *PreparedEnvelope) error {
+	p = p.compressed(internalpb.Compression(c.compression.Load()))
+	data, err := p.Binary()
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.writeTimeout > 0 {
+		_ = c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+	return writeFrame(c.writer, data)
+}
+
+func (c *BufferedConn) Flush() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.writeTimeout > 0 {
+		_ = c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+	return c.writer.Flush()
+}
+
+// RemoteAddr 对端地址，Gate 按它做 IP 过滤
+func (c *BufferedConn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
 func (c *BufferedConn) Close() error {
 	return c.conn.Close()
 }
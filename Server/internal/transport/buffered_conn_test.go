@@ -0,0 +1,297 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"runtime"
+	"testing"
+
+	"game-server/internal/protocol/internalpb"
+	"google.golang.org/protobuf/proto"
+)
+
+// discardConn 丢弃所有写入，用来测写路径本身的开销
+type discardConn struct{ net.Conn }
+
+func (discardConn) Write(p []byte) (int, error) { return len(p), nil }
+func (discardConn) Close() error                { return nil }
+
+// loopConn 循环读出同一段已编码的帧流，用来测读路径
+type loopConn struct {
+	net.Conn
+	data []byte
+	off  int
+}
+
+func (c *loopConn) Read(p []byte) (int, error) {
+	if c.off == len(c.data) {
+		c.off = 0
+	}
+	n := copy(p, c.data[c.off:])
+	c.off += n
+	return n, nil
+}
+
+func (c *loopConn) Close() error { return nil }
+
+// recordReader 记下 Read 收到的每块缓冲（即池化的帧），测试随后改写它们模拟被下一次 getFrame 复用
+type recordReader struct {
+	r    *bytes.Reader
+	bufs [][]byte
+}
+
+func (r *recordReader) Read(p []byte) (int, error) {
+	r.bufs = append(r.bufs, p)
+	return r.r.Read(p)
+}
+
+func encodeForTest(t testing.TB, env *internalpb.Envelope) []byte {
+	t.Helper()
+	frame, err := encodeFrame(env)
+	if err != nil {
+		t.Fatalf("encode frame: %v", err)
+	}
+	defer putFrame(frame)
+	return append([]byte(nil), *frame...)
+}
+
+func benchPayload(size int) []byte {
+	payload := make([]byte, size)
+	for i := range payload {
+		payload[i] = byte(i % 251)
+	}
+	return payload
+}
+
+var benchSizes = []int{64, 1 << 10, 16 << 10}
+
+// =======================
+// 池化帧不被持有
+// =======================
+
+func TestReadEnvelopeDoesNotRetainFrame(t *testing.T) {
+	for _, algo := range []internalpb.Compression{
+		internalpb.Compression_COMPRESSION_NONE,
+		internalpb.Compression_COMPRESSION_SNAPPY,
+		internalpb.Compression_COMPRESSION_ZSTD,
+	} {
+		t.Run(algo.String(), func(t *testing.T) {
+			payload := bytes.Repeat([]byte("pooled-frame "), 512)
+			src := compressPayload(&internalpb.Envelope{
+				MsgId:   42,
+				Codec:   "json",
+				Payload: payload,
+			}, algo)
+			rr := &recordReader{r: bytes.NewReader(encodeForTest(t, src))}
+
+			env, err := readEnvelope(rr)
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			// putFrame 之后帧缓冲随时会被别的连接拿去复用
+			for _, buf := range rr.bufs {
+				for i := range buf {
+					buf[i] = 0xff
+				}
+			}
+			if env.MsgId != 42 || env.Codec != "json" || !bytes.Equal(env.Payload, payload) {
+				t.Fatalf("envelope changed after frame reuse: msg_id=%d codec=%q payload %d bytes",
+					env.MsgId, env.Codec, len(env.Payload))
+			}
+		})
+	}
+}
+
+func TestWriteEnvelopeReleasesCompressedCopy(t *testing.T) {
+	var out bytes.Buffer
+	conn := NewBufferedConn(&bufferConn{buf: &out})
+	conn.SetCompression(internalpb.Compression_COMPRESSION_SNAPPY)
+
+	payload := bytes.Repeat([]byte("compress-me "), 1024)
+	env := &internalpb.Envelope{MsgId: 7, Seq: 3, Payload: payload}
+	if err := conn.WriteEnvelope(env); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	// 压缩副本和压缩缓冲已归还：再取一轮并写满，调用方的 Envelope 和已写出的数据都不能受影响
+	for _, class := range frameClasses {
+		p := getFrame(class)
+		for i := range *p {
+			(*p)[i] = 0xff
+		}
+		putFrame(p)
+	}
+	scratch := getEnvelope()
+	scratch.MsgId = 999
+	putEnvelope(scratch)
+
+	if env.Compression != internalpb.Compression_COMPRESSION_NONE || !bytes.Equal(env.Payload, payload) {
+		t.Fatalf("caller envelope modified: compression=%v payload %d bytes", env.Compression, len(env.Payload))
+	}
+	got, err := readEnvelope(&out)
+	if err != nil {
+		t.Fatalf("read back: %v", err)
+	}
+	if got.MsgId != 7 || got.Seq != 3 || !bytes.Equal(got.Payload, payload) {
+		t.Fatalf("written frame corrupted: %v", got)
+	}
+}
+
+func TestPutFrameDropsUnpooledSizes(t *testing.T) {
+	large := frameClasses[len(frameClasses)-1] + 1
+	p := getFrame(large)
+	if len(*p) != large || cap(*p) != large {
+		t.Fatalf("oversized frame len=%d cap=%d, want exact %d", len(*p), cap(*p), large)
+	}
+	putFrame(p)
+	if len(*p) != large {
+		t.Fatal("putFrame touched a frame it does not pool")
+	}
+
+	p = getFrame(100)
+	if len(*p) != 100 || cap(*p) != frameClasses[0] {
+		t.Fatalf("small frame len=%d cap=%d", len(*p), cap(*p))
+	}
+	putFrame(p)
+	if len(*p) != 0 {
+		t.Fatalf("pooled frame len=%d after putFrame, want 0", len(*p))
+	}
+}
+
+// bufferConn 把写入收集到 bytes.Buffer
+type bufferConn struct {
+	net.Conn
+	buf *bytes.Buffer
+}
+
+func (c *bufferConn) Write(p []byte) (int, error) { return c.buf.Write(p) }
+func (c *bufferConn) Close() error                { return nil }
+
+// =======================
+// 读写基准：单连接 / 1 万连接
+// =======================
+
+func BenchmarkWriteEnvelope(b *testing.B) {
+	for _, size := range benchSizes {
+		b.Run(fmt.Sprintf("%dB", size), func(b *testing.B) {
+			conn := NewBufferedConn(discardConn{})
+			env := &internalpb.Envelope{MsgId: 1001, SessionId: 1, PlayerId: 2, Seq: 3, Payload: benchPayload(size)}
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := conn.WriteEnvelope(env); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkWriteEnvelopeSnappy(b *testing.B) {
+	for _, size := range benchSizes {
+		b.Run(fmt.Sprintf("%dB", size), func(b *testing.B) {
+			conn := NewBufferedConn(discardConn{})
+			conn.SetCompression(internalpb.Compression_COMPRESSION_SNAPPY)
+			env := &internalpb.Envelope{MsgId: 1001, Payload: benchPayload(size)}
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := conn.WriteEnvelope(env); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkReadEnvelope(b *testing.B) {
+	for _, size := range benchSizes {
+		b.Run(fmt.Sprintf("%dB", size), func(b *testing.B) {
+			frame := encodeForTest(b, &internalpb.Envelope{MsgId: 1001, SessionId: 1, Payload: benchPayload(size)})
+			conn := NewBufferedConn(&loopConn{data: frame})
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := conn.ReadEnvelope(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+const benchConnCount = 10000
+
+// heapPerConn 建 n 条连接前后的堆差值，按连接平均
+func heapPerConn(n int, build func(i int)) float64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	for i := 0; i < n; i++ {
+		build(i)
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	return float64(after.HeapAlloc-before.HeapAlloc) / float64(n)
+}
+
+// BenchmarkWriteEnvelope10kConns 1 万条连接轮流写，观察常驻内存和写路径分配是否随连接数增长
+func BenchmarkWriteEnvelope10kConns(b *testing.B) {
+	conns := make([]*BufferedConn, benchConnCount)
+	perConn := heapPerConn(benchConnCount, func(i int) {
+		conns[i] = NewBufferedConn(discardConn{})
+	})
+	env := &internalpb.Envelope{MsgId: 1001, SessionId: 1, Payload: benchPayload(256)}
+
+	b.SetBytes(256)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := conns[i%benchConnCount].WriteEnvelope(env); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	b.ReportMetric(perConn, "heap-bytes/conn")
+	runtime.KeepAlive(conns)
+}
+
+func BenchmarkReadEnvelope10kConns(b *testing.B) {
+	frame := encodeForTest(b, &internalpb.Envelope{MsgId: 1001, SessionId: 1, Payload: benchPayload(256)})
+	conns := make([]*BufferedConn, benchConnCount)
+	perConn := heapPerConn(benchConnCount, func(i int) {
+		conns[i] = NewBufferedConn(&loopConn{data: frame})
+	})
+
+	b.SetBytes(256)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := conns[i%benchConnCount].ReadEnvelope(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	b.ReportMetric(perConn, "heap-bytes/conn")
+	runtime.KeepAlive(conns)
+}
+
+// 对照：帧不走池、每次新分配时的读路径
+func BenchmarkReadEnvelopeUnpooled(b *testing.B) {
+	frame := encodeForTest(b, &internalpb.Envelope{MsgId: 1001, SessionId: 1, Payload: benchPayload(1 << 10)})
+	body := frame[4:]
+	b.SetBytes(1 << 10)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf := make([]byte, len(body))
+		copy(buf, body)
+		var env internalpb.Envelope
+		if err := proto.Unmarshal(buf, &env); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
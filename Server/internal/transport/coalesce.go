@@ -0,0 +1,132 @@
+package transport
+
+import (
+	"time"
+
+	"game-server/internal/protocol/internalpb"
+)
+
+const defaultWriteBatch = 64
+
+// BatchWriter 支持先缓冲多条、最后统一 Flush 的连接；写协程用它把一批消息合并成一次系统调用
+type BatchWriter interface {
+	BufferEnvelope(*internalpb.Envelope) error
+	BufferPrepared(*PreparedEnvelope) error
+	Flush() error
+}
+
+// Coalescer 写协程取到一条消息后，顺带取出队列里已排队的消息凑成一批：
+// WriteDelay 为 0 时只取已排队的，不增加延迟；大于 0 时队列空了再最多等这么久凑满 WriteBatch
+type Coalescer[T any] struct {
+	max   int
+	delay time.Duration
+	timer *time.Timer
+	batch []T
+}
+
+func NewCoalescer[T any](opts ConnOptions) *Coalescer[T] {
+	limit := opts.WriteBatch
+	if limit <= 0 {
+		limit = defaultWriteBatch
+	}
+	return &Coalescer[T]{
+		max:   limit,
+		delay: opts.WriteDelay,
+		batch: make([]T, 0, limit),
+	}
+}
+
+// Collect 返回以 first 开头的一批消息；凑批最多等 WriteDelay（从 first 取出时算起），
+// done 关闭时立即返回已取到的部分。返回的切片在下一次 Collect 前有效
+func (c *Coalescer[T]) Collect(ch <-chan T, first T, done <-chan struct{}) []T {
+	clear(c.batch) // 不持有上一批消息的引用
+	batch := append(c.batch[:0], first)
+	armed := false
+collect:
+	for len(batch) < c.max {
+		select {
+		case item := <-ch:
+			batch = append(batch, item)
+			continue
+		default:
+		}
+		if c.delay <= 0 {
+			break
+		}
+		if !armed {
+			if c.timer == nil {
+				c.timer = time.NewTimer(c.delay)
+			} else {
+				c.timer.Reset(c.delay)
+			}
+			armed = true
+		}
+		select {
+		case item := <-ch:
+			batch = append(batch, item)
+		case <-c.timer.C:
+			armed = false
+			break collect
+		case <-done:
+			break collect
+		}
+	}
+	if armed {
+		c.timer.Stop()
+	}
+	c.batch = batch
+	return batch
+}
+
+// CollectFunc 同 Collect，消息来自多个队列时使用：next 按优先级非阻塞地取一条，
+// ready 在有新消息入队时可读
+func (c *Coalescer[T]) CollectFunc(first T, next func() (T, bool), ready, done <-chan struct{}) []T {
+	clear(c.batch)
+	batch := append(c.batch[:0], first)
+	armed := false
+collect:
+	for len(batch) < c.max {
+		if item, ok := next(); ok {
+			batch = append(batch, item)
+			continue
+		}
+		if c.delay <= 0 {
+			break
+		}
+		if !armed {
+			if c.timer == nil {
+				c.timer = time.NewTimer(c.delay)
+			} else {
+				c.timer.Reset(c.delay)
+			}
+			armed = true
+		}
+		select {
+		case <-ready:
+		case <-c.timer.C:
+			armed = false
+			break collect
+		case <-done:
+			break collect
+		}
+	}
+	if armed {
+		c.timer.Stop()
+	}
+	c.batch = batch
+	return batch
+}
+
+// WriteBatch 逐条写入缓冲后统一 Flush。失败时缓冲里已经凑在一起的消息都无法确认送达，
+// 返回值为整批条数，调用方计入丢弃数
+func WriteBatch(w BatchWriter, batch []*internalpb.Envelope) (int, error) {
+	for _, env := range batch {
+		if err := w.BufferEnvelope(env); err != nil {
+			return len(batch), err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return len(batch), err
+	}
+	return 0, nil
+}
@@ -0,0 +1,143 @@
+package transport
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"game-server/internal/protocol/internalpb"
+)
+
+var errBatchWrite = errors.New("batch write failed")
+
+// failingBatchWriter 第 failAt 次 BufferEnvelope 失败；failAt < 0 时改为 Flush 失败
+type failingBatchWriter struct {
+	failAt   int
+	buffered int
+}
+
+func (w *failingBatchWriter) BufferEnvelope(*internalpb.Envelope) error {
+	if w.buffered == w.failAt {
+		return errBatchWrite
+	}
+	w.buffered++
+	return nil
+}
+
+func (w *failingBatchWriter) BufferPrepared(*PreparedEnvelope) error { return nil }
+
+func (w *failingBatchWriter) Flush() error {
+	if w.failAt < 0 {
+		return errBatchWrite
+	}
+	return nil
+}
+
+func TestWriteBatchCountsUnsent(t *testing.T) {
+	batch := make([]*internalpb.Envelope, 5)
+	for i := range batch {
+		batch[i] = &internalpb.Envelope{MsgId: int32(i)}
+	}
+
+	cases := []struct {
+		name   string
+		failAt int
+		unsent int
+	}{
+		{"ok", len(batch), 0},
+		{"first buffer", 0, len(batch)},
+		{"mid buffer", 3, len(batch)},
+		{"flush", -1, len(batch)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			unsent, err := WriteBatch(&failingBatchWriter{failAt: tc.failAt}, batch)
+			if unsent != tc.unsent {
+				t.Fatalf("unsent = %d, want %d", unsent, tc.unsent)
+			}
+			if (err != nil) != (tc.unsent > 0) {
+				t.Fatalf("err = %v with unsent %d", err, unsent)
+			}
+		})
+	}
+}
+
+// loopbackConn 本地 TCP 连接，对端读出并丢弃，基准里包含真实的系统调用开销
+func loopbackConn(b *testing.B) *BufferedConn {
+	b.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		_, _ = io.Copy(io.Discard, c)
+		_ = c.Close()
+	}()
+	nc, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		b.Fatal(err)
+	}
+	conn := NewBufferedConn(nc)
+	b.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// benchWriteLoop 生产者往队列里塞消息，写协程负责写出；batched 为 false 时是合批前的逐条 Write + Flush
+func benchWriteLoop(b *testing.B, batched bool) {
+	conn := loopbackConn(b)
+	env := &internalpb.Envelope{MsgId: 1001, SessionId: 1, PlayerId: 2, Payload: benchPayload(128)}
+	ch := make(chan *internalpb.Envelope, 2048)
+	done := make(chan struct{})
+	finished := make(chan error, 1)
+
+	go func() {
+		co := NewCoalescer[*internalpb.Envelope](ConnOptions{})
+		written := 0
+		for written < b.N {
+			first := <-ch
+			if !batched {
+				if err := conn.WriteEnvelope(first); err != nil {
+					finished <- err
+					return
+				}
+				written++
+				continue
+			}
+			batch := co.Collect(ch, first, done)
+			if _, err := WriteBatch(conn, batch); err != nil {
+				finished <- err
+				return
+			}
+			written += len(batch)
+		}
+		finished <- nil
+	}()
+
+	b.SetBytes(int64(len(env.Payload)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ch <- env
+	}
+	if err := <-finished; err != nil {
+		b.Fatal(err)
+	}
+	b.StopTimer()
+	close(done)
+}
+
+// BenchmarkWriteLoopPerMessage 合批前：每条消息一次 Flush（一次系统调用）
+func BenchmarkWriteLoopPerMessage(b *testing.B) {
+	benchWriteLoop(b, false)
+}
+
+// BenchmarkWriteLoopBatched 合批后：已排队的消息一起写，一次 Flush
+func BenchmarkWriteLoopBatched(b *testing.B) {
+	benchWriteLoop(b, true)
+}
@@ -0,0 +1,176 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"game-server/internal/protocol/internalpb"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	ErrUnknownCompression = errors.New("unknown payload compression")
+	ErrPayloadTooLarge    = errors.New("decompressed payload too large")
+)
+
+const defaultCompressThreshold = 1024
+
+// compressionKinds Compression 枚举的取值个数
+const compressionKinds = 3
+
+var compressThreshold = defaultCompressThreshold
+
+// SetCompressThreshold payload 超过该字节数才压缩；0 恢复默认
+func SetCompressThreshold(size int) {
+	if size <= 0 {
+		compressThreshold = defaultCompressThreshold
+		return
+	}
+	compressThreshold = size
+}
+
+func CompressThreshold() int {
+	return compressThreshold
+}
+
+// Compressible 支持按连接设置下行压缩算法（握手后设置）
+type Compressible interface {
+	SetCompression(internalpb.Compression)
+}
+
+// ParseCompression 配置中的算法名：none / snappy / zstd
+func ParseCompression(name string) (internalpb.Compression, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "none":
+		return internalpb.Compression_COMPRESSION_NONE, nil
+	case "snappy":
+		return internalpb.Compression_COMPRESSION_SNAPPY, nil
+	case "zstd":
+		return internalpb.Compression_COMPRESSION_ZSTD, nil
+	default:
+		return internalpb.Compression_COMPRESSION_NONE, fmt.Errorf("%w: %s", ErrUnknownCompression, name)
+	}
+}
+
+// zstd 编解码器 EncodeAll / DecodeAll 可并发调用，进程内共享一份
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+func zstdCodec() (*zstd.Encoder, *zstd.Decoder, error) {
+	zstdOnce.Do(func() {
+		zstdEncoder, zstdErr = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+		if zstdErr != nil {
+			return
+		}
+		zstdDecoder, zstdErr = zstd.NewReader(nil,
+			zstd.WithDecoderConcurrency(0),
+			zstd.WithDecoderMaxMemory(uint64(maxEnvelopeSize)),
+		)
+	})
+	return zstdEncoder, zstdDecoder, zstdErr
+}
+
+func shouldCompress(env *internalpb.Envelope, algo internalpb.Compression) bool {
+	return algo != internalpb.Compression_COMPRESSION_NONE &&
+		env.Compression == internalpb.Compression_COMPRESSION_NONE &&
+		len(env.Payload) > compressThreshold
+}
+
+// encodePayload 压缩到 dst（可为 nil）；压缩失败或没有变小时返回 false
+func encodePayload(dst, payload []byte, algo internalpb.Compression) ([]byte, bool) {
+	var data []byte
+	switch algo {
+	case internalpb.Compression_COMPRESSION_SNAPPY:
+		data = s2.EncodeSnappy(dst, payload)
+	case internalpb.Compression_COMPRESSION_ZSTD:
+		enc, _, err := zstdCodec()
+		if err != nil {
+			return nil, false
+		}
+		data = enc.EncodeAll(payload, dst[:0])
+	default:
+		return nil, false
+	}
+	return data, len(data) < len(payload)
+}
+
+// compressPayload 超过阈值且压缩后更小时返回带压缩标记的新 Envelope，否则原样返回
+func compressPayload(env *internalpb.Envelope, algo internalpb.Compression) *internalpb.Envelope {
+	if !shouldCompress(env, algo) {
+		return env
+	}
+	data, ok := encodePayload(nil, env.Payload, algo)
+	if !ok {
+		return env
+	}
+	out := &internalpb.Envelope{}
+	copyEnvelopeHeader(out, env)
+	out.Payload = data
+	out.Compression = algo
+	return out
+}
+
+// compressForWrite 同 compressPayload，但压缩结果只在本次写入期间使用：
+// 副本和压缩缓冲都从池里取，写完调用 release 归还
+func compressForWrite(env *internalpb.Envelope, algo internalpb.Compression) (*internalpb.Envelope, func()) {
+	if !shouldCompress(env, algo) {
+		return env, func() {}
+	}
+	buf := getFrame(s2.MaxEncodedLen(len(env.Payload)))
+	data, ok := encodePayload((*buf)[:0], env.Payload, algo)
+	if !ok {
+		putFrame(buf)
+		return env, func() {}
+	}
+	out := getEnvelope()
+	copyEnvelopeHeader(out, env)
+	out.Payload = data
+	out.Compression = algo
+	return out, func() {
+		putEnvelope(out)
+		putFrame(buf)
+	}
+}
+
+// decompressPayload 读到的 Envelope 原地解压，之后业务层看到的都是原文
+func decompressPayload(env *internalpb.Envelope) error {
+	var (
+		data []byte
+		err  error
+	)
+	switch env.Compression {
+	case internalpb.Compression_COMPRESSION_NONE:
+		return nil
+	case internalpb.Compression_COMPRESSION_SNAPPY:
+		n, derr := s2.DecodedLen(env.Payload)
+		if derr != nil {
+			return derr
+		}
+		if maxEnvelopeSize > 0 && uint32(n) > maxEnvelopeSize {
+			return fmt.Errorf("%w: %d > %d", ErrPayloadTooLarge, n, maxEnvelopeSize)
+		}
+		data, err = s2.Decode(nil, env.Payload)
+	case internalpb.Compression_COMPRESSION_ZSTD:
+		_, dec, cerr := zstdCodec()
+		if cerr != nil {
+			return cerr
+		}
+		data, err = dec.DecodeAll(env.Payload, nil)
+	default:
+		return fmt.Errorf("%w: %d", ErrUnknownCompression, env.Compression)
+	}
+	if err != nil {
+		return err
+	}
+	env.Payload = data
+	env.Compression = internalpb.Compression_COMPRESSION_NONE
+	return nil
+}
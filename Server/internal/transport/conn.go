@@ -7,3 +7,9 @@ type Conn interface {
 	WriteEnvelope(*internalpb.Envelope) error
 	Close() error
 }
+
+// CodecSwitcher 帧格式随协商的 payload 编码变化的连接（WebSocket 的 json 走文本帧）；
+// 其余连接帧格式固定，payload 编码只体现在 Envelope.Codec 上
+type CodecSwitcher interface {
+	SetCodec(name string) error
+}
@@ -40,30 +40,41 @@ func readEnvelope(reader io.Reader) (*internalpb.Envelope, error) {
 	if maxEnvelopeSize > 0 && size > maxEnvelopeSize {
 		return nil, fmt.Errorf("envelope too large: %d > %d", size, maxEnvelopeSize)
 	}
-	data := make([]byte, size)
-	if _, err := io.ReadFull(reader, data); err != nil {
+	// ⭐ Unmarshal 会拷贝 bytes 字段，帧缓冲解码后即可归还
+	frame := getFrame(int(size))
+	defer putFrame(frame)
+	if _, err := io.ReadFull(reader, *frame); err != nil {
 		return nil, err
 	}
 
 	var env internalpb.Envelope
-	if err := proto.Unmarshal(data, &env); err != nil {
+	if err := proto.Unmarshal(*frame, &env); err != nil {
+		return nil, err
+	}
+	if err := decompressPayload(&env); err != nil {
 		return nil, err
 	}
 	return &env, nil
 }
 
 func writeEnvelope(writer io.Writer, env *internalpb.Envelope) error {
-	data, err := proto.Marshal(env)
+	frame, err := encodeFrame(env)
 	if err != nil {
 		return err
 	}
+	_, err = writer.Write(*frame)
+	putFrame(frame)
+	return err
+}
 
+// writeFrame 写入已编码的 Envelope（4 字节大端长度 + 数据）
+func writeFrame(writer io.Writer, data []byte) error {
 	var sizeBuf [4]byte
 	binary.BigEndian.PutUint32(sizeBuf[:], uint32(len(data)))
 
 	if _, err := writer.Write(sizeBuf[:]); err != nil {
 		return err
 	}
-	_, err = writer.Write(data)
+	_, err := writer.Write(data)
 	return err
 }
@@ -0,0 +1,63 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"game-server/internal/protocol/codec"
+	"game-server/internal/protocol/internalpb"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// marshalJSONEnvelope WebSocket 文本帧：payload 为 json 编码且未压缩时直接内联成 JSON 对象，
+// 网页工具不用再解 base64；其余情况与 protojson 一致（payload 为 base64）
+func marshalJSONEnvelope(env *internalpb.Envelope) ([]byte, error) {
+	if !inlinePayload(env) {
+		return protojson.Marshal(env)
+	}
+	head := getEnvelope()
+	defer putEnvelope(head)
+	copyEnvelopeHeader(head, env)
+	data, err := protojson.Marshal(head)
+	if err != nil {
+		return nil, err
+	}
+	// Codec 非空，data 至少是 {"codec":"json"}；去掉末尾的 } 接上 payload
+	data = bytes.TrimRight(data, " \n")
+	data = append(data[:len(data)-1], `,"payload":`...)
+	data = append(data, env.Payload...)
+	return append(data, '}'), nil
+}
+
+func inlinePayload(env *internalpb.Envelope) bool {
+	return env.Codec == codec.NameJSON &&
+		env.Compression == internalpb.Compression_COMPRESSION_NONE &&
+		len(env.Payload) > 0 &&
+		json.Valid(env.Payload)
+}
+
+// unmarshalJSONEnvelope 上行文本帧：payload 是 JSON 对象时按 json 编码处理，是字符串时按 base64
+func unmarshalJSONEnvelope(data []byte, env *internalpb.Envelope) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	raw, ok := fields["payload"]
+	raw = bytes.TrimSpace(raw)
+	if !ok || len(raw) == 0 || raw[0] != '{' {
+		return protojson.Unmarshal(data, env)
+	}
+	delete(fields, "payload")
+	rest, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	if err := protojson.Unmarshal(rest, env); err != nil {
+		return err
+	}
+	env.Payload = raw
+	if env.Codec == "" {
+		env.Codec = codec.NameJSON
+	}
+	return nil
+}
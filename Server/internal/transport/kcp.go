@@ -0,0 +1,146 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/xtaci/kcp-go/v5"
+)
+
+var ErrInvalidKCPConfig = errors.New("invalid kcp config")
+
+const (
+	defaultKCPInterval = 10 * time.Millisecond
+	defaultKCPWindow   = 256
+	defaultKCPMTU      = 1350
+)
+
+// KCPOptions 可靠 UDP 的拥塞 / 重传参数；零值字段取默认（接近 KCP 的快速模式）
+type KCPOptions struct {
+	NoDelay      bool
+	Interval     time.Duration // 内部 flush 间隔，越小延迟越低、CPU 越高
+	Resend       int           // 快速重传：被跳过多少次 ACK 即重传，0 关闭
+	NoCongestion bool          // 关闭拥塞控制，弱网下更激进
+	SendWindow   int
+	RecvWindow   int
+	MTU          int
+
+	DataShards   int // FEC，两者都为 0 时关闭
+	ParityShards int
+
+	SocketBuffer int // UDP socket 读写缓冲字节数，0 用系统默认
+}
+
+func (o KCPOptions) normalize() (KCPOptions, error) {
+	if o.Interval <= 0 {
+		o.Interval = defaultKCPInterval
+	}
+	if o.SendWindow <= 0 {
+		o.SendWindow = defaultKCPWindow
+	}
+	if o.RecvWindow <= 0 {
+		o.RecvWindow = defaultKCPWindow
+	}
+	if o.MTU <= 0 {
+		o.MTU = defaultKCPMTU
+	}
+	if o.MTU < 50 || o.MTU > 1500 {
+		return o, fmt.Errorf("%w: mtu %d", ErrInvalidKCPConfig, o.MTU)
+	}
+	if o.DataShards < 0 || o.ParityShards < 0 {
+		return o, fmt.Errorf("%w: fec shards %d/%d", ErrInvalidKCPConfig, o.DataShards, o.ParityShards)
+	}
+	return o, nil
+}
+
+// apply 流模式承载与 TCP 相同的长度前缀帧；写入不延迟，由 KCP 的 interval 合并发送
+func (o KCPOptions) apply(sess *kcp.UDPSession) {
+	nodelay, nc := 0, 0
+	if o.NoDelay {
+		nodelay = 1
+	}
+	if o.NoCongestion {
+		nc = 1
+	}
+	sess.SetStreamMode(true)
+	sess.SetWriteDelay(false)
+	sess.SetNoDelay(nodelay, int(o.Interval/time.Millisecond), o.Resend, nc)
+	sess.SetACKNoDelay(o.NoDelay)
+	sess.SetWindowSize(o.SendWindow, o.RecvWindow)
+	sess.SetMtu(o.MTU)
+}
+
+// KCPListener 接受 KCP 会话；不支持 TLS，需要加密时在业务层处理
+type KCPListener struct {
+	ln   *kcp.Listener
+	opts KCPOptions
+}
+
+func ListenKCP(addr string, opts KCPOptions) (*KCPListener, error) {
+	opts, err := opts.normalize()
+	if err != nil {
+		return nil, err
+	}
+	ln, err := kcp.ListenWithOptions(addr, nil, opts.DataShards, opts.ParityShards)
+	if err != nil {
+		return nil, err
+	}
+	if opts.SocketBuffer > 0 {
+		_ = ln.SetReadBuffer(opts.SocketBuffer)
+		_ = ln.SetWriteBuffer(opts.SocketBuffer)
+	}
+	return &KCPListener{ln: ln, opts: opts}, nil
+}
+
+// Accept 返回已按配置调好参数的会话（*kcp.UDPSession）
+func (l *KCPListener) Accept() (net.Conn, error) {
+	sess, err := l.ln.AcceptKCP()
+	if err != nil {
+		return nil, err
+	}
+	l.opts.apply(sess)
+	return sess, nil
+}
+
+func (l *KCPListener) Close() error {
+	return l.ln.Close()
+}
+
+func (l *KCPListener) Addr() net.Addr {
+	return l.ln.Addr()
+}
+
+// DialKCP 客户端 / 压测工具使用；conv 由 kcp-go 随机生成
+func DialKCP(addr string, opts KCPOptions) (net.Conn, error) {
+	opts, err := opts.normalize()
+	if err != nil {
+		return nil, err
+	}
+	sess, err := kcp.DialWithOptions(addr, nil, opts.DataShards, opts.ParityShards)
+	if err != nil {
+		return nil, err
+	}
+	opts.apply(sess)
+	return sess, nil
+}
+
+// KCPConn KCP 会话上的 Envelope 连接，帧格式与压缩与 TCP 完全一致
+type KCPConn struct {
+	*BufferedConn
+	conv uint32
+}
+
+func NewKCPConn(conn net.Conn, opts ConnOptions) *KCPConn {
+	c := &KCPConn{BufferedConn: NewBufferedConnWithOptions(conn, opts)}
+	if sess, ok := conn.(*kcp.UDPSession); ok {
+		c.conv = sess.GetConv()
+	}
+	return c
+}
+
+// Conv KCP 会话号（conversation id），客户端每次拨号随机生成
+func (c *KCPConn) Conv() uint32 {
+	return c.conv
+}
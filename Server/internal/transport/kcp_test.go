@@ -0,0 +1,204 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"net"
+	"testing"
+	"time"
+
+	"game-server/internal/protocol/internalpb"
+	"google.golang.org/protobuf/proto"
+)
+
+var kcpTestConnOptions = ConnOptions{ReadTimeout: 5 * time.Second, WriteTimeout: 5 * time.Second}
+
+// kcpPair 拨号并接受一条 KCP 会话；kcp-go 收到第一个数据包才会 Accept，所以客户端先发 hello
+func kcpPair(t *testing.T, opts KCPOptions) (client, server *KCPConn) {
+	t.Helper()
+	ln, err := ListenKCP("127.0.0.1:0", opts)
+	if err != nil {
+		t.Fatalf("listen kcp: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	nc, err := DialKCP(ln.Addr().String(), opts)
+	if err != nil {
+		t.Fatalf("dial kcp: %v", err)
+	}
+	client = NewKCPConn(nc, kcpTestConnOptions)
+	t.Cleanup(func() { _ = client.Close() })
+
+	hello := &internalpb.Envelope{MsgId: 1, SessionId: 7}
+	if err := client.WriteEnvelope(hello); err != nil {
+		t.Fatalf("write hello: %v", err)
+	}
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		sc, err := ln.Accept()
+		if err != nil {
+			close(accepted)
+			return
+		}
+		accepted <- sc
+	}()
+	select {
+	case sc, ok := <-accepted:
+		if !ok {
+			t.Fatal("accept kcp failed")
+		}
+		server = NewKCPConn(sc, kcpTestConnOptions)
+		t.Cleanup(func() { _ = server.Close() })
+	case <-time.After(5 * time.Second):
+		t.Fatal("accept kcp timeout")
+	}
+
+	got, err := server.ReadEnvelope()
+	if err != nil {
+		t.Fatalf("read hello: %v", err)
+	}
+	if !proto.Equal(got, hello) {
+		t.Fatalf("hello = %v, want %v", got, hello)
+	}
+	return client, server
+}
+
+func TestKCPDialAccept(t *testing.T) {
+	client, server := kcpPair(t, KCPOptions{NoDelay: true, Resend: 2, NoCongestion: true})
+
+	if client.Conv() == 0 {
+		t.Fatal("client conv is 0")
+	}
+	if client.Conv() != server.Conv() {
+		t.Fatalf("conv mismatch: client %d server %d", client.Conv(), server.Conv())
+	}
+}
+
+func TestKCPEnvelopeRoundTrip(t *testing.T) {
+	client, server := kcpPair(t, KCPOptions{})
+
+	envs := []*internalpb.Envelope{
+		{MsgId: 1001, SessionId: 1, PlayerId: 2, Payload: []byte("hello")},
+		{MsgId: 1002, Seq: 99, Codec: "json", Payload: []byte(`{"a":1}`)},
+		{MsgId: 1003, Client: &internalpb.ClientInfo{Platform: "ios", ClientVersion: "1.2.3"}},
+		{MsgId: 1004},
+	}
+
+	// 上行：逐条写
+	for _, env := range envs {
+		if err := client.WriteEnvelope(env); err != nil {
+			t.Fatalf("client write %d: %v", env.MsgId, err)
+		}
+	}
+	for _, want := range envs {
+		got, err := server.ReadEnvelope()
+		if err != nil {
+			t.Fatalf("server read %d: %v", want.MsgId, err)
+		}
+		if !proto.Equal(got, want) {
+			t.Fatalf("upstream got %v, want %v", got, want)
+		}
+	}
+
+	// 下行：合批写，多个帧落在同一次流写入里，读端按长度前缀拆开
+	for _, env := range envs {
+		if err := server.BufferEnvelope(env); err != nil {
+			t.Fatalf("server buffer %d: %v", env.MsgId, err)
+		}
+	}
+	if err := server.Flush(); err != nil {
+		t.Fatalf("server flush: %v", err)
+	}
+	for _, want := range envs {
+		got, err := client.ReadEnvelope()
+		if err != nil {
+			t.Fatalf("client read %d: %v", want.MsgId, err)
+		}
+		if !proto.Equal(got, want) {
+			t.Fatalf("downstream got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestKCPLargeFrames(t *testing.T) {
+	client, server := kcpPair(t, KCPOptions{MTU: 512})
+
+	rng := rand.New(rand.NewSource(1))
+	sizes := []int{511, 512, 513, 64 * 1024, 1024 * 1024}
+	want := make([]*internalpb.Envelope, 0, len(sizes))
+	for i, size := range sizes {
+		payload := make([]byte, size)
+		rng.Read(payload)
+		want = append(want, &internalpb.Envelope{MsgId: int32(2000 + i), Payload: payload})
+	}
+
+	// 1 MiB 的帧超过收发窗口，必须边写边读，否则写端会卡在窗口上
+	errc := make(chan error, 1)
+	go func() {
+		for _, env := range want {
+			if err := client.WriteEnvelope(env); err != nil {
+				errc <- err
+				return
+			}
+		}
+		errc <- nil
+	}()
+
+	for _, w := range want {
+		got, err := server.ReadEnvelope()
+		if err != nil {
+			t.Fatalf("read %d: %v", w.MsgId, err)
+		}
+		if got.MsgId != w.MsgId || !bytes.Equal(got.Payload, w.Payload) {
+			t.Fatalf("frame %d: got %d bytes, want %d bytes", w.MsgId, len(got.Payload), len(w.Payload))
+		}
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+func TestKCPCompressedFrame(t *testing.T) {
+	client, server := kcpPair(t, KCPOptions{})
+	server.SetCompression(internalpb.Compression_COMPRESSION_ZSTD)
+
+	payload := bytes.Repeat([]byte("kcp-stream-frame "), 8*1024)
+	want := &internalpb.Envelope{MsgId: 3001, Payload: payload}
+	if err := server.WriteEnvelope(want); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	got, err := client.ReadEnvelope()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if got.Compression != internalpb.Compression_COMPRESSION_NONE || !bytes.Equal(got.Payload, payload) {
+		t.Fatalf("got compression %v, %d bytes; want decompressed %d bytes", got.Compression, len(got.Payload), len(payload))
+	}
+}
+
+func TestKCPOptionsNormalize(t *testing.T) {
+	opts, err := KCPOptions{}.normalize()
+	if err != nil {
+		t.Fatalf("normalize zero options: %v", err)
+	}
+	if opts.Interval != defaultKCPInterval || opts.SendWindow != defaultKCPWindow ||
+		opts.RecvWindow != defaultKCPWindow || opts.MTU != defaultKCPMTU {
+		t.Fatalf("defaults not applied: %+v", opts)
+	}
+
+	for _, bad := range []KCPOptions{
+		{MTU: 10},
+		{MTU: 9000},
+		{DataShards: -1},
+		{ParityShards: -1},
+	} {
+		if _, err := bad.normalize(); !errors.Is(err, ErrInvalidKCPConfig) {
+			t.Fatalf("normalize(%+v) = %v, want ErrInvalidKCPConfig", bad, err)
+		}
+	}
+	if _, err := ListenKCP("127.0.0.1:0", KCPOptions{MTU: 10}); !errors.Is(err, ErrInvalidKCPConfig) {
+		t.Fatalf("ListenKCP with bad mtu = %v, want ErrInvalidKCPConfig", err)
+	}
+}
@@ -0,0 +1,84 @@
+package transport
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"game-server/internal/protocol/internalpb"
+	"google.golang.org/protobuf/proto"
+)
+
+// frameClasses 帧缓冲的尺寸档位；超过最大档的帧直接分配，不放回池里，避免池子长期持有大块内存
+var frameClasses = [...]int{512, 2 << 10, 8 << 10, 32 << 10, 128 << 10}
+
+var framePools [len(frameClasses)]sync.Pool
+
+// getFrame 取一块长度为 size 的缓冲，用完调用 putFrame 归还
+func getFrame(size int) *[]byte {
+	for i, class := range frameClasses {
+		if size > class {
+			continue
+		}
+		if p, ok := framePools[i].Get().(*[]byte); ok {
+			*p = (*p)[:size]
+			return p
+		}
+		b := make([]byte, size, class)
+		return &b
+	}
+	b := make([]byte, size)
+	return &b
+}
+
+func putFrame(p *[]byte) {
+	c := cap(*p)
+	for i, class := range frameClasses {
+		if c == class {
+			*p = (*p)[:0]
+			framePools[i].Put(p)
+			return
+		}
+	}
+}
+
+// envelopePool 只用于不会逃出写路径的临时 Envelope（如下行压缩后的副本）；
+// 读到的 Envelope 会交给业务层长期持有，不能池化
+var envelopePool = sync.Pool{
+	New: func() any { return new(internalpb.Envelope) },
+}
+
+func getEnvelope() *internalpb.Envelope {
+	return envelopePool.Get().(*internalpb.Envelope)
+}
+
+func putEnvelope(env *internalpb.Envelope) {
+	env.Reset()
+	envelopePool.Put(env)
+}
+
+// copyEnvelopeHeader 复制 Payload / Compression 以外的全部字段（Client 共享指针）；
+// ⭐ Envelope 新增字段时同步这里，否则压缩 / 内联后的副本会丢字段
+func copyEnvelopeHeader(dst, src *internalpb.Envelope) {
+	dst.MsgId = src.MsgId
+	dst.SessionId = src.SessionId
+	dst.PlayerId = src.PlayerId
+	dst.Seq = src.Seq
+	dst.Client = src.Client
+	dst.Codec = src.Codec
+}
+
+var frameMarshal = proto.MarshalOptions{UseCachedSize: true}
+
+// encodeFrame 编码为 4 字节大端长度 + Envelope，一次写入；返回的缓冲用完调用 putFrame 归还
+func encodeFrame(env *internalpb.Envelope) (*[]byte, error) {
+	size := proto.Size(env)
+	p := getFrame(4 + size)
+	data, err := frameMarshal.MarshalAppend((*p)[:4], env)
+	if err != nil {
+		putFrame(p)
+		return nil, err
+	}
+	binary.BigEndian.PutUint32(data[:4], uint32(len(data)-4))
+	*p = data
+	return p, nil
+}
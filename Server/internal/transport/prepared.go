@@ -0,0 +1,96 @@
+package transport
+
+import (
+	"sync"
+
+	"game-server/internal/protocol/internalpb"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+)
+
+// PreparedEnvelope 广播 / 组播用：同一个 Envelope 只编码一次，所有连接共享编码结果
+type PreparedEnvelope struct {
+	Env *internalpb.Envelope
+
+	binOnce sync.Once
+	bin     []byte
+	binErr  error
+
+	jsonOnce sync.Once
+	json     []byte
+	jsonErr  error
+
+	wsBinOnce  sync.Once
+	wsBin      *websocket.PreparedMessage
+	wsBinErr   error
+	wsJSONOnce sync.Once
+	wsJSON     *websocket.PreparedMessage
+	wsJSONErr  error
+
+	// 按压缩算法缓存的压缩版本，同一算法的连接共享
+	compOnce [compressionKinds]sync.Once
+	comp     [compressionKinds]*PreparedEnvelope
+}
+
+// PreparedWriter 支持直接写预编码消息的连接
+type PreparedWriter interface {
+	WritePrepared(*PreparedEnvelope) error
+}
+
+func NewPreparedEnvelope(env *internalpb.Envelope) *PreparedEnvelope {
+	return &PreparedEnvelope{Env: env}
+}
+
+func (p *PreparedEnvelope) Binary() ([]byte, error) {
+	p.binOnce.Do(func() {
+		p.bin, p.binErr = proto.Marshal(p.Env)
+	})
+	return p.bin, p.binErr
+}
+
+func (p *PreparedEnvelope) JSON() ([]byte, error) {
+	p.jsonOnce.Do(func() {
+		p.json, p.jsonErr = marshalJSONEnvelope(p.Env)
+	})
+	return p.json, p.jsonErr
+}
+
+func (p *PreparedEnvelope) wsMessage(useJSON bool) (*websocket.PreparedMessage, error) {
+	if useJSON {
+		p.wsJSONOnce.Do(func() {
+			data, err := p.JSON()
+			if err != nil {
+				p.wsJSONErr = err
+				return
+			}
+			p.wsJSON, p.wsJSONErr = websocket.NewPreparedMessage(websocket.TextMessage, data)
+		})
+		return p.wsJSON, p.wsJSONErr
+	}
+	p.wsBinOnce.Do(func() {
+		data, err := p.Binary()
+		if err != nil {
+			p.wsBinErr = err
+			return
+		}
+		p.wsBin, p.wsBinErr = websocket.NewPreparedMessage(websocket.BinaryMessage, data)
+	})
+	return p.wsBin, p.wsBinErr
+}
+
+// compressed 返回按 algo 压缩后的版本；不需要压缩时返回自身
+func (p *PreparedEnvelope) compressed(algo internalpb.Compression) *PreparedEnvelope {
+	if algo <= internalpb.Compression_COMPRESSION_NONE || int(algo) >= compressionKinds {
+		return p
+	}
+	p.compOnce[algo].Do(func() {
+		env := compressPayload(p.Env, algo)
+		if env == p.Env {
+			p.comp[algo] = p
+			return
+		}
+		p.comp[algo] = NewPreparedEnvelope(env)
+	})
+	return p.comp[algo]
+}
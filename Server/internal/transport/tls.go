@@ -0,0 +1,211 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"game-server/internal/config"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidTLSConfig = errors.New("invalid tls config")
+
+// TLSOptions CertFile 为空表示不启用 TLS
+type TLSOptions struct {
+	CertFile   string
+	KeyFile    string
+	CAFile     string // 校验对端证书的 CA；为空时客户端用系统根证书，服务端不校验客户端证书
+	MinVersion uint16
+	ClientAuth tls.ClientAuthType // 仅服务端
+	ServerName string             // 仅客户端；为空时取拨号地址中的主机名
+}
+
+// TLSProvider 持有当前生效的证书，Reload 后新握手立即使用新证书，已建立的连接不受影响
+type TLSProvider struct {
+	opts TLSOptions
+	cfg  atomic.Pointer[tls.Config]
+}
+
+func NewTLSProvider(opts TLSOptions) (*TLSProvider, error) {
+	if opts.CertFile == "" || opts.KeyFile == "" {
+		return nil, fmt.Errorf("%w: cert_file and key_file required", ErrInvalidTLSConfig)
+	}
+	if opts.MinVersion == 0 {
+		opts.MinVersion = tls.VersionTLS12
+	}
+	p := &TLSProvider{opts: opts}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// TLSFromConfig 按配置创建 TLSProvider；cert_file 为空时返回 nil（明文）
+func TLSFromConfig(cfg config.TLSConfig, defaultAuth tls.ClientAuthType) (*TLSProvider, error) {
+	if cfg.CertFile == "" {
+		return nil, nil
+	}
+	minVersion, err := ParseTLSVersion(cfg.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	clientAuth, err := ParseClientAuth(cfg.ClientAuth, defaultAuth)
+	if err != nil {
+		return nil, err
+	}
+	return NewTLSProvider(TLSOptions{
+		CertFile:   cfg.CertFile,
+		KeyFile:    cfg.KeyFile,
+		CAFile:     cfg.CAFile,
+		MinVersion: minVersion,
+		ClientAuth: clientAuth,
+		ServerName: cfg.ServerName,
+	})
+}
+
+// ReloadTLS 证书路径不变，只重新读取文件（证书轮换）；nil 跳过，失败的保留旧证书
+func ReloadTLS(logger *zap.Logger, providers ...*TLSProvider) {
+	for _, p := range providers {
+		if p == nil {
+			continue
+		}
+		if err := p.Reload(); err != nil {
+			logger.Warn("reload tls failed",
+				zap.String("cert_file", p.opts.CertFile),
+				zap.String("reason", err.Error()),
+			)
+			continue
+		}
+		logger.Info("tls reloaded", zap.String("cert_file", p.opts.CertFile))
+	}
+}
+
+// WatchTLSReload 每收到一次 SIGHUP 执行 ReloadTLS，ctx 结束时返回；
+// 只需轮换证书的进程直接 go 它，SIGHUP 还要重载其他配置的进程在自己的循环里调 ReloadTLS
+func WatchTLSReload(ctx context.Context, logger *zap.Logger, providers ...*TLSProvider) {
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	defer signal.Stop(hupCh)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hupCh:
+		}
+		ReloadTLS(logger, providers...)
+	}
+}
+
+// Reload 重新读取证书 / CA 文件；失败时保留旧证书
+func (p *TLSProvider) Reload() error {
+	cert, err := tls.LoadX509KeyPair(p.opts.CertFile, p.opts.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load key pair: %w", err)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   p.opts.MinVersion,
+		ClientAuth:   p.opts.ClientAuth,
+		ServerName:   p.opts.ServerName,
+	}
+	if p.opts.CAFile != "" {
+		pem, err := os.ReadFile(p.opts.CAFile)
+		if err != nil {
+			return fmt.Errorf("read ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("%w: no certificate in %s", ErrInvalidTLSConfig, p.opts.CAFile)
+		}
+		cfg.RootCAs = pool
+		cfg.ClientCAs = pool
+	}
+	p.cfg.Store(cfg)
+	return nil
+}
+
+// ServerConfig 每次握手取最新的证书
+func (p *TLSProvider) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: p.opts.MinVersion,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return p.cfg.Load(), nil
+		},
+	}
+}
+
+func (p *TLSProvider) clientConfig(addr string) *tls.Config {
+	cfg := p.cfg.Load().Clone()
+	if cfg.ServerName == "" {
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			cfg.ServerName = host
+		}
+	}
+	return cfg
+}
+
+// Listen 监听 TCP；opts.TLS 非空时为 TLS（内部链路同时要求客户端证书即为 mTLS）
+func Listen(addr string, opts ConnOptions) (net.Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if opts.TLS == nil {
+		return ln, nil
+	}
+	return tls.NewListener(ln, opts.TLS.ServerConfig()), nil
+}
+
+// Dial 拨号；opts.TLS 非空时完成 TLS 握手后返回，握手失败视为拨号失败
+func Dial(addr string, timeout time.Duration, opts ConnOptions) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: opts.KeepAlive}
+	if opts.TLS == nil {
+		return dialer.Dial("tcp", addr)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	tlsDialer := &tls.Dialer{NetDialer: dialer, Config: opts.TLS.clientConfig(addr)}
+	return tlsDialer.DialContext(ctx, "tcp", addr)
+}
+
+// ParseTLSVersion "1.2" / "1.3"，为空默认 1.2
+func ParseTLSVersion(s string) (uint16, error) {
+	switch strings.TrimSpace(s) {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("%w: min_version %s", ErrInvalidTLSConfig, s)
+	}
+}
+
+// ParseClientAuth none / request / require / verify_if_given / require_and_verify；为空时返回 def
+func ParseClientAuth(s string, def tls.ClientAuthType) (tls.ClientAuthType, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "":
+		return def, nil
+	case "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "require":
+		return tls.RequireAnyClientCert, nil
+	case "verify_if_given":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require_and_verify":
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return 0, fmt.Errorf("%w: client_auth %s", ErrInvalidTLSConfig, s)
+	}
+}
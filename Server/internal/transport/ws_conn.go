@@ -1,23 +1,27 @@
 package transport
 
 import (
+	"net"
+	"sync/atomic"
+
+	"game-server/internal/protocol/codec"
 	"game-server/internal/protocol/internalpb"
 
 	"github.com/gorilla/websocket"
-	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 )
 
 type WSConn struct {
 	conn    *websocket.Conn
-	useJSON bool
+	useJSON atomic.Bool // 下行走文本帧；上行按帧类型自动识别
+
+	compression atomic.Int32 // internalpb.Compression，与 BufferedConn 一致
 }
 
 func NewWSConn(conn *websocket.Conn, useJSON bool) *WSConn {
-	return &WSConn{
-		conn:    conn,
-		useJSON: useJSON,
-	}
+	c := &WSConn{conn: conn}
+	c.useJSON.Store(useJSON)
+	return c
 }
 
 func (c *WSConn) ReadEnvelope() (*internalpb.Envelope, error) {
@@ -28,7 +32,7 @@ func (c *WSConn) ReadEnvelope() (*internalpb.Envelope, error) {
 	var env internalpb.Envelope
 	switch messageType {
 	case websocket.TextMessage:
-		if err := protojson.Unmarshal(data, &env); err != nil {
+		if err := unmarshalJSONEnvelope(data, &env); err != nil {
 			return nil, err
 		}
 	default:
@@ -36,22 +40,58 @@ func (c *WSConn) ReadEnvelope() (*internalpb.Envelope, error) {
 			return nil, err
 		}
 	}
+	if err := decompressPayload(&env); err != nil {
+		return nil, err
+	}
 	return &env, nil
 }
 
+// SetCompression 设置下行 payload 压缩算法；开启 permessage-deflate 时通常不需要再压缩
+func (c *WSConn) SetCompression(algo internalpb.Compression) {
+	c.compression.Store(int32(algo))
+}
+
+// SetCodec 握手协商后切换下行帧格式：json 走文本帧，其余编码走二进制帧，之后的写入立即生效
+func (c *WSConn) SetCodec(name string) error {
+	cc, err := codec.Get(name)
+	if err != nil {
+		return err
+	}
+	c.useJSON.Store(cc.Name() == codec.NameJSON)
+	return nil
+}
+
 func (c *WSConn) WriteEnvelope(env *internalpb.Envelope) error {
-	if c.useJSON {
-		data, err := protojson.Marshal(env)
+	env, release := compressForWrite(env, internalpb.Compression(c.compression.Load()))
+	defer release()
+	if c.useJSON.Load() {
+		data, err := marshalJSONEnvelope(env)
 		if err != nil {
 			return err
 		}
 		return c.conn.WriteMessage(websocket.TextMessage, data)
 	}
-	data, err := proto.Marshal(env)
+	// WriteMessage 会拷贝到自己的帧缓冲，编码缓冲写完即可归还
+	frame, err := encodeFrame(env)
 	if err != nil {
 		return err
 	}
-	return c.conn.WriteMessage(websocket.BinaryMessage, data)
+	err = c.conn.WriteMessage(websocket.BinaryMessage, (*frame)[4:])
+	putFrame(frame)
+	return err
+}
+
+func (c *WSConn) WritePrepared(p *PreparedEnvelope) error {
+	p = p.compressed(internalpb.Compression(c.compression.Load()))
+	msg, err := p.wsMessage(c.useJSON.Load())
+	if err != nil {
+		return err
+	}
+	return c.conn.WritePreparedMessage(msg)
+}
+
+func (c *WSConn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
 }
 
 func (c *WSConn) Close() error {
@@ -4,6 +4,7 @@ setlocal
 set PROTOC=protoc.exe
 set PROTO_DIR=..\internal\protocol\proto
 set OUT_DIR=..\internal\protocol\internalpb
+set MSGID_PLUGIN=..\bin\protoc-gen-msgid.exe
 
 echo ===== Generating Go Protos =====
 
@@ -16,5 +17,26 @@ for %%f in (%PROTO_DIR%\*.proto) do (
         %%f
 )
 
+echo ===== Generating MsgIDs =====
+
+REM 插件读 options.pb.go 里的选项定义，需在上面生成 internalpb 之后再编译
+pushd ..
+go build -o bin\protoc-gen-msgid.exe .\cmd\protoc-gen-msgid || (popd & exit /b 1)
+popd
+
+REM 号段 / msgID 常量、msgtype 表、handler 注册桩；所有 .proto 一次传入
+%PROTOC% ^
+    --proto_path=%PROTO_DIR% ^
+    --plugin=protoc-gen-msgid=%MSGID_PLUGIN% ^
+    --msgid_out=..\internal ^
+    %PROTO_DIR%\*.proto
+
+REM Python 客户端加载的 msgid.json
+%PROTOC% ^
+    --proto_path=%PROTO_DIR% ^
+    --plugin=protoc-gen-msgid=%MSGID_PLUGIN% ^
+    --msgid_out=manifest=true:..\..\Client ^
+    %PROTO_DIR%\*.proto
+
 echo ===== Go Proto Generate Finished =====
 pause